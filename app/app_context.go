@@ -19,14 +19,18 @@ import (
 
 	deroConfig "github.com/deroproject/derohe/config"
 	"github.com/deroproject/derohe/globals"
+	"github.com/g45t345rt/derosphere/commit_sync"
 	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/secure"
 	"github.com/g45t345rt/derosphere/table"
 	"github.com/g45t345rt/derosphere/utils"
 )
 
 type Config struct {
-	Env              string `json:"env"`
-	CloseWalletAfter uint64 `json:"closeWalletAfter"`
+	Env               string `json:"env"`
+	CloseWalletAfter  uint64 `json:"closeWalletAfter"`
+	Output            string `json:"output"`
+	VaultUnlockWindow uint64 `json:"vaultUnlockWindow"` // seconds the vault stays unlocked
 }
 
 type AppContext struct {
@@ -35,32 +39,53 @@ type AppContext struct {
 	rootApp           *cli.App
 	walletApp         *cli.App
 	DAppApp           *cli.App
-	WalletInstance    *WalletInstance
+	WalletInstance    *WalletInstance // session in focus - used by the wallet and dapp commands
 	walletInstances   []*WalletInstance
+	sessions          []*WalletInstance // wallets opened at the same time
+	sessionsLock      sync.Mutex
+	focusLock         sync.RWMutex // guards the writes of WalletInstance, UseApp and DAppApp - the prompt refresh reads them in the background
 	readlineInstance  *readline.Instance
 	DB                *sql.DB
-	StopPromptRefresh bool // prompt auto refresh every second to display block height - use this arg to disable and show other prompt
+	Batch             *Batch                       // answers prompts when running non-interactively - nil in the REPL
+	StopPromptRefresh bool                         // prompt auto refresh every second to display block height - use this arg to disable and show other prompt
+	SyncEngines       func() []*commit_sync.Engine // dapp engines synced in the background while a wallet is opened
+	DAppAssetSCIDs    func() ([]string, error)     // assets found in the synced dapp tables - candidates of the asset discovery
+	masterKey         *secure.Key                  // nil without master password
+	memoryConn        *sql.Conn                    // keeps the in memory db alive while a master password is set
+	saveLock          sync.Mutex
+	sealedFilename    string // sealed file of the loaded db
+	savedHash         []byte // hash of the last sealed db snapshot
+	vault             Vault
+	commandLine       string // command running - recorded with the txs it creates
 }
 
 var Context *AppContext
 
-func InitAppContext(rootApp *cli.App, walletApp *cli.App) {
+var ENVS = []string{"mainnet", "testnet", "simulator"}
+
+func InitAppContext(rootApp *cli.App, walletApp *cli.App, batch *Batch) {
 	app := new(AppContext)
 	app.rootApp = rootApp
 	app.walletApp = walletApp
 	app.UseApp = "rootApp"
+	app.Batch = batch
 
-	instance, err := readline.New("")
-	if err != nil {
-		log.Fatal(err)
+	// no terminal in batch mode - prompts are answered by the batch
+	if batch == nil {
+		instance, err := readline.New("")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		app.readlineInstance = instance
 	}
 
-	app.readlineInstance = instance
+	// the master password prompt needs the context
+	Context = app
+
 	app.LoadConfig()
 	app.LoadDB()
 	app.LoadWalletInstances()
-
-	Context = app
 }
 
 func (app *AppContext) Run() {
@@ -90,10 +115,10 @@ func (app *AppContext) Run() {
 		for {
 			if !app.StopPromptRefresh {
 				closeWalletAfter := time.Duration(app.Config.CloseWalletAfter) * time.Second
-				if closeWalletAfter > 0 && app.WalletInstance != nil && time.Now().After(lastActivity.Add(closeWalletAfter)) {
+				if closeWalletAfter > 0 && len(app.Sessions()) > 0 && time.Now().After(lastActivity.Add(closeWalletAfter)) {
 					m.Lock()
 					app.ResetRootApp()
-					fmt.Printf("\nWallet close after %ds of inactivity.\n", app.Config.CloseWalletAfter)
+					fmt.Printf("\nWallets closed after %ds of inactivity.\n", app.Config.CloseWalletAfter)
 					m.Unlock()
 				}
 			}
@@ -125,57 +150,98 @@ out:
 			break out
 		}
 
-		switch app.UseApp {
-		case "rootApp":
-			app.rootApp.Run(strings.Fields("cmd " + line))
-		case "walletApp":
-			app.walletApp.Run(strings.Fields("cmd " + line))
-		case "dappApp":
-			app.DAppApp.Run(strings.Fields("cmd " + line))
+		err = app.RunLine(line)
+		if err != nil {
+			PrintCommandErr(err)
 		}
 	}
+
+	app.ResetRootApp()
+	err := app.CloseDB()
+	if err != nil {
+		PrintCommandErr(err)
+	}
 }
 
-func (app *AppContext) ResetRootApp() {
-	if app.WalletInstance != nil {
-		app.WalletInstance.Close()
-		app.WalletInstance = nil
+// RunLine dispatches a command line to the app currently in use (root, wallet or dapp)
+func (app *AppContext) RunLine(line string) error {
+	err := app.runLine(line)
+
+	// a sealed db is only written after commands
+	saveErr := app.SaveDB()
+	if err != nil {
+		return err
 	}
 
-	app.UseApp = "rootApp"
-	app.DAppApp = nil
+	return saveErr
+}
+
+func (app *AppContext) runLine(line string) error {
+	args := strings.Fields("cmd " + line)
+	app.commandLine = strings.Join(args[1:], " ")
+	defer func() { app.commandLine = "" }()
+
+	// --wallet name runs the command on another session without changing the focus
+	name, args := targetWallet(args)
+	if name != "" {
+		return app.runOnWallet(name, args)
+	}
+
+	switch app.UseApp {
+	case "rootApp":
+		return app.rootApp.Run(args)
+	case "walletApp":
+		return app.walletApp.Run(args)
+	case "dappApp":
+		return app.DAppApp.Run(args)
+	}
+
+	return nil
+}
+
+// ResetRootApp closes every wallet session and goes back to the root app
+func (app *AppContext) ResetRootApp() {
+	app.CloseSessions()
+	app.setFocus(nil, "rootApp", nil)
 }
 
 func (app *AppContext) LoadDB() {
-	dataFolder := config.DATA_FOLDER
-	utils.CreateFoldersIfNotExists(dataFolder)
-	db, err := sql.Open("sqlite3", dataFolder+"/"+app.Config.Env+".db")
+	err := app.loadDB()
 	if err != nil {
 		log.Fatal(err)
 	}
+}
 
-	sql := `
-		create table if not exists app_wallets (
-			id integer primary key,
-			name varchar unique,
-			daemon_rpc varchar,
-			wallet_rpc varchar,
-			wallet_path varchar
-		);
-	`
+func (app *AppContext) loadDB() error {
+	utils.CreateFoldersIfNotExists(config.DATA_FOLDER)
+
+	var db *sql.DB
+	var err error
+	if app.masterKey != nil {
+		db, err = app.loadSealedDB()
+	} else {
+		db, err = openDBFile(dbFilename(app.Config.Env))
+	}
 
-	_, err = db.Exec(sql)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	app.DB = db
+
+	err = initMigrations(db)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.Migrate(AppMigrations())
+	return err
 }
 
-func (app *AppContext) setEnvGlobals() {
+func setEnvGlobals(env string) {
 	// we need this if want to use wallet SetOnlineMode() and sync wallet with daemon
 	globals.Arguments["--simulator"] = false
-	switch app.Config.Env {
+	switch env {
 	case "mainnet":
 		globals.Config = deroConfig.Mainnet
 	case "testnet":
@@ -186,14 +252,68 @@ func (app *AppContext) setEnvGlobals() {
 	}
 }
 
-func (app *AppContext) SetEnv(env string) {
+func IsValidEnv(env string) bool {
+	for _, e := range ENVS {
+		if e == env {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UseEnv switches environment without saving it to the config file
+func (app *AppContext) UseEnv(env string) error {
+	if !IsValidEnv(env) {
+		return fmt.Errorf("invalid environment [%s] - valid env are %s", env, strings.Join(ENVS, ", "))
+	}
+
+	// sessions belong to the wallets of the env db
+	app.ResetRootApp()
+
+	err := app.CloseDB()
+	if err != nil {
+		return err
+	}
+
 	app.Config.Env = env
 
-	app.setEnvGlobals()
-	app.SaveConfig()
-	app.DB.Close()
+	setEnvGlobals(app.Config.Env)
+	app.LockVault()
 	app.LoadDB()
 	app.LoadWalletInstances()
+	return nil
+}
+
+func (app *AppContext) SetEnv(env string) error {
+	err := app.UseEnv(env)
+	if err != nil {
+		return err
+	}
+
+	app.SaveConfig()
+	return nil
+}
+
+// UseOutput changes output format without saving it to the config file
+func (app *AppContext) UseOutput(output string) error {
+	if !utils.IsValidOutput(output) {
+		return fmt.Errorf("invalid output [%s] - valid output are %s", output, strings.Join(utils.OUTPUTS, ", "))
+	}
+
+	app.Config.Output = output
+	utils.Output = output
+	return nil
+}
+
+func (app *AppContext) SetOutput(output string) error {
+	err := app.UseOutput(output)
+	if err != nil {
+		return err
+	}
+
+	app.SaveConfig()
+	return nil
 }
 
 func (app *AppContext) SetWalletInactivity(timeout uint64) {
@@ -218,25 +338,47 @@ func (app *AppContext) GetWalletInstances() []*WalletInstance {
 func (app *AppContext) RefreshPrompt() {
 	prompt := fmt.Sprintf("[%s] > ", app.Config.Env)
 
-	if app.WalletInstance != nil {
-		daemonHeight, err := app.WalletInstance.Daemon.GetHeight()
-		if err != nil {
-			fmt.Println(err)
-			app.ResetRootApp()
-			return
+	w, dappApp := app.focus()
+	if w != nil {
+		// keep the wallet opened if the daemon is down - the node pool switches to another node when available
+		heights := "offline"
+		daemonHeight, err := w.Daemon.GetHeight()
+		if err == nil {
+			walletHeight, err := w.GetHeight()
+			if err == nil {
+				heights = fmt.Sprintf("%d/%d", walletHeight, daemonHeight.Height)
+			}
 		}
 
-		walletHeight, err := app.WalletInstance.GetHeight()
-		if err != nil {
-			fmt.Println(err)
-			app.ResetRootApp()
-			return
+		status := fmt.Sprintf("(%s)", heights)
+
+		syncWorker := w.SyncWorker()
+		if syncWorker != nil {
+			prefix := ""
+			if dappApp != nil {
+				prefix = dappApp.Name
+			}
+
+			status = fmt.Sprintf("%s (%s)", status, syncWorker.PromptStatus(prefix))
+		}
+
+		txTracker := w.TxTracker()
+		if txTracker != nil {
+			txStatus := txTracker.PromptStatus()
+			if txStatus != "" {
+				status = fmt.Sprintf("%s (%s)", status, txStatus)
+			}
+		}
+
+		name := w.Name
+		if others := len(app.Sessions()) - 1; others > 0 {
+			name = fmt.Sprintf("%s (+%d)", name, others)
 		}
 
-		prompt = fmt.Sprintf("[%s] (%d/%d) > %s > ", app.Config.Env, walletHeight, daemonHeight.Height, app.WalletInstance.Name)
+		prompt = fmt.Sprintf("[%s] %s > %s > ", app.Config.Env, status, name)
 
-		if app.DAppApp != nil {
-			prompt = fmt.Sprintf("%s%s > ", prompt, app.DAppApp.Name)
+		if dappApp != nil {
+			prompt = fmt.Sprintf("%s%s > ", prompt, dappApp.Name)
 		}
 	}
 
@@ -245,7 +387,20 @@ func (app *AppContext) RefreshPrompt() {
 }
 
 func (app *AppContext) LoadConfig() {
-	content, err := ioutil.ReadFile("./data/config.json")
+	var content []byte
+	var err error
+	if fileExists(sealedConfigFilename()) {
+		content, err = app.loadSealedConfig()
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		content, err = ioutil.ReadFile(configFilename())
+	}
+
+	// kept by config files saved before the vault existed
+	app.Config.VaultUnlockWindow = 300 // default 300s (5min)
+
 	if err != nil {
 		app.Config.Env = config.START_ENV
 		app.Config.CloseWalletAfter = 180 // default 180s (3min)
@@ -256,26 +411,66 @@ func (app *AppContext) LoadConfig() {
 		}
 	}
 
-	app.setEnvGlobals()
+	if !utils.IsValidOutput(app.Config.Output) {
+		app.Config.Output = utils.OUTPUT_TABLE
+	}
+
+	utils.Output = app.Config.Output
+	setEnvGlobals(app.Config.Env)
 }
 
 func (app *AppContext) SaveConfig() {
-	configString, err := json.Marshal(app.Config)
+	err := app.saveConfig()
 	if err != nil {
 		log.Fatal(err)
 	}
+}
 
-	err = ioutil.WriteFile("./data/config.json", configString, os.ModePerm)
+// configContent returns the config file content and its filename - sealed with the key if not nil.
+// The other filename is the config file to remove.
+func (app *AppContext) configContent(key *secure.Key) ([]byte, string, string, error) {
+	content, err := json.Marshal(app.Config)
 	if err != nil {
-		log.Fatal(err)
+		return nil, "", "", err
+	}
+
+	if key == nil {
+		return content, configFilename(), sealedConfigFilename(), nil
+	}
+
+	content, err = key.Seal(content)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	return content, sealedConfigFilename(), configFilename(), nil
+}
+
+func (app *AppContext) saveConfig() error {
+	content, filename, removeFilename, err := app.configContent(app.masterKey)
+	if err != nil {
+		return err
+	}
+
+	err = secure.WriteFile(filename, content)
+	if err != nil {
+		return err
 	}
+
+	err = os.Remove(removeFilename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
 }
 
 func (app *AppContext) LoadWalletInstances() {
 	app.walletInstances = []*WalletInstance{}
 
 	query := `
-		select * from app_wallets
+		select id, name, daemon_rpc, wallet_rpc, wallet_path, watch_address
+		from app_wallets
 	`
 
 	rows, err := app.DB.Query(query)
@@ -290,12 +485,14 @@ func (app *AppContext) LoadWalletInstances() {
 			&walletInstance.DaemonAddress,
 			&walletInstance.WalletAddress,
 			&walletInstance.WalletPath,
+			&walletInstance.WatchAddress,
 		)
 
 		if err != nil {
 			log.Fatal(err)
 		}
 
+		walletInstance.WalletPath = loadedWalletPath(walletInstance.WalletPath)
 		app.walletInstances = append(app.walletInstances, walletInstance)
 	}
 }
@@ -306,8 +503,23 @@ func (app *AppContext) DisplayTable(count int, rowFunc TableRowDef, headers []in
 	headerFmt := color.New(color.FgGreen, color.Underline).SprintfFunc()
 	columnFmt := color.New(color.FgYellow).SprintfFunc()
 
+	if utils.Output != utils.OUTPUT_TABLE {
+		var rows [][]interface{}
+		for i := 0; i < count; i++ {
+			rows = append(rows, rowFunc(i))
+		}
+
+		utils.PrintRecords(headers, rows)
+		return
+	}
+
 	cursor := 0
 
+	// can't wait for keypress without a terminal so print everything at once
+	if app.readlineInstance == nil {
+		pageSize = count
+	}
+
 	tbl := table.New(headers...)
 	tbl.WithHeaderFormatter(headerFmt).WithFirstColumnFormatter(columnFmt)
 	headerPrinted := false
@@ -0,0 +1,51 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/config"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// newTestContext sets a simulator app context with its db in a temp data folder
+func newTestContext(t *testing.T) *AppContext {
+	t.Helper()
+
+	config.DATA_FOLDER = t.TempDir()
+	Context = &AppContext{Config: Config{Env: "simulator"}}
+	err := Context.loadDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		Context.CloseDB()
+	})
+
+	return Context
+}
+
+// newTestWallet returns a registered wallet holding 1000 DERO
+func newTestWallet(id int64) (*WalletInstance, *MemoryWalletBackend) {
+	backend := NewMemoryWalletBackend(fmt.Sprintf("deto1wallet%d", id))
+	backend.Registered = true
+	backend.Balances[crypto.Hash{}] = 1000 * 100000
+
+	w := &WalletInstance{
+		Id:      id,
+		Name:    fmt.Sprintf("wallet%d", id),
+		Backend: backend,
+	}
+
+	return w, backend
+}
+
+func deroTransfer(destination string, amount uint64) *rpc.Transfer_Params {
+	return &rpc.Transfer_Params{
+		Ringsize:  2,
+		Transfers: []rpc.Transfer{{Destination: destination, Amount: amount}},
+	}
+}
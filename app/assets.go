@@ -0,0 +1,279 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/utils"
+)
+
+// Standards of the tokens held in a wallet
+const (
+	ASSET_DERO    = "DERO"
+	ASSET_G45_AT  = "G45-AT"
+	ASSET_G45_FAT = "G45-FAT"
+	ASSET_G45_NFT = "G45-NFT"
+	ASSET_G45_C   = "G45-C"   // collection of assets - not a token
+	ASSET_UNKNOWN = "unknown" // smart contract that is not a token standard
+)
+
+// Asset is the token standard of a scid and its metadata - cached because the code of a smart contract never changes
+type Asset struct {
+	SCID           crypto.Hash
+	Standard       string
+	Decimals       uint64
+	Collection     string
+	MetadataFormat string
+	Metadata       string
+	Checked        int64
+}
+
+func initAssetTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_assets (
+			scid varchar primary key,
+			standard varchar,
+			decimals integer,
+			collection varchar,
+			metadata_format varchar,
+			metadata varchar,
+			checked bigint
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+// IsToken is true for DERO and the G45 token standards
+func (a *Asset) IsToken() bool {
+	switch a.Standard {
+	case ASSET_DERO, ASSET_G45_AT, ASSET_G45_FAT, ASSET_G45_NFT:
+		return true
+	}
+
+	return false
+}
+
+// Name returns the name in the json metadata of the token or its scid
+func (a *Asset) Name() string {
+	if a.SCID.IsZero() {
+		return ASSET_DERO
+	}
+
+	var metadata map[string]interface{}
+	if a.MetadataFormat == "json" && json.Unmarshal([]byte(a.Metadata), &metadata) == nil {
+		if name, ok := metadata["name"].(string); ok && name != "" {
+			return name
+		}
+	}
+
+	return a.SCID.String()
+}
+
+// FormatAmount applies the decimals of the token to an atomic amount
+func (a *Asset) FormatAmount(amount uint64) string {
+	if a.SCID.IsZero() {
+		return globals.FormatMoney(amount)
+	}
+
+	return formatDecimals(new(big.Int).SetUint64(amount), a.Decimals)
+}
+
+// FormatDelta formats the difference between two atomic amounts with a sign
+func (a *Asset) FormatDelta(from uint64, to uint64) string {
+	if to == from {
+		return a.FormatAmount(0)
+	}
+
+	if to > from {
+		return "+" + a.FormatAmount(to-from)
+	}
+
+	return "-" + a.FormatAmount(from-to)
+}
+
+func formatDecimals(amount *big.Int, decimals uint64) string {
+	if decimals == 0 {
+		return amount.String()
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), new(big.Int).SetUint64(decimals), nil)
+	whole, fraction := new(big.Int).QuoRem(amount, divisor, new(big.Int))
+	return fmt.Sprintf("%s.%0*s", whole, int(decimals), fraction)
+}
+
+func (app *AppContext) GetAsset(scid crypto.Hash) (*Asset, error) {
+	query := `
+		select scid, standard, decimals, collection, metadata_format, metadata, checked
+		from app_assets
+		where scid = ?
+	`
+
+	a := new(Asset)
+	var sScid string
+	err := app.DB.QueryRow(query, scid.String()).Scan(&sScid, &a.Standard, &a.Decimals, &a.Collection, &a.MetadataFormat, &a.Metadata, &a.Checked)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	a.SCID = crypto.HashHexToHash(sScid)
+	return a, nil
+}
+
+func (app *AppContext) saveAsset(a *Asset) error {
+	query := `
+		insert or replace into app_assets (scid, standard, decimals, collection, metadata_format, metadata, checked)
+		values (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := app.DB.Exec(query, a.SCID.String(), a.Standard, a.Decimals, a.Collection, a.MetadataFormat, a.Metadata, a.Checked)
+	return err
+}
+
+// parseAsset finds the G45 standard of the smart contract code - the variables are only parsed for a known standard
+func parseAsset(scid crypto.Hash, result *rpc.GetSC_Result) *Asset {
+	a := &Asset{SCID: scid, Standard: ASSET_UNKNOWN}
+
+	at := utils.G45_AT{}
+	if ok, _ := at.Validate(result.Code); ok {
+		a.Standard = ASSET_G45_AT
+		if at.Parse(scid.String(), result) == nil {
+			a.Decimals, a.Collection, a.MetadataFormat, a.Metadata = at.Decimals, at.Collection, at.MetadataFormat, at.Metadata
+		}
+
+		return a
+	}
+
+	fat := utils.G45_FAT{}
+	if ok, _ := fat.Validate(result.Code); ok {
+		a.Standard = ASSET_G45_FAT
+		if fat.Parse(scid.String(), result) == nil {
+			a.Decimals, a.Collection, a.MetadataFormat, a.Metadata = fat.Decimals, fat.Collection, fat.MetadataFormat, fat.Metadata
+		}
+
+		return a
+	}
+
+	nft := utils.G45_NFT{}
+	if ok, _ := nft.Validate(result.Code); ok {
+		a.Standard = ASSET_G45_NFT
+		if nft.Parse(scid.String(), result) == nil {
+			a.Collection, a.MetadataFormat, a.Metadata = nft.Collection, nft.MetadataFormat, nft.Metadata
+		}
+
+		return a
+	}
+
+	collection := utils.G45_C{}
+	if ok, _ := collection.Validate(result.Code); ok {
+		a.Standard = ASSET_G45_C
+		if collection.Parse(scid.String(), result) == nil {
+			a.MetadataFormat, a.Metadata = collection.MetadataFormat, collection.Metadata
+		}
+	}
+
+	return a
+}
+
+// ClassifyAsset returns the cached standard of the scid or reads the smart contract from the daemon
+func (w *WalletInstance) ClassifyAsset(scid crypto.Hash) (*Asset, error) {
+	if scid.IsZero() {
+		return &Asset{SCID: scid, Standard: ASSET_DERO}, nil
+	}
+
+	a, err := Context.GetAsset(scid)
+	if err != nil || a != nil {
+		return a, err
+	}
+
+	result, err := w.Daemon.GetSC(&rpc.GetSC_Params{
+		SCID:      scid.String(),
+		Code:      true,
+		Variables: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	a = parseAsset(scid, result)
+	a.Checked = time.Now().Unix()
+	err = Context.saveAsset(a)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// KnownSCIDs returns the scids found in the local records of the wallet - tx history, payouts, schedules, policies,
+// watched assets and assets discovered before
+func (app *AppContext) KnownSCIDs(walletId int64) ([]crypto.Hash, error) {
+	query := `
+		select scid from app_tx_history where wallet_id = ?
+		union select scid from app_payout_journal where wallet_id = ?
+		union select scid from app_schedules where wallet_id = ?
+		union select scid from app_wallet_policies where wallet_id = ?
+		union select scid from app_watch_assets where wallet_id = ?
+		union select scid from app_wallet_assets where wallet_id = ?
+		union select value from app_wallet_allowlist where wallet_id = ? and kind = ?
+	`
+
+	rows, err := app.DB.Query(query, walletId, walletId, walletId, walletId, walletId, walletId, walletId, ALLOW_SCID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	found := make(map[crypto.Hash]bool)
+	for rows.Next() {
+		var scid sql.NullString
+		err = rows.Scan(&scid)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(scid.String) == 64 {
+			found[crypto.HashHexToHash(scid.String)] = true
+		}
+	}
+
+	err = rows.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	// assets of the transfers sent by derosphere
+	sent, err := app.WalletSpentSince(walletId, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	for scid := range sent {
+		found[scid] = true
+	}
+
+	var scids []crypto.Hash
+	for scid := range found {
+		if !scid.IsZero() {
+			scids = append(scids, scid)
+		}
+	}
+
+	sort.Slice(scids, func(i, j int) bool {
+		return scids[i].String() < scids[j].String()
+	})
+
+	return scids, nil
+}
@@ -0,0 +1,62 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrMissingAnswer = errors.New("missing answer")
+
+// Batch answers prompts when derosphere runs without a terminal (exec / run).
+// Keyed answers match the prompt text and are never consumed.
+// Queued answers are consumed in order by the next prompt without a keyed answer.
+type Batch struct {
+	keyed  map[string]string
+	queued []string
+}
+
+func NewBatch() *Batch {
+	return &Batch{
+		keyed: make(map[string]string),
+	}
+}
+
+// SetAnswer parses "prompt=value" and always answers the prompt with the value
+func (b *Batch) SetAnswer(keyValue string) error {
+	parts := strings.SplitN(keyValue, "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return fmt.Errorf("invalid answer [%s] - use prompt=value", keyValue)
+	}
+
+	b.keyed[normalizePrompt(parts[0])] = parts[1]
+	return nil
+}
+
+func (b *Batch) QueueAnswers(answers ...string) {
+	b.queued = append(b.queued, answers...)
+}
+
+// ClearQueue drops answers left over by the previous command
+func (b *Batch) ClearQueue() {
+	b.queued = nil
+}
+
+func (b *Batch) Answer(prompt string) (string, error) {
+	value, ok := b.keyed[normalizePrompt(prompt)]
+	if ok {
+		return value, nil
+	}
+
+	if len(b.queued) == 0 {
+		return "", fmt.Errorf("%w for prompt [%s]", ErrMissingAnswer, prompt)
+	}
+
+	value = b.queued[0]
+	b.queued = b.queued[1:]
+	return value, nil
+}
+
+func normalizePrompt(prompt string) string {
+	return strings.ToLower(strings.TrimSpace(prompt))
+}
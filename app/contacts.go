@@ -0,0 +1,256 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+// Sources of a resolved address
+const (
+	ADDRESS_SOURCE_CONTACT     = "contact"
+	ADDRESS_SOURCE_NAMESERVICE = "nameservice"
+	ADDRESS_SOURCE_USERNAME    = "username"
+	ADDRESS_SOURCE_RAW         = "address"
+)
+
+type Contact struct {
+	Id              int64
+	Label           string
+	Address         string
+	Note            string
+	Comment         string        // default comment of transfers to this contact
+	DestinationPort sql.NullInt64 // default destination port of transfers to this contact
+}
+
+type ResolvedAddress struct {
+	Input   string
+	Address string
+	Source  string
+	Contact *Contact // set if resolved from a contact label
+}
+
+func initContactTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_contacts (
+			id integer primary key,
+			label varchar unique,
+			address varchar,
+			note varchar,
+			comment varchar,
+			destination_port bigint
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+func scanContact(row interface{ Scan(...interface{}) error }) (*Contact, error) {
+	contact := new(Contact)
+	err := row.Scan(&contact.Id, &contact.Label, &contact.Address, &contact.Note, &contact.Comment, &contact.DestinationPort)
+	if err != nil {
+		return nil, err
+	}
+
+	return contact, nil
+}
+
+func (app *AppContext) GetContacts() ([]*Contact, error) {
+	query := `
+		select id, label, address, note, comment, destination_port
+		from app_contacts
+		order by label
+	`
+
+	rows, err := app.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var contacts []*Contact
+	for rows.Next() {
+		contact, err := scanContact(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		contacts = append(contacts, contact)
+	}
+
+	return contacts, rows.Err()
+}
+
+// GetContact returns nil if no contact has this label
+func (app *AppContext) GetContact(label string) (*Contact, error) {
+	query := `
+		select id, label, address, note, comment, destination_port
+		from app_contacts
+		where label = ?
+	`
+
+	contact, err := scanContact(app.DB.QueryRow(query, label))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return contact, err
+}
+
+func (app *AppContext) AddContact(contact *Contact) error {
+	query := `
+		insert into app_contacts (label, address, note, comment, destination_port)
+		values (?, ?, ?, ?, ?)
+	`
+
+	res, err := app.DB.Exec(query, contact.Label, contact.Address, contact.Note, contact.Comment, contact.DestinationPort)
+	if err != nil {
+		return err
+	}
+
+	contact.Id, err = res.LastInsertId()
+	return err
+}
+
+func (app *AppContext) EditContact(contact *Contact) error {
+	query := `
+		update app_contacts
+		set label = ?, address = ?, note = ?, comment = ?, destination_port = ?
+		where id = ?
+	`
+
+	_, err := app.DB.Exec(query, contact.Label, contact.Address, contact.Note, contact.Comment, contact.DestinationPort, contact.Id)
+	return err
+}
+
+func (app *AppContext) DelContact(label string) error {
+	res, err := app.DB.Exec(`delete from app_contacts where label == ?`, label)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("contact [%s] does not exists", label)
+	}
+
+	return nil
+}
+
+// usernameAddress looks up the synced username dapp table - empty if the dapp was never opened in this env
+func (app *AppContext) usernameAddress(name string) (string, error) {
+	var count int
+	err := app.DB.QueryRow(`select count(*) from sqlite_master where type = 'table' and name = 'dapps_username'`).Scan(&count)
+	if err != nil || count == 0 {
+		return "", err
+	}
+
+	var address string
+	err = app.DB.QueryRow(`select wallet_address from dapps_username where name == ? limit 1`, name).Scan(&address)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+
+	return address, err
+}
+
+// ResolveAddress resolves input in order contact label, nameservice name, username dapp and raw address.
+// A valid raw address is checked before the lookups that need the daemon. The nameservice is skipped if daemon is nil.
+func (app *AppContext) ResolveAddress(daemon *rpc_client.Daemon, input string) (*ResolvedAddress, error) {
+	resolved := &ResolvedAddress{Input: input}
+
+	contact, err := app.GetContact(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if contact != nil {
+		resolved.Address = contact.Address
+		resolved.Source = ADDRESS_SOURCE_CONTACT
+		resolved.Contact = contact
+		return resolved, nil
+	}
+
+	_, err = globals.ParseValidateAddress(input)
+	if err == nil {
+		resolved.Address = input
+		resolved.Source = ADDRESS_SOURCE_RAW
+		return resolved, nil
+	}
+
+	if daemon != nil {
+		result, err := daemon.NameToAddress(&rpc.NameToAddress_Params{
+			Name:       input,
+			TopoHeight: -1,
+		})
+
+		if err == nil && result.Address != "" {
+			resolved.Address = result.Address
+			resolved.Source = ADDRESS_SOURCE_NAMESERVICE
+			return resolved, nil
+		}
+	}
+
+	address, err := app.usernameAddress(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if address != "" {
+		resolved.Address = address
+		resolved.Source = ADDRESS_SOURCE_USERNAME
+		return resolved, nil
+	}
+
+	return nil, fmt.Errorf("could not resolve [%s] to an address", input)
+}
+
+func (r *ResolvedAddress) String() string {
+	if r.Source == ADDRESS_SOURCE_RAW {
+		return r.Address
+	}
+
+	return fmt.Sprintf("%s (%s %s)", r.Address, r.Source, r.Input)
+}
+
+// BaseAddress returns the address without the arguments of an integrated address
+func (r *ResolvedAddress) BaseAddress() (string, error) {
+	addr, err := rpc.NewAddress(r.Address)
+	if err != nil {
+		return "", err
+	}
+
+	return addr.BaseAddress().String(), nil
+}
+
+// PromptAddress prompts a contact label, name, username or address and prints the resolved address
+func PromptAddress(prompt string) (*ResolvedAddress, error) {
+	input, err := Prompt(prompt, "")
+	if err != nil {
+		return nil, err
+	}
+
+	var daemon *rpc_client.Daemon
+	if Context.WalletInstance != nil {
+		daemon = Context.WalletInstance.Daemon
+	}
+
+	resolved, err := Context.ResolveAddress(daemon, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if resolved.Source != ADDRESS_SOURCE_RAW {
+		fmt.Printf("Address found: %s\n", resolved)
+	}
+
+	return resolved, nil
+}
@@ -0,0 +1,602 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/secure"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// With a master password the env db only lives in memory (sqlite memdb vfs) and is sealed to <env>.db.enc after each command.
+// The config is sealed to config.json.enc and the password is prompted once at startup.
+
+var MASTER_PASSWORD_ATTEMPTS = 3
+
+var ErrMasterPasswordNotSet = errors.New("master password is not set")
+
+func configFilename() string {
+	return fmt.Sprintf("%s/config.json", config.DATA_FOLDER)
+}
+
+func sealedConfigFilename() string {
+	return configFilename() + ".enc"
+}
+
+func dbFilename(env string) string {
+	return fmt.Sprintf("%s/%s.db", config.DATA_FOLDER, env)
+}
+
+func sealedDBFilename(env string) string {
+	return dbFilename(env) + ".enc"
+}
+
+func fileExists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+func removeDBFiles(filename string) error {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		err := os.Remove(filename + suffix)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type dbTable struct {
+	Name    string
+	Columns []string
+	Rows    [][]interface{}
+}
+
+type dbSnapshot struct {
+	Schema []string // tables first then indexes
+	Tables []dbTable
+}
+
+func init() {
+	// sqlite3 driver returns time.Time for date columns
+	gob.Register(time.Time{})
+}
+
+// snapshotDB reads every table in one transaction and encodes it
+func snapshotDB(db *sql.DB) ([]byte, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		select type, name, sql from sqlite_master
+		where sql is not null and name not like 'sqlite_%'
+		order by type = 'table' desc, rowid
+	`
+
+	rows, err := tx.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := dbSnapshot{}
+	var tables []string
+	for rows.Next() {
+		var schemaType, name, schema string
+		err = rows.Scan(&schemaType, &name, &schema)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		snapshot.Schema = append(snapshot.Schema, schema)
+		if schemaType == "table" {
+			tables = append(tables, name)
+		}
+	}
+
+	rows.Close()
+	if rows.Err() != nil {
+		return nil, rows.Err()
+	}
+
+	// the next ids of autoincrement tables - restored after the rows
+	var sequence int
+	err = tx.QueryRow(`select count(*) from sqlite_master where type = 'table' and name = 'sqlite_sequence'`).Scan(&sequence)
+	if err != nil {
+		return nil, err
+	}
+
+	if sequence > 0 {
+		tables = append(tables, "sqlite_sequence")
+	}
+
+	for _, name := range tables {
+		table, err := snapshotTable(tx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.Tables = append(snapshot.Tables, table)
+	}
+
+	var buf bytes.Buffer
+	err = gob.NewEncoder(&buf).Encode(snapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func snapshotTable(tx *sql.Tx, name string) (dbTable, error) {
+	table := dbTable{Name: name}
+	rows, err := tx.Query(fmt.Sprintf("select * from %s", name))
+	if err != nil {
+		return table, err
+	}
+	defer rows.Close()
+
+	table.Columns, err = rows.Columns()
+	if err != nil {
+		return table, err
+	}
+
+	for rows.Next() {
+		values := make([]interface{}, len(table.Columns))
+		pointers := make([]interface{}, len(table.Columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return table, err
+		}
+
+		table.Rows = append(table.Rows, values)
+	}
+
+	return table, rows.Err()
+}
+
+// restoreDB creates the tables of a snapshot in an empty db
+func restoreDB(db *sql.DB, data []byte) error {
+	var snapshot dbSnapshot
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&snapshot)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, schema := range snapshot.Schema {
+		_, err = tx.Exec(schema)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, table := range snapshot.Tables {
+		if len(table.Rows) == 0 {
+			continue
+		}
+
+		// inserting the rows of autoincrement tables filled it already
+		if table.Name == "sqlite_sequence" {
+			_, err = tx.Exec(`delete from sqlite_sequence`)
+			if err != nil {
+				return err
+			}
+		}
+
+		query := fmt.Sprintf("insert into %s (%s) values (%s)", table.Name, strings.Join(table.Columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(table.Columns)), ", "))
+		stmt, err := tx.Prepare(query)
+		if err != nil {
+			return err
+		}
+
+		for _, row := range table.Rows {
+			_, err = stmt.Exec(row...)
+			if err != nil {
+				stmt.Close()
+				return err
+			}
+		}
+
+		stmt.Close()
+	}
+
+	return tx.Commit()
+}
+
+func openDBFile(filename string) (*sql.DB, error) {
+	// wal and busy timeout let commands read while the background sync writes
+	return sql.Open("sqlite3", filename+"?_journal_mode=WAL&_busy_timeout=5000")
+}
+
+// openMemoryDB opens an in memory db shared by every connection of the pool.
+// The returned conn keeps the db alive since memdb is freed with its last connection.
+func openMemoryDB(env string) (*sql.DB, *sql.Conn, error) {
+	db, err := sql.Open("sqlite3", fmt.Sprintf("file:/%s_%d.db?vfs=memdb&_busy_timeout=5000", env, time.Now().UnixNano()))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return db, conn, nil
+}
+
+func readSealedFile(key *secure.Key, filename string) ([]byte, error) {
+	sealed, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	return key.Open(sealed)
+}
+
+func writeSealedFile(key *secure.Key, filename string, data []byte) error {
+	sealed, err := key.Seal(data)
+	if err != nil {
+		return err
+	}
+
+	return secure.WriteFile(filename, sealed)
+}
+
+// sealDBFile moves a plain db file to its sealed file
+func sealDBFile(key *secure.Key, env string) error {
+	filename := dbFilename(env)
+	if !fileExists(filename) {
+		return nil
+	}
+
+	db, err := openDBFile(filename)
+	if err != nil {
+		return err
+	}
+
+	data, err := snapshotDB(db)
+	db.Close()
+	if err != nil {
+		return err
+	}
+
+	err = writeSealedFile(key, sealedDBFilename(env), data)
+	if err != nil {
+		return err
+	}
+
+	return removeDBFiles(filename)
+}
+
+// pendingFile is written next to its target and renamed only once every file of a master password change is ready
+type pendingFile struct {
+	tmp    string
+	target string
+	remove string // db file replaced by the target - removed with its wal and shm files
+}
+
+func discardPendingFiles(files []pendingFile) {
+	for _, f := range files {
+		os.Remove(f.tmp)
+	}
+}
+
+func commitPendingFiles(files []pendingFile) error {
+	for _, f := range files {
+		err := os.Rename(f.tmp, f.target)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, f := range files {
+		if f.remove == "" {
+			continue
+		}
+
+		err := removeDBFiles(f.remove)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// prepareDBFile writes the db of the env for the new key in a temp file - a plain db file if newKey is nil
+func prepareDBFile(oldKey *secure.Key, newKey *secure.Key, env string) (*pendingFile, error) {
+	filename, sealedFilename := dbFilename(env), sealedDBFilename(env)
+
+	var data []byte
+	var err error
+	remove := ""
+	if oldKey != nil {
+		if !fileExists(sealedFilename) {
+			return nil, nil
+		}
+
+		data, err = readSealedFile(oldKey, sealedFilename)
+		if newKey == nil {
+			remove = sealedFilename
+		}
+	} else {
+		if !fileExists(filename) {
+			return nil, nil
+		}
+
+		var db *sql.DB
+		db, err = openDBFile(filename)
+		if err == nil {
+			data, err = snapshotDB(db)
+			db.Close()
+		}
+
+		remove = filename
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if newKey != nil {
+		f := &pendingFile{tmp: sealedFilename + ".tmp", target: sealedFilename, remove: remove}
+		return f, writeSealedFile(newKey, f.tmp, data)
+	}
+
+	// no wal for the temp file - it is a single file to rename
+	f := &pendingFile{tmp: filename + ".tmp", target: filename, remove: remove}
+	err = removeDBFiles(f.tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", f.tmp)
+	if err != nil {
+		return nil, err
+	}
+
+	err = restoreDB(db, data)
+	db.Close()
+	return f, err
+}
+
+// changeMasterKey writes the config and the db of every env for the new key then replaces the files once all are written.
+// The db is closed during the change and loaded again with the key in use after - the old one if anything failed.
+func (app *AppContext) changeMasterKey(newKey *secure.Key) error {
+	err := app.CloseDB()
+	if err != nil {
+		return err
+	}
+
+	err = app.writeMasterKeyFiles(newKey)
+	if err == nil {
+		app.masterKey = newKey
+	}
+
+	loadErr := app.loadDB()
+	if err != nil {
+		return err
+	}
+
+	return loadErr
+}
+
+func (app *AppContext) writeMasterKeyFiles(newKey *secure.Key) error {
+	var files []pendingFile
+	for _, env := range ENVS {
+		f, err := prepareDBFile(app.masterKey, newKey, env)
+		if f != nil {
+			files = append(files, *f)
+		}
+
+		if err != nil {
+			discardPendingFiles(files)
+			return err
+		}
+	}
+
+	content, filename, removeFilename, err := app.configContent(newKey)
+	if err == nil {
+		f := pendingFile{tmp: filename + ".tmp", target: filename}
+		files = append(files, f)
+		err = secure.WriteFile(f.tmp, content)
+	}
+
+	if err != nil {
+		discardPendingFiles(files)
+		return err
+	}
+
+	err = commitPendingFiles(files)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(removeFilename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// loadSealedDB opens the env db in memory from its sealed file - a plain db file left from before is sealed first
+func (app *AppContext) loadSealedDB() (*sql.DB, error) {
+	env := app.Config.Env
+	sealedFilename := sealedDBFilename(env)
+	if !fileExists(sealedFilename) {
+		err := sealDBFile(app.masterKey, env)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	db, conn, err := openMemoryDB(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if fileExists(sealedFilename) {
+		data, err := readSealedFile(app.masterKey, sealedFilename)
+		if err == nil {
+			err = restoreDB(db, data)
+		}
+
+		if err != nil {
+			conn.Close()
+			db.Close()
+			return nil, err
+		}
+	}
+
+	app.memoryConn = conn
+	app.sealedFilename = sealedFilename
+	app.savedHash = nil
+	return db, nil
+}
+
+// SaveDB seals the in memory db if it changed since the last save - does nothing without master password
+func (app *AppContext) SaveDB() error {
+	app.saveLock.Lock()
+	defer app.saveLock.Unlock()
+
+	if app.masterKey == nil || app.memoryConn == nil {
+		return nil
+	}
+
+	data, err := snapshotDB(app.DB)
+	if err != nil {
+		return err
+	}
+
+	hash := sha256.Sum256(data)
+	if bytes.Equal(hash[:], app.savedHash) {
+		return nil
+	}
+
+	err = writeSealedFile(app.masterKey, app.sealedFilename, data)
+	if err != nil {
+		return err
+	}
+
+	app.savedHash = hash[:]
+	return nil
+}
+
+// CloseDB saves and closes the env db
+func (app *AppContext) CloseDB() error {
+	err := app.SaveDB()
+
+	app.saveLock.Lock()
+	defer app.saveLock.Unlock()
+
+	if app.memoryConn != nil {
+		app.memoryConn.Close()
+		app.memoryConn = nil
+	}
+
+	app.DB.Close()
+	return err
+}
+
+func (app *AppContext) HasMasterPassword() bool {
+	return app.masterKey != nil
+}
+
+// loadSealedConfig prompts the master password and returns the config content
+func (app *AppContext) loadSealedConfig() ([]byte, error) {
+	sealed, err := ioutil.ReadFile(sealedConfigFilename())
+	if err != nil {
+		return nil, err
+	}
+
+	salt, err := secure.ReadSalt(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	attempts := MASTER_PASSWORD_ATTEMPTS
+	if app.Batch != nil {
+		attempts = 1
+	}
+
+	for i := 0; i < attempts; i++ {
+		password, err := PromptPassword("Enter master password")
+		if err != nil {
+			return nil, err
+		}
+
+		key := secure.DeriveKey(password, salt)
+		content, err := key.Open(sealed)
+		if err == nil {
+			app.masterKey = key
+			return content, nil
+		}
+
+		if !errors.Is(err, secure.ErrInvalidPassword) {
+			return nil, err
+		}
+
+		if i < attempts-1 {
+			fmt.Println(err)
+		}
+	}
+
+	return nil, secure.ErrInvalidPassword
+}
+
+func (app *AppContext) CheckMasterPassword(password string) error {
+	if app.masterKey == nil {
+		return ErrMasterPasswordNotSet
+	}
+
+	if !app.masterKey.Equal(password) {
+		return secure.ErrInvalidPassword
+	}
+
+	return nil
+}
+
+// SetMasterPassword seals the config and the db of every env with a new master password - also used to change it
+func (app *AppContext) SetMasterPassword(password string) error {
+	newKey, err := secure.NewKey(password)
+	if err != nil {
+		return err
+	}
+
+	return app.changeMasterKey(newKey)
+}
+
+// RemoveMasterPassword stores the config and the db of every env in plain files again
+func (app *AppContext) RemoveMasterPassword() error {
+	if app.masterKey == nil {
+		return ErrMasterPasswordNotSet
+	}
+
+	return app.changeMasterKey(nil)
+}
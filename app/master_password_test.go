@@ -0,0 +1,210 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/secure"
+)
+
+func TestSnapshotRestoreDB(t *testing.T) {
+	db, err := openDBFile(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		create table items (id integer primary key autoincrement, name varchar, data blob);
+		create index items_name on items (name);
+		insert into items (name, data) values ('a', x'0102'), ('b', null), ('c', x'03');
+		delete from items where name = 'c';
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := snapshotDB(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored, conn, err := openMemoryDB("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer restored.Close()
+	defer conn.Close()
+
+	err = restoreDB(restored, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var count int
+	var blob []byte
+	err = restored.QueryRow(`select count(*), (select data from items where name = 'a') from items`).Scan(&count, &blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 2 || len(blob) != 2 || blob[1] != 2 {
+		t.Fatalf("unexpected restored rows: %d %v", count, blob)
+	}
+
+	err = restored.QueryRow(`select count(*) from sqlite_master where type = 'index' and name = 'items_name'`).Scan(&count)
+	if err != nil || count != 1 {
+		t.Fatalf("index not restored: %v", err)
+	}
+
+	// the deleted id is never used again
+	res, err := restored.Exec(`insert into items (name) values ('d')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id, _ := res.LastInsertId()
+	if id != 4 {
+		t.Fatalf("autoincrement sequence not restored: new id %d", id)
+	}
+}
+
+func TestSealedFile(t *testing.T) {
+	key, err := secure.NewKey("pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filename := filepath.Join(t.TempDir(), "test.db.enc")
+	err = writeSealedFile(key, filename, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := readSealedFile(key, filename)
+	if err != nil || string(data) != "data" {
+		t.Fatalf("unexpected sealed file content: %s %v", data, err)
+	}
+
+	other, err := secure.NewKey("other")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = readSealedFile(other, filename)
+	if err == nil {
+		t.Fatal("sealed file opened with another key")
+	}
+}
+
+func addTestWalletRow(t *testing.T, name string) {
+	t.Helper()
+
+	_, err := Context.DB.Exec(`insert into app_wallets (name) values (?)`, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func countWalletRows(t *testing.T) int {
+	t.Helper()
+
+	var count int
+	err := Context.DB.QueryRow(`select count(*) from app_wallets`).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return count
+}
+
+func TestMasterPasswordRoundTrip(t *testing.T) {
+	newTestContext(t)
+	addTestWalletRow(t, "w1")
+
+	err := Context.SetMasterPassword("pw")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env := Context.Config.Env
+	if fileExists(dbFilename(env)) || !fileExists(sealedDBFilename(env)) {
+		t.Fatal("db file was not sealed")
+	}
+
+	if fileExists(configFilename()) || !fileExists(sealedConfigFilename()) {
+		t.Fatal("config file was not sealed")
+	}
+
+	if countWalletRows(t) != 1 {
+		t.Fatal("sealed db lost its rows")
+	}
+
+	// changes of the in memory db are sealed by SaveDB
+	addTestWalletRow(t, "w2")
+	err = Context.SaveDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a new start with the same key loads the sealed db
+	key := Context.masterKey
+	err = Context.CloseDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	Context = &AppContext{Config: Config{Env: env}, masterKey: key}
+	err = Context.loadDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if countWalletRows(t) != 2 {
+		t.Fatal("sealed db lost the rows saved after sealing")
+	}
+
+	// a new password reseals the files - the temp files are renamed once all are written
+	err = Context.SetMasterPassword("pw2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tmpFiles, err := filepath.Glob(filepath.Join(config.DATA_FOLDER, "*.tmp"))
+	if err != nil || len(tmpFiles) != 0 {
+		t.Fatalf("temp files left: %v %v", tmpFiles, err)
+	}
+
+	_, err = readSealedFile(key, sealedDBFilename(env))
+	if err == nil {
+		t.Fatal("db file still opens with the old password")
+	}
+
+	if countWalletRows(t) != 2 {
+		t.Fatal("resealed db lost its rows")
+	}
+
+	err = Context.RemoveMasterPassword()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fileExists(dbFilename(env)) || fileExists(sealedDBFilename(env)) {
+		t.Fatal("db file was not unsealed")
+	}
+
+	if !fileExists(configFilename()) || fileExists(sealedConfigFilename()) {
+		t.Fatal("config file was not unsealed")
+	}
+
+	if countWalletRows(t) != 2 {
+		t.Fatal("unsealed db lost its rows")
+	}
+
+	err = Context.RemoveMasterPassword()
+	if err != ErrMasterPasswordNotSet {
+		t.Fatalf("removed a master password not set: %v", err)
+	}
+}
@@ -0,0 +1,286 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/g45t345rt/derosphere/commit_sync"
+	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/utils"
+)
+
+// Migration changes the schema of a module - versions start at 1 and are never renumbered or edited once released
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *sql.Tx) error
+}
+
+// MigrationModule is the list of migrations of the app or a dapp - applied versions are stored in schema_migrations
+type MigrationModule struct {
+	Name       string
+	Migrations []Migration
+}
+
+type PendingMigration struct {
+	Module  string
+	Version int
+	Name    string
+}
+
+func MigrateExec(query string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		_, err := tx.Exec(query)
+		return err
+	}
+}
+
+// MigrateAddColumn adds a column only if missing - databases created before migrations may already have it
+func MigrateAddColumn(table string, column string, definition string) func(tx *sql.Tx) error {
+	return func(tx *sql.Tx) error {
+		rows, err := tx.Query(fmt.Sprintf("pragma table_info(%s)", table))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var cid int
+			var name, columnType string
+			var notNull, pk int
+			var defaultValue sql.NullString
+			err = rows.Scan(&cid, &name, &columnType, &notNull, &defaultValue, &pk)
+			if err != nil {
+				return err
+			}
+
+			if name == column {
+				return nil
+			}
+		}
+
+		err = rows.Close()
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(fmt.Sprintf("alter table %s add column %s %s", table, column, definition))
+		return err
+	}
+}
+
+func initMigrations(db *sql.DB) error {
+	sql := `
+		create table if not exists schema_migrations (
+			module varchar,
+			version integer,
+			name varchar,
+			applied_timestamp bigint,
+			primary key(module, version)
+		);
+	`
+
+	_, err := db.Exec(sql)
+	return err
+}
+
+func (app *AppContext) appliedVersions(module string) (map[int]bool, error) {
+	rows, err := app.DB.Query(`select version from schema_migrations where module = ?`, module)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	versions := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		err = rows.Scan(&version)
+		if err != nil {
+			return nil, err
+		}
+
+		versions[version] = true
+	}
+
+	return versions, rows.Err()
+}
+
+// PendingMigrations returns the migrations not applied yet - in order
+func (app *AppContext) PendingMigrations(modules []MigrationModule) ([]PendingMigration, error) {
+	var pending []PendingMigration
+	for _, module := range modules {
+		versions, err := app.appliedVersions(module.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, migration := range module.Migrations {
+			if !versions[migration.Version] {
+				pending = append(pending, PendingMigration{Module: module.Name, Version: migration.Version, Name: migration.Name})
+			}
+		}
+	}
+
+	return pending, nil
+}
+
+// BackupDB copies the env database in the backups folder and returns the backup filename
+func (app *AppContext) BackupDB() (string, error) {
+	backupFolder := fmt.Sprintf("%s/backups", config.DATA_FOLDER)
+	utils.CreateFoldersIfNotExists(backupFolder)
+
+	ext := ".db"
+	if app.masterKey != nil {
+		ext += ".enc"
+	}
+
+	// app and dapp migrations can back up within the same second - never overwrite a backup
+	name := fmt.Sprintf("%s/%s_%s", backupFolder, app.Config.Env, time.Now().Format("20060102_150405"))
+	filename := name + ext
+	for i := 2; ; i++ {
+		_, err := os.Stat(filename)
+		if os.IsNotExist(err) {
+			break
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		filename = fmt.Sprintf("%s_%d%s", name, i, ext)
+	}
+
+	// the backup is sealed like the db
+	if app.masterKey != nil {
+		data, err := snapshotDB(app.DB)
+		if err != nil {
+			return "", err
+		}
+
+		return filename, writeSealedFile(app.masterKey, filename, data)
+	}
+
+	// vacuum into includes the pages still in the wal file
+	_, err := app.DB.Exec(`vacuum into ?`, filename)
+	if err != nil {
+		return "", err
+	}
+
+	return filename, nil
+}
+
+func (app *AppContext) applyMigration(module string, migration Migration) error {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = migration.Up(tx)
+	if err != nil {
+		return fmt.Errorf("migration [%s %d %s] failed: %s", module, migration.Version, migration.Name, err)
+	}
+
+	query := `
+		insert into schema_migrations (module, version, name, applied_timestamp)
+		values (?, ?, ?, ?)
+	`
+
+	_, err = tx.Exec(query, module, migration.Version, migration.Name, time.Now().Unix())
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Migrate applies the pending migrations of modules - the database is backed up first if anything is pending
+func (app *AppContext) Migrate(modules ...MigrationModule) ([]PendingMigration, error) {
+	pending, err := app.PendingMigrations(modules)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	// nothing to back up in a new database
+	var tables int
+	err = app.DB.QueryRow(`select count(*) from sqlite_master where type = 'table' and name != 'schema_migrations'`).Scan(&tables)
+	if err != nil {
+		return nil, err
+	}
+
+	if tables > 0 {
+		filename, err := app.BackupDB()
+		if err != nil {
+			return nil, err
+		}
+
+		fmt.Fprintf(os.Stderr, "Database backup [%s] - applying %d migration(s)\n", filename, len(pending))
+	}
+
+	for _, module := range modules {
+		versions, err := app.appliedVersions(module.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, migration := range module.Migrations {
+			if versions[migration.Version] {
+				continue
+			}
+
+			err = app.applyMigration(module.Name, migration)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return pending, nil
+}
+
+// AppMigrations returns the migrations of the app tables applied by LoadDB
+func AppMigrations() MigrationModule {
+	return MigrationModule{
+		Name: "app",
+		Migrations: []Migration{
+			{Version: 1, Name: "create app_wallets", Up: MigrateExec(`
+			create table if not exists app_wallets (
+				id integer primary key,
+				name varchar unique,
+				daemon_rpc varchar,
+				wallet_rpc varchar,
+				wallet_path varchar
+			);
+		`)},
+			{Version: 2, Name: "create app_nodes", Up: MigrateExec(`
+			create table if not exists app_nodes (
+				id integer primary key,
+				wallet_id integer,
+				address varchar,
+				priority integer
+			);
+		`)},
+			{Version: 3, Name: "create sync checkpoints", Up: commit_sync.InitTables},
+			{Version: 4, Name: "create credential vault", Up: initVaultTables},
+			{Version: 5, Name: "create contacts", Up: initContactTables},
+			{Version: 6, Name: "create tx history", Up: initTxHistoryTables},
+			{Version: 7, Name: "create pending txs", Up: initPendingTxTables},
+			{Version: 8, Name: "create payout journal", Up: initPayoutTables},
+			{Version: 9, Name: "create scheduled transfers", Up: initScheduleTables},
+			{Version: 10, Name: "create wallet policies", Up: initWalletPolicyTables},
+			{Version: 11, Name: "add app_wallets.watch_address", Up: MigrateAddColumn("app_wallets", "watch_address", "varchar not null default ''")},
+			{Version: 12, Name: "create watch-only views", Up: initWatchTables},
+			{Version: 13, Name: "create asset cache", Up: initAssetTables},
+			{Version: 14, Name: "create portfolio snapshots", Up: initPortfolioTables},
+			{Version: 15, Name: "create wallet assets", Up: initWalletAssetTables},
+			{Version: 16, Name: "wallet paths relative to the profile", Up: relativeWalletPaths},
+			{Version: 17, Name: "create uncertain spends", Up: initUncertainSpendTables},
+		},
+	}
+}
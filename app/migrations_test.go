@@ -0,0 +1,186 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/g45t345rt/derosphere/config"
+)
+
+func countBackups(t *testing.T) int {
+	t.Helper()
+
+	files, err := ioutil.ReadDir(filepath.Join(config.DATA_FOLDER, "backups"))
+	if err != nil {
+		return 0
+	}
+
+	return len(files)
+}
+
+func tableExists(t *testing.T, name string) bool {
+	t.Helper()
+
+	var count int
+	err := Context.DB.QueryRow(`select count(*) from sqlite_master where type = 'table' and name = ?`, name).Scan(&count)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return count > 0
+}
+
+func TestMigrateAppTables(t *testing.T) {
+	newTestContext(t)
+
+	pending, err := Context.PendingMigrations([]MigrationModule{AppMigrations()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 0 {
+		t.Fatalf("app migrations still pending after load: %v", pending)
+	}
+
+	// nothing to back up in a new database
+	if countBackups(t) != 0 {
+		t.Fatal("new database was backed up")
+	}
+
+	applied, err := Context.Migrate(AppMigrations())
+	if err != nil || len(applied) != 0 {
+		t.Fatalf("applied migrations again: %v %v", applied, err)
+	}
+}
+
+func TestMigrateModule(t *testing.T) {
+	newTestContext(t)
+
+	module := MigrationModule{
+		Name: "dapp",
+		Migrations: []Migration{
+			{Version: 1, Name: "create items", Up: MigrateExec(`create table dapp_items (id integer primary key)`)},
+			{Version: 2, Name: "add items.name", Up: MigrateAddColumn("dapp_items", "name", "varchar")},
+		},
+	}
+
+	applied, err := Context.Migrate(module)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(applied) != 2 || applied[0].Version != 1 || applied[1].Version != 2 {
+		t.Fatalf("unexpected applied migrations: %v", applied)
+	}
+
+	if countBackups(t) != 1 {
+		t.Fatal("database with tables was not backed up before the migrations")
+	}
+
+	_, err = Context.DB.Exec(`insert into dapp_items (name) values ('a')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pending, err := Context.PendingMigrations([]MigrationModule{module})
+	if err != nil || len(pending) != 0 {
+		t.Fatalf("unexpected pending migrations: %v %v", pending, err)
+	}
+}
+
+func TestMigrateFailure(t *testing.T) {
+	newTestContext(t)
+
+	module := MigrationModule{
+		Name: "dapp",
+		Migrations: []Migration{
+			{Version: 1, Name: "create items", Up: MigrateExec(`create table dapp_items (id integer primary key)`)},
+			{Version: 2, Name: "create tags", Up: func(tx *sql.Tx) error {
+				_, err := tx.Exec(`create table dapp_tags (id integer primary key)`)
+				if err != nil {
+					return err
+				}
+
+				return errors.New("broken")
+			}},
+		},
+	}
+
+	_, err := Context.Migrate(module)
+	if err == nil {
+		t.Fatal("failed migration returned no error")
+	}
+
+	// the migrations before the failed one stay applied
+	if !tableExists(t, "dapp_items") || tableExists(t, "dapp_tags") {
+		t.Fatal("failed migration was not rolled back")
+	}
+
+	pending, err := Context.PendingMigrations([]MigrationModule{module})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(pending) != 1 || pending[0].Version != 2 {
+		t.Fatalf("unexpected pending migrations: %v", pending)
+	}
+}
+
+func TestMigrateAddColumnExisting(t *testing.T) {
+	newTestContext(t)
+
+	tx, err := Context.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`create table items (id integer primary key, name varchar)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// databases created before migrations may already have the column
+	err = MigrateAddColumn("items", "name", "varchar")(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = MigrateAddColumn("items", "note", "varchar not null default ''")(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = tx.Exec(`insert into items (name) values ('a')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var note string
+	err = tx.QueryRow(`select note from items`).Scan(&note)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBackupDBSameSecond(t *testing.T) {
+	newTestContext(t)
+
+	// app and dapp migrations can back up within the same second
+	first, err := Context.BackupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := Context.BackupDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second || !fileExists(first) || !fileExists(second) {
+		t.Fatalf("backup overwritten: %s %s", first, second)
+	}
+}
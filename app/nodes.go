@@ -0,0 +1,154 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/deroproject/derohe/globals"
+)
+
+var NODE_CHECK_INTERVAL = 30 * time.Second
+var NODE_MAX_HEIGHT_LAG = int64(10)
+
+type Node struct {
+	Id         int64
+	WalletId   sql.NullInt64 // null if the node is used by every wallet of the env
+	WalletName sql.NullString
+	Address    string
+	Priority   int64
+}
+
+func (app *AppContext) GetNodes() ([]Node, error) {
+	query := `
+		select n.id, n.wallet_id, w.name, n.address, n.priority
+		from app_nodes as n
+		left join app_wallets as w on w.id = n.wallet_id
+		order by n.wallet_id is not null desc, n.wallet_id, n.priority
+	`
+
+	rows, err := app.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var nodes []Node
+	for rows.Next() {
+		var node Node
+		err = rows.Scan(&node.Id, &node.WalletId, &node.WalletName, &node.Address, &node.Priority)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes = append(nodes, node)
+	}
+
+	return nodes, nil
+}
+
+// AddNode appends the node at the end of the wallet list or the env list if walletId is not valid
+func (app *AppContext) AddNode(walletId sql.NullInt64, address string) error {
+	query := `
+		insert into app_nodes (wallet_id, address, priority)
+		values (?, ?, (select coalesce(max(priority), 0) + 1 from app_nodes where wallet_id is ?))
+	`
+
+	_, err := app.DB.Exec(query, walletId, address, walletId)
+	return err
+}
+
+func (app *AppContext) DelNode(id int64) error {
+	res, err := app.DB.Exec(`delete from app_nodes where id == ?`, id)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("node [%d] does not exists", id)
+	}
+
+	return nil
+}
+
+// NodeAddresses returns the ordered pool of a wallet - wallet daemon, wallet nodes and then env nodes
+func (app *AppContext) NodeAddresses(w *WalletInstance) ([]string, error) {
+	addresses := []string{w.DaemonAddress}
+	exists := map[string]bool{w.DaemonAddress: true}
+
+	nodes, err := app.GetNodes()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, node := range nodes {
+		if node.WalletId.Valid && node.WalletId.Int64 != w.Id {
+			continue
+		}
+
+		if !exists[node.Address] {
+			exists[node.Address] = true
+			addresses = append(addresses, node.Address)
+		}
+	}
+
+	return addresses, nil
+}
+
+// RefreshNodes reloads the node pool of the opened wallet
+func (app *AppContext) RefreshNodes() error {
+	w := app.WalletInstance
+	if w == nil || w.Daemon == nil {
+		return nil
+	}
+
+	addresses, err := app.NodeAddresses(w)
+	if err != nil {
+		return err
+	}
+
+	w.Daemon.SetNodes(addresses)
+	return nil
+}
+
+func (w *WalletInstance) setupDaemonPool() error {
+	addresses, err := Context.NodeAddresses(w)
+	if err != nil {
+		return err
+	}
+
+	w.Daemon.SetNodes(addresses)
+	w.Daemon.Testnet = !globals.IsMainnet()
+	w.Daemon.MaxHeightLag = NODE_MAX_HEIGHT_LAG
+	w.Daemon.OnSwitch = func(address string) {
+		fmt.Printf("\nSwitched to daemon %s\n", address)
+
+		if w.Backend != nil {
+			w.Backend.SetDaemon(address)
+		}
+	}
+
+	_, err = w.Daemon.CheckNodes()
+	if err != nil {
+		return err
+	}
+
+	w.Daemon.StartHealthCheck(NODE_CHECK_INTERVAL)
+	return nil
+}
+
+// walletapi keeps one daemon connection for every wallet file of the process
+var keepConnectivity sync.Once
+
+func setWalletapiDaemon(address string) {
+	httpKey := regexp.MustCompile("https?://")
+	globals.Arguments["--daemon-address"] = httpKey.ReplaceAllString(address, "")
+}
@@ -0,0 +1,433 @@
+package app
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	deroConfig "github.com/deroproject/derohe/config"
+	"github.com/deroproject/derohe/cryptography/bn256"
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/transaction"
+	"github.com/deroproject/derohe/walletapi"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+var OFFLINE_TX_VERSION = 1
+
+type OfflineTxRing struct {
+	Addresses []string `json:"addresses"` // signer, destination and then random members
+	Balances  []string `json:"balances"`  // hex encrypted balances of addresses at topoheight
+}
+
+// OfflineTx is everything needed to sign a transaction without network access (cold wallet)
+type OfflineTx struct {
+	Version    int             `json:"version"`
+	Env        string          `json:"env"`
+	Signer     string          `json:"signer"`
+	Ringsize   uint64          `json:"ringsize"`
+	Transfers  []rpc.Transfer  `json:"transfers"`
+	SC_RPC     rpc.Arguments   `json:"sc_rpc"`
+	Fees       uint64          `json:"fees"`
+	Height     uint64          `json:"height"`
+	Topoheight int64           `json:"topoheight"`
+	BlockHash  string          `json:"blockhash"`
+	TreeHash   string          `json:"treehash"`
+	MaxBits    int             `json:"max_bits"`
+	Rings      []OfflineTxRing `json:"rings"`
+	TxHex      string          `json:"tx_hex,omitempty"` // set after signing
+	TxId       string          `json:"txid,omitempty"`
+}
+
+func LoadOfflineTx(filename string) (*OfflineTx, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var offlineTx OfflineTx
+	err = json.Unmarshal(content, &offlineTx)
+	if err != nil {
+		return nil, err
+	}
+
+	if offlineTx.Version != OFFLINE_TX_VERSION {
+		return nil, fmt.Errorf("unsupported offline tx version %d", offlineTx.Version)
+	}
+
+	return &offlineTx, nil
+}
+
+func (o *OfflineTx) Save(filename string) error {
+	content, err := json.MarshalIndent(o, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// the file holds the spend details - the mode of a file written by a previous version is fixed too
+	err = ioutil.WriteFile(filename, content, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Chmod(filename, 0600)
+}
+
+func (o *OfflineTx) IsSigned() bool {
+	return o.TxHex != ""
+}
+
+func (w *WalletInstance) randomRingMember(scid crypto.Hash, exclude map[string]bool) (string, error) {
+	result, err := w.Daemon.GetRandomAddresses(&rpc.GetRandomAddress_Params{SCID: scid})
+	if err != nil {
+		return "", err
+	}
+
+	for _, address := range result.Address {
+		if !exclude[address] {
+			return address, nil
+		}
+	}
+
+	return "", fmt.Errorf("could not obtain random ring member for scid %s", scid)
+}
+
+// encryptedBalance returns an empty Data if the address never received the asset
+func (w *WalletInstance) encryptedBalance(scid crypto.Hash, address string, topoheight int64) (*rpc.GetEncryptedBalance_Result, error) {
+	result, err := w.Daemon.GetEncrypedBalance(&rpc.GetEncryptedBalance_Params{
+		Address:    address,
+		SCID:       scid,
+		TopoHeight: topoheight,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if scid.IsZero() && result.Status != "OK" {
+		return nil, fmt.Errorf("%s: %s", address, result.Status)
+	}
+
+	return result, nil
+}
+
+func (w *WalletInstance) getEncryptedBalance(scid crypto.Hash, address string, topoheight int64) (*rpc.GetEncryptedBalance_Result, error) {
+	result, err := w.encryptedBalance(scid, address, topoheight)
+	if err != nil {
+		return nil, err
+	}
+
+	if result.Data == "" {
+		return nil, fmt.Errorf("no encrypted balance for %s", address)
+	}
+
+	return result, nil
+}
+
+// BuildOfflineTx collects ring members, encrypted balances and fees like walletapi TransferPayload0 does
+// but leaves the signature to a wallet that can be offline
+func (w *WalletInstance) BuildOfflineTx(transfers []rpc.Transfer, scRPC rpc.Arguments, ringsize uint64) (*OfflineTx, error) {
+	if ringsize&(ringsize-1) != 0 || ringsize < deroConfig.MIN_RINGSIZE || ringsize > deroConfig.MAX_RINGSIZE {
+		return nil, fmt.Errorf("invalid ringsize %d - must be a power of 2 between %d and %d", ringsize, deroConfig.MIN_RINGSIZE, deroConfig.MAX_RINGSIZE)
+	}
+
+	// the policy allowlist is about the addresses behind contact names and names
+	for i := range transfers {
+		t := &transfers[i]
+		if t.Destination == "" {
+			continue
+		}
+
+		resolved, err := Context.ResolveAddress(w.Daemon, t.Destination)
+		if err != nil {
+			return nil, err
+		}
+
+		t.Destination = resolved.Address
+	}
+
+	err := w.CheckPolicy(&rpc.Transfer_Params{Transfers: transfers, SC_RPC: scRPC, Ringsize: ringsize}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	signer, err := w.GetAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	var zeroscid crypto.Hash
+	self := map[string]bool{signer: true}
+
+	hasBase := false
+	for _, t := range transfers {
+		if t.SCID.IsZero() {
+			hasBase = true
+		}
+	}
+
+	// sc call or asset transfer needs a DERO transfer for fees
+	if !hasBase && (len(scRPC) > 0 || len(transfers) > 0) {
+		member, err := w.randomRingMember(zeroscid, self)
+		if err != nil {
+			return nil, err
+		}
+
+		transfers = append(transfers, rpc.Transfer{Destination: member, Amount: 0})
+	}
+
+	for i := range transfers {
+		t := &transfers[i]
+		if t.Destination == "" {
+			t.Destination, err = w.randomRingMember(t.SCID, self)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		addr, err := rpc.NewAddress(t.Destination)
+		if err != nil {
+			return nil, err
+		}
+
+		if addr.IsIntegratedAddress() {
+			if addr.Arguments.Validate_Arguments() != nil {
+				return nil, fmt.Errorf("integrated address arguments could not be validated")
+			}
+
+			if len(t.Payload_RPC) == 0 && addr.Arguments.Has(rpc.RPC_DESTINATION_PORT, rpc.DataUint64) {
+				t.Payload_RPC = append(t.Payload_RPC, rpc.Argument{Name: rpc.RPC_DESTINATION_PORT, DataType: rpc.DataUint64, Value: addr.Arguments.Value(rpc.RPC_DESTINATION_PORT, rpc.DataUint64).(uint64)})
+			}
+		}
+
+		if addr.BaseAddress().String() == signer {
+			return nil, fmt.Errorf("sending to self is not supported")
+		}
+
+		_, err = t.Payload_RPC.CheckPack(transaction.PAYLOAD0_LIMIT)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	estimateParams := &rpc.GasEstimate_Params{
+		Ringsize:  ringsize,
+		Transfers: transfers,
+		SC_RPC:    scRPC,
+	}
+
+	if ringsize == 2 {
+		estimateParams.Signer = signer
+	}
+
+	estimate, err := w.Daemon.GetGasEstimate(estimateParams)
+	if err != nil {
+		return nil, err
+	}
+
+	// use a topoheight a bit in the past if the wallet was not used recently - same as walletapi
+	selfBalance, err := w.getEncryptedBalance(zeroscid, signer, -1)
+	if err != nil {
+		return nil, err
+	}
+
+	topoheight := int64(-1)
+	if selfBalance.DTopoheight >= selfBalance.Topoheight+3 {
+		topoheight = selfBalance.DTopoheight - 3
+	}
+
+	er, err := w.getEncryptedBalance(transfers[0].SCID, signer, topoheight)
+	if err != nil {
+		return nil, err
+	}
+
+	treehash, err := hex.DecodeString(er.Merkle_Balance_TreeHash)
+	if err != nil || len(treehash) != 32 {
+		return nil, fmt.Errorf("invalid treehash [%s]", er.Merkle_Balance_TreeHash)
+	}
+
+	offlineTx := &OfflineTx{
+		Version:    OFFLINE_TX_VERSION,
+		Env:        Context.Config.Env,
+		Signer:     signer,
+		Ringsize:   ringsize,
+		Transfers:  transfers,
+		SC_RPC:     scRPC,
+		Fees:       estimate.GasStorage,
+		Height:     uint64(er.Height),
+		Topoheight: er.Topoheight,
+		BlockHash:  er.BlockHash.String(),
+		TreeHash:   er.Merkle_Balance_TreeHash,
+	}
+
+	maxBits := 0
+	for _, t := range transfers {
+		var ring OfflineTxRing
+		destination, _ := rpc.NewAddress(t.Destination)
+		members := []string{signer, destination.BaseAddress().String()}
+		exists := map[string]bool{members[0]: true, members[1]: true}
+
+		candidates, err := w.Daemon.GetRandomAddresses(&rpc.GetRandomAddress_Params{SCID: t.SCID})
+		if err != nil {
+			return nil, err
+		}
+
+		// not enough members for this asset - take them from DERO
+		if len(candidates.Address) <= 40 {
+			candidates, err = w.Daemon.GetRandomAddresses(&rpc.GetRandomAddress_Params{SCID: zeroscid})
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		for _, address := range candidates.Address {
+			if uint64(len(members)) >= ringsize {
+				break
+			}
+
+			if !exists[address] {
+				exists[address] = true
+				members = append(members, address)
+			}
+		}
+
+		if uint64(len(members)) < ringsize {
+			return nil, fmt.Errorf("not enough ring members - need %d but have %d", ringsize, len(members))
+		}
+
+		for _, address := range members {
+			balance, err := w.getEncryptedBalance(t.SCID, address, er.Topoheight)
+			if err != nil {
+				return nil, err
+			}
+
+			if balance.Bits > maxBits {
+				maxBits = balance.Bits
+			}
+
+			ring.Addresses = append(ring.Addresses, address)
+			ring.Balances = append(ring.Balances, balance.Data)
+		}
+
+		offlineTx.Rings = append(offlineTx.Rings, ring)
+	}
+
+	offlineTx.MaxBits = maxBits + 6 // extra 6 bits - same as walletapi
+	return offlineTx, nil
+}
+
+// SignOfflineTx builds the transaction with the private key of wallet - does not need network access
+func SignOfflineTx(offlineTx *OfflineTx, wallet *walletapi.Wallet_Memory) (err error) {
+	if offlineTx.Env != Context.Config.Env {
+		return fmt.Errorf("offline tx was built for %s environment", offlineTx.Env)
+	}
+
+	if wallet.GetAddress().String() != offlineTx.Signer {
+		return fmt.Errorf("wallet address does not match signer %s", offlineTx.Signer)
+	}
+
+	if len(offlineTx.Rings) != len(offlineTx.Transfers) {
+		return fmt.Errorf("invalid offline tx - expected one ring per transfer")
+	}
+
+	var rings [][]*bn256.G1
+	var ringsBalances [][][]byte
+	for _, ring := range offlineTx.Rings {
+		if len(ring.Addresses) != len(ring.Balances) || uint64(len(ring.Addresses)) != offlineTx.Ringsize {
+			return fmt.Errorf("invalid offline tx ring")
+		}
+
+		var publicKeys []*bn256.G1
+		var balances [][]byte
+		for i, address := range ring.Addresses {
+			addr, err := rpc.NewAddress(address)
+			if err != nil {
+				return err
+			}
+
+			balance, err := hex.DecodeString(ring.Balances[i])
+			if err != nil {
+				return err
+			}
+
+			publicKeys = append(publicKeys, addr.PublicKey.G1())
+			balances = append(balances, balance)
+		}
+
+		rings = append(rings, publicKeys)
+		ringsBalances = append(ringsBalances, balances)
+	}
+
+	treehash, err := hex.DecodeString(offlineTx.TreeHash)
+	if err != nil || len(treehash) != 32 {
+		return fmt.Errorf("invalid treehash [%s]", offlineTx.TreeHash)
+	}
+
+	// walletapi panics instead of returning errors
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("can't build transaction: %v", r)
+		}
+	}()
+
+	blockHash := crypto.HashHexToHash(offlineTx.BlockHash)
+	tx := wallet.BuildTransaction(offlineTx.Transfers, ringsBalances, rings, blockHash, offlineTx.Height, offlineTx.SC_RPC, treehash, offlineTx.MaxBits, offlineTx.Fees)
+	if tx == nil {
+		return fmt.Errorf("can't build transaction")
+	}
+
+	offlineTx.TxHex = hex.EncodeToString(tx.Serialize())
+	offlineTx.TxId = tx.GetHash().String()
+	return nil
+}
+
+func BroadcastOfflineTx(offlineTx *OfflineTx, daemon *rpc_client.Daemon) error {
+	if !offlineTx.IsSigned() {
+		return fmt.Errorf("offline tx is not signed")
+	}
+
+	result, err := daemon.SendRawTransaction(&rpc.SendRawTransaction_Params{
+		Tx_as_hex: offlineTx.TxHex,
+	})
+
+	if err != nil {
+		return err
+	}
+
+	if result.Status != "OK" {
+		return fmt.Errorf("transaction rejected: %s %s", result.Status, result.Reason)
+	}
+
+	return nil
+}
+
+// RecordOfflineTx adds a broadcasted offline tx to the history of the wallet that built it - the daily limits of the policy count it
+func (app *AppContext) RecordOfflineTx(offlineTx *OfflineTx) {
+	signer := app.WalletInstance
+	if signer != nil {
+		address, err := signer.GetAddress()
+		if err != nil || address != offlineTx.Signer {
+			signer = nil
+		}
+	}
+
+	for _, w := range app.GetWalletInstances() {
+		if signer == nil && w.WatchAddress == offlineTx.Signer {
+			signer = w
+		}
+	}
+
+	if signer == nil {
+		return
+	}
+
+	signer.recordTx(offlineTx.TxId, &rpc.Transfer_Params{
+		Transfers: offlineTx.Transfers,
+		SC_RPC:    offlineTx.SC_RPC,
+		Ringsize:  offlineTx.Ringsize,
+		Fees:      offlineTx.Fees,
+	}, app.commandOrigin())
+}
@@ -0,0 +1,38 @@
+package app
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestOfflineTxSave(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "tx.json")
+
+	// a file written before with a world readable mode
+	err := ioutil.WriteFile(filename, []byte("{}"), 0777)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offlineTx := &OfflineTx{Version: OFFLINE_TX_VERSION, Env: "simulator", Signer: "signer"}
+	err = offlineTx.Save(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("offline tx saved with mode %s", info.Mode().Perm())
+	}
+
+	loaded, err := LoadOfflineTx(filename)
+	if err != nil || loaded.Signer != "signer" {
+		t.Fatalf("offline tx not loaded: %v", err)
+	}
+}
@@ -0,0 +1,423 @@
+package app
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deroproject/derohe/config"
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/transaction"
+)
+
+var PAYOUT_MAX_TRANSFERS = 32      // transfers per tx - keeps proof generation and fees of a single tx reasonable
+var PAYOUT_TX_OVERHEAD = 4096      // estimated size of a tx without payloads
+var PAYOUT_PAYLOAD_OVERHEAD = 2048 // estimated size of a payload proof without its ring
+var PAYOUT_RING_MEMBER_SIZE = 160  // estimated size added to a payload by each ring member
+
+// Status of a payout row in the journal
+const (
+	PAYOUT_STATUS_SENDING = "sending" // the tx was being sent when the run stopped or failed after it was handed to the wallet - it may be on chain
+	PAYOUT_STATUS_SENT    = "sent"
+	PAYOUT_STATUS_FAILED  = "failed"
+)
+
+// PayoutRow is a line of a payout csv file - address/name, amount, asset scid, comment
+type PayoutRow struct {
+	Line     int
+	Input    string
+	Resolved *ResolvedAddress
+	SCID     crypto.Hash
+	Amount   uint64
+	Comment  string
+	Key      string // identifies the row in the journal - set by ValidatePayoutRows
+	Err      error  // set if the row is invalid
+	Status   string // journal status - empty if the row was never sent
+	TxId     string
+}
+
+// PayoutJournalEntry is the progress of a payout row saved before and after its tx is sent
+type PayoutJournalEntry struct {
+	WalletId    int64
+	Payout      string
+	RowKey      string
+	Line        int
+	Destination string
+	SCID        string
+	Amount      uint64
+	Comment     string
+	Status      string
+	TxId        string
+	Err         string
+	Timestamp   int64
+}
+
+func initPayoutTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_payout_journal (
+			wallet_id integer,
+			payout varchar,
+			row_key varchar,
+			line integer,
+			destination varchar,
+			scid varchar,
+			amount bigint,
+			comment varchar,
+			status varchar,
+			txid varchar,
+			err varchar,
+			timestamp bigint,
+			primary key (wallet_id, payout, row_key)
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+func parsePayoutRecord(record []string) (*PayoutRow, error) {
+	row := new(PayoutRow)
+	for len(record) < 4 {
+		record = append(record, "")
+	}
+
+	row.Input = strings.TrimSpace(record[0])
+	if row.Input == "" {
+		return row, fmt.Errorf("address/name is empty")
+	}
+
+	scid := strings.TrimSpace(record[2])
+	if scid != "" {
+		if len(scid) != 64 {
+			return row, fmt.Errorf("invalid asset scid [%s]", scid)
+		}
+
+		row.SCID = crypto.HashHexToHash(scid)
+	}
+
+	var err error
+	amount := strings.TrimSpace(record[1])
+	if row.SCID.IsZero() {
+		row.Amount, err = globals.ParseAmount(amount)
+	} else {
+		row.Amount, err = strconv.ParseUint(amount, 10, 64)
+	}
+
+	if err != nil {
+		return row, fmt.Errorf("invalid amount [%s]", amount)
+	}
+
+	if row.Amount == 0 {
+		return row, fmt.Errorf("amount is zero")
+	}
+
+	row.Comment = record[3]
+	return row, nil
+}
+
+// ReadPayoutFile reads the rows of a payout csv - DERO amounts are in Dero and asset amounts in atomic value.
+// The first line is skipped if it is a header. Rows that can't be parsed are returned with Err set.
+func ReadPayoutFile(filePath string) ([]*PayoutRow, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var rows []*PayoutRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		row, err := parsePayoutRecord(record)
+		if err != nil && line == 1 && len(record) > 1 {
+			// a first line without a valid amount is the header
+			if _, err := globals.ParseAmount(strings.TrimSpace(record[1])); err != nil {
+				continue
+			}
+		}
+
+		row.Line = line
+		row.Err = err
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// Transfer returns the transfer of the row - a contact sets the destination port and the comment if empty
+func (row *PayoutRow) Transfer() rpc.Transfer {
+	transfer := rpc.Transfer{
+		SCID:        row.SCID,
+		Destination: row.Resolved.Address,
+		Amount:      row.Amount,
+	}
+
+	comment := row.Comment
+	if comment == "" && row.Resolved.Contact != nil {
+		comment = row.Resolved.Contact.Comment
+	}
+
+	if comment != "" {
+		transfer.Payload_RPC = append(transfer.Payload_RPC, rpc.Argument{
+			Name:     rpc.RPC_COMMENT,
+			DataType: rpc.DataString,
+			Value:    comment,
+		})
+	}
+
+	if row.Resolved.Contact != nil && row.Resolved.Contact.DestinationPort.Valid {
+		transfer.Payload_RPC = append(transfer.Payload_RPC, rpc.Argument{
+			Name:     rpc.RPC_DESTINATION_PORT,
+			DataType: rpc.DataUint64,
+			Value:    uint64(row.Resolved.Contact.DestinationPort.Int64),
+		})
+	}
+
+	return transfer
+}
+
+// setPayoutRowKeys keys the parsed rows by their input so a name or contact pointing to a new address is not paid again.
+// Identical rows get a different key by occurrence so each of them is paid once - the occurrences are counted
+// before validation so a row failing to resolve does not change the keys of the next identical rows.
+func setPayoutRowKeys(rows []*PayoutRow) {
+	occurrences := make(map[string]int)
+	for _, row := range rows {
+		if row.Err != nil {
+			continue
+		}
+
+		id := fmt.Sprintf("%s|%s|%d|%s", row.Input, row.SCID, row.Amount, row.Comment)
+		occurrences[id]++
+
+		hash := sha256.Sum256([]byte(fmt.Sprintf("%s|%d", id, occurrences[id])))
+		row.Key = hex.EncodeToString(hash[:])
+	}
+}
+
+// ValidatePayoutRows keys the rows and resolves the destinations like transfer-from-file and checks the payloads fit PAYLOAD0_LIMIT
+func (w *WalletInstance) ValidatePayoutRows(rows []*PayoutRow) {
+	setPayoutRowKeys(rows)
+	for _, row := range rows {
+		if row.Err != nil {
+			continue
+		}
+
+		resolved, err := Context.ResolveAddress(w.Daemon, row.Input)
+		if err != nil {
+			row.Err = err
+			continue
+		}
+
+		row.Resolved = resolved
+		if resolved.Source == ADDRESS_SOURCE_RAW {
+			_, err = w.Daemon.GetEncrypedBalance(&rpc.GetEncryptedBalance_Params{
+				Address:    resolved.Address,
+				TopoHeight: -1,
+			})
+
+			if err != nil {
+				row.Err = err
+				continue
+			}
+		}
+
+		transfer := row.Transfer()
+		_, err = transfer.Payload_RPC.CheckPack(transaction.PAYLOAD0_LIMIT)
+		if err != nil {
+			row.Err = err
+		}
+	}
+}
+
+// PayoutTransfersPerTx is the number of transfers that fit in a tx with this ringsize - maxTransfers caps it if not 0
+func PayoutTransfersPerTx(ringsize uint64, maxTransfers int) int {
+	payloadSize := PAYOUT_PAYLOAD_OVERHEAD + int(ringsize)*PAYOUT_RING_MEMBER_SIZE + transaction.PAYLOAD0_LIMIT + crypto.HashLength
+	// one payload is kept for the zero DERO transfer added by the wallet to txs without DERO
+	count := (config.STARGATE_HE_MAX_TX_SIZE-PAYOUT_TX_OVERHEAD)/payloadSize - 1
+
+	if count > PAYOUT_MAX_TRANSFERS {
+		count = PAYOUT_MAX_TRANSFERS
+	}
+
+	if maxTransfers > 0 && count > maxTransfers {
+		count = maxTransfers
+	}
+
+	if count < 1 {
+		count = 1
+	}
+
+	return count
+}
+
+// ChunkPayoutRows splits the rows in txs that fit the tx size limit
+func ChunkPayoutRows(rows []*PayoutRow, ringsize uint64, maxTransfers int) [][]*PayoutRow {
+	count := PayoutTransfersPerTx(ringsize, maxTransfers)
+
+	var chunks [][]*PayoutRow
+	for i := 0; i < len(rows); i += count {
+		end := i + count
+		if end > len(rows) {
+			end = len(rows)
+		}
+
+		chunks = append(chunks, rows[i:end])
+	}
+
+	return chunks
+}
+
+// GetPayoutJournal returns the journal of a payout by row key
+func (app *AppContext) GetPayoutJournal(walletId int64, payout string) (map[string]*PayoutJournalEntry, error) {
+	query := `
+		select wallet_id, payout, row_key, line, destination, scid, amount, comment, status, txid, err, timestamp
+		from app_payout_journal
+		where wallet_id = ? and payout = ?
+	`
+
+	rows, err := app.DB.Query(query, walletId, payout)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	journal := make(map[string]*PayoutJournalEntry)
+	for rows.Next() {
+		e := new(PayoutJournalEntry)
+		err = rows.Scan(&e.WalletId, &e.Payout, &e.RowKey, &e.Line, &e.Destination, &e.SCID, &e.Amount, &e.Comment, &e.Status, &e.TxId, &e.Err, &e.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		journal[e.RowKey] = e
+	}
+
+	return journal, rows.Err()
+}
+
+// IsPayoutComplete returns true if every row in the journal of the payout was sent - the name can't be used for another payout
+func (app *AppContext) IsPayoutComplete(walletId int64, payout string) (bool, error) {
+	var count, sent int
+	query := `select count(*), count(case when status = ? then 1 end) from app_payout_journal where wallet_id = ? and payout = ?`
+	err := app.DB.QueryRow(query, PAYOUT_STATUS_SENT, walletId, payout).Scan(&count, &sent)
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0 && count == sent, nil
+}
+
+// LoadPayoutJournal sets the status and txid of the rows already in the journal
+func (app *AppContext) LoadPayoutJournal(walletId int64, payout string, rows []*PayoutRow) error {
+	journal, err := app.GetPayoutJournal(walletId, payout)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		if e, ok := journal[row.Key]; ok && row.Err == nil {
+			row.Status = e.Status
+			row.TxId = e.TxId
+		}
+	}
+
+	return nil
+}
+
+// setPayoutStatus saves the status of the rows and writes the sealed db right away so an interrupted run can resume
+func (app *AppContext) setPayoutStatus(walletId int64, payout string, rows []*PayoutRow, status string, txid string, txErr error) error {
+	query := `
+		insert or replace into app_payout_journal (wallet_id, payout, row_key, line, destination, scid, amount, comment, status, txid, err, timestamp)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	errMsg := ""
+	if txErr != nil {
+		errMsg = txErr.Error()
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for _, row := range rows {
+		_, err = tx.Exec(query, walletId, payout, row.Key, row.Line, row.Resolved.Address, row.SCID.String(), row.Amount, row.Comment, status, txid, errMsg, now)
+		if err != nil {
+			return err
+		}
+
+		row.Status = status
+		row.TxId = txid
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return app.SaveDB()
+}
+
+// SendPayout sends the rows in a single tx - the rows are journaled as sending before the tx is sent.
+// They are only marked as failed if the tx was refused before it was broadcasted, otherwise they stay sending.
+func (w *WalletInstance) SendPayout(payout string, rows []*PayoutRow, ringsize uint64) (string, error) {
+	err := Context.setPayoutStatus(w.Id, payout, rows, PAYOUT_STATUS_SENDING, "", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var transfers []rpc.Transfer
+	for _, row := range rows {
+		transfers = append(transfers, row.Transfer())
+	}
+
+	txid, err := w.Transfer(&rpc.Transfer_Params{
+		Ringsize:  ringsize,
+		Transfers: transfers,
+	})
+
+	if err != nil {
+		status := PAYOUT_STATUS_FAILED
+		if !IsTxNotSent(err) {
+			status = PAYOUT_STATUS_SENDING
+		}
+
+		saveErr := Context.setPayoutStatus(w.Id, payout, rows, status, "", err)
+		if saveErr != nil {
+			fmt.Fprintf(os.Stderr, "Could not save payout journal: %s\n", saveErr)
+		}
+
+		return "", err
+	}
+
+	err = Context.setPayoutStatus(w.Id, payout, rows, PAYOUT_STATUS_SENT, txid, nil)
+	return txid, err
+}
@@ -0,0 +1,194 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadPayoutFile(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "payout.csv")
+	content := "address,amount,scid,comment\n" +
+		"alice,1.5,,thanks\n" +
+		"\n" +
+		"bob,abc\n" +
+		"carol,10,aa00000000000000000000000000000000000000000000000000000000000000\n"
+
+	err := ioutil.WriteFile(filename, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rows, err := ReadPayoutFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows without header and empty line, got %d", len(rows))
+	}
+
+	if rows[0].Input != "alice" || rows[0].Amount != 150000 || rows[0].Comment != "thanks" || rows[0].Err != nil || rows[0].Line != 2 {
+		t.Fatalf("unexpected first row: %+v", rows[0])
+	}
+
+	if rows[1].Err == nil {
+		t.Fatal("row with an invalid amount is not flagged")
+	}
+
+	// asset amounts are atomic
+	if rows[2].Amount != 10 || rows[2].SCID.IsZero() || rows[2].Err != nil {
+		t.Fatalf("unexpected asset row: %+v", rows[2])
+	}
+}
+
+func testPayoutRows(count int) []*PayoutRow {
+	var rows []*PayoutRow
+	for i := 0; i < count; i++ {
+		input := fmt.Sprintf("dest%d", i)
+		rows = append(rows, &PayoutRow{
+			Line:     i + 1,
+			Input:    input,
+			Resolved: &ResolvedAddress{Input: input, Address: input, Source: ADDRESS_SOURCE_RAW},
+			Amount:   100,
+			Key:      input,
+		})
+	}
+
+	return rows
+}
+
+func TestChunkPayoutRows(t *testing.T) {
+	count := PayoutTransfersPerTx(16, 0)
+	if count < 1 || count > PAYOUT_MAX_TRANSFERS {
+		t.Fatalf("transfers per tx out of range: %d", count)
+	}
+
+	if PayoutTransfersPerTx(128, 0) > count {
+		t.Fatal("a bigger ring fits more transfers per tx")
+	}
+
+	if PayoutTransfersPerTx(16, 5) != 5 {
+		t.Fatal("max transfers is not applied")
+	}
+
+	rows := testPayoutRows(12)
+	chunks := ChunkPayoutRows(rows, 16, 5)
+	if len(chunks) != 3 || len(chunks[0]) != 5 || len(chunks[1]) != 5 || len(chunks[2]) != 2 {
+		t.Fatalf("unexpected chunks of 12 rows by 5: %d", len(chunks))
+	}
+
+	if chunks[1][0] != rows[5] || chunks[2][1] != rows[11] {
+		t.Fatal("chunks do not keep the row order")
+	}
+}
+
+func TestPayoutRowKeys(t *testing.T) {
+	rows := []*PayoutRow{
+		{Input: "alice", Amount: 100},
+		{Input: "alice", Amount: 100},
+		{Input: "bob", Err: errors.New("invalid amount")},
+		{Input: "alice", Amount: 100},
+	}
+
+	setPayoutRowKeys(rows)
+	if rows[0].Key == rows[1].Key || rows[1].Key == rows[3].Key || rows[2].Key != "" {
+		t.Fatal("identical rows are not keyed by occurrence")
+	}
+
+	// keys are set before validation - a row that fails to resolve does not shift the keys of the next identical rows
+	again := []*PayoutRow{{Input: "alice", Amount: 100}, {Input: "alice", Amount: 100}, {Input: "alice", Amount: 100}}
+	setPayoutRowKeys(again)
+	if again[2].Key != rows[3].Key {
+		t.Fatal("key of an identical row depends on the other rows")
+	}
+}
+
+func TestSendPayoutJournal(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+
+	rows := testPayoutRows(3)
+	txid, err := w.SendPayout("payout.csv", rows[:2], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(backend.Transfers) != 1 || len(backend.Transfers[0].Transfers) != 2 {
+		t.Fatal("rows are not sent in a single tx")
+	}
+
+	// a policy refusal never reaches the wallet - the row can be sent again
+	err = Context.SetWalletPolicy(&WalletPolicy{WalletId: w.Id, MaxPerTx: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = w.SendPayout("payout.csv", rows[2:], 2)
+	if !IsTxNotSent(err) || rows[2].Status != PAYOUT_STATUS_FAILED {
+		t.Fatalf("refused tx: got %v with row status %s", err, rows[2].Status)
+	}
+
+	// the tx may be on chain if the wallet failed after getting it
+	err = Context.DelWalletPolicy(w.Id, rows[2].SCID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backend.Err = errors.New("connection reset")
+	_, err = w.SendPayout("payout.csv", rows[2:], 2)
+	if err == nil || IsTxNotSent(err) || rows[2].Status != PAYOUT_STATUS_SENDING {
+		t.Fatalf("ambiguous tx failure: got %v with row status %s", err, rows[2].Status)
+	}
+
+	// a new run of the same file resumes from the journal
+	reloaded := testPayoutRows(4)
+	err = Context.LoadPayoutJournal(w.Id, "payout.csv", reloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{PAYOUT_STATUS_SENT, PAYOUT_STATUS_SENT, PAYOUT_STATUS_SENDING, ""}
+	for i, row := range reloaded {
+		if row.Status != expected[i] {
+			t.Fatalf("row %d: expected status [%s] got [%s]", i, expected[i], row.Status)
+		}
+	}
+
+	if reloaded[0].TxId != txid {
+		t.Fatal("sent rows lost their txid")
+	}
+
+	journal, err := Context.GetPayoutJournal(w.Id, "payout.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if journal[rows[2].Key].Err != "connection reset" {
+		t.Fatalf("journal did not save the error: %s", journal[rows[2].Key].Err)
+	}
+
+	// a name is complete once every row of its journal was sent
+	complete, err := Context.IsPayoutComplete(w.Id, "payout.csv")
+	if err != nil || complete {
+		t.Fatalf("payout with a row sending is complete: %v", err)
+	}
+
+	backend.Err = nil
+	_, err = w.SendPayout("payout.csv", rows[2:], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	complete, err = Context.IsPayoutComplete(w.Id, "payout.csv")
+	if err != nil || !complete {
+		t.Fatalf("payout with every row sent is not complete: %v", err)
+	}
+
+	complete, err = Context.IsPayoutComplete(w.Id, "payout-2.csv")
+	if err != nil || complete {
+		t.Fatalf("payout without journal is complete: %v", err)
+	}
+}
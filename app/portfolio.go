@@ -0,0 +1,215 @@
+package app
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+)
+
+// PortfolioBalance is the balance of an asset in a wallet and the one of the last snapshot of the wallet
+type PortfolioBalance struct {
+	WalletId    int64
+	WalletName  string
+	Asset       *Asset
+	Balance     uint64
+	Previous    uint64
+	HasPrevious bool
+	Since       int64 // time of the previous snapshot
+	Err         error // the balance could not be read
+}
+
+// PortfolioWallet is the result of a wallet - Err is set if the wallet could not be opened
+type PortfolioWallet struct {
+	Wallet   *WalletInstance
+	Balances []*PortfolioBalance
+	Err      error
+}
+
+type PortfolioSnapshot struct {
+	Id        int64
+	Timestamp int64
+}
+
+func initPortfolioTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_portfolio_snapshots (
+			id integer primary key,
+			timestamp bigint
+		);
+
+		create table if not exists app_portfolio_balances (
+			snapshot_id integer,
+			wallet_id integer,
+			scid varchar,
+			balance bigint,
+			primary key (snapshot_id, wallet_id, scid)
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+// PortfolioBalances reads DERO and the G45 tokens found in the local records and the asset discovery of the wallet - zero token balances are left out
+func (w *WalletInstance) PortfolioBalances() ([]*PortfolioBalance, error) {
+	scids, err := Context.KnownSCIDs(w.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	var zeroscid crypto.Hash
+	scids = append([]crypto.Hash{zeroscid}, scids...)
+
+	previous, snapshot, err := Context.LastPortfolioBalances(w.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	since := int64(0)
+	if snapshot != nil {
+		since = snapshot.Timestamp
+	}
+
+	var balances []*PortfolioBalance
+	for _, scid := range scids {
+		asset, err := w.ClassifyAsset(scid)
+		if err != nil {
+			balances = append(balances, &PortfolioBalance{WalletId: w.Id, WalletName: w.Name, Asset: &Asset{SCID: scid, Standard: ASSET_UNKNOWN}, Err: err})
+			continue
+		}
+
+		if !asset.IsToken() {
+			continue
+		}
+
+		b := &PortfolioBalance{WalletId: w.Id, WalletName: w.Name, Asset: asset, Since: since}
+		b.Previous, b.HasPrevious = previous[scid]
+		b.Balance, b.Err = w.GetBalance(scid)
+		if b.Err == nil && b.Balance == 0 && !b.HasPrevious && !scid.IsZero() {
+			continue
+		}
+
+		balances = append(balances, b)
+	}
+
+	return balances, nil
+}
+
+// ReadPortfolio reads the balances of the wallets - open sessions are reused and the others are connected
+// for the read only and closed, prompting their password if needed
+func (app *AppContext) ReadPortfolio(walletInstances []*WalletInstance) []*PortfolioWallet {
+	var wallets []*PortfolioWallet
+	for _, w := range walletInstances {
+		pw := &PortfolioWallet{Wallet: w}
+		wallets = append(wallets, pw)
+
+		if w.Backend != nil {
+			pw.Balances, pw.Err = w.PortfolioBalances()
+			continue
+		}
+
+		pw.Err = w.Connect()
+		if pw.Err == nil {
+			pw.Balances, pw.Err = w.PortfolioBalances()
+		}
+
+		w.Close()
+		app.restoreWalletapiDaemon()
+	}
+
+	return wallets
+}
+
+// restoreWalletapiDaemon points the walletapi back to the daemon of the open wallet files - the address is global to the process
+func (app *AppContext) restoreWalletapiDaemon() {
+	w := app.diskSession(nil)
+	if w != nil {
+		w.Backend.SetDaemon(w.Daemon.Address)
+	}
+}
+
+// LastPortfolioBalances returns the balances of the last snapshot including the wallet
+func (app *AppContext) LastPortfolioBalances(walletId int64) (map[crypto.Hash]uint64, *PortfolioSnapshot, error) {
+	query := `
+		select s.id, s.timestamp
+		from app_portfolio_snapshots as s
+		where exists (select 1 from app_portfolio_balances as b where b.snapshot_id = s.id and b.wallet_id = ?)
+		order by s.id desc
+		limit 1
+	`
+
+	snapshot := new(PortfolioSnapshot)
+	err := app.DB.QueryRow(query, walletId).Scan(&snapshot.Id, &snapshot.Timestamp)
+	if err == sql.ErrNoRows {
+		return map[crypto.Hash]uint64{}, nil, nil
+	}
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := app.DB.Query(`select scid, balance from app_portfolio_balances where snapshot_id = ? and wallet_id = ?`, snapshot.Id, walletId)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	balances := make(map[crypto.Hash]uint64)
+	for rows.Next() {
+		var scid string
+		var balance uint64
+		err = rows.Scan(&scid, &balance)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		balances[crypto.HashHexToHash(scid)] = balance
+	}
+
+	return balances, snapshot, rows.Err()
+}
+
+// SavePortfolioSnapshot stores the balances read without error - a wallet that could not be read keeps its last snapshot
+func (app *AppContext) SavePortfolioSnapshot(wallets []*PortfolioWallet) (*PortfolioSnapshot, error) {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	snapshot := &PortfolioSnapshot{Timestamp: time.Now().Unix()}
+	res, err := tx.Exec(`insert into app_portfolio_snapshots (timestamp) values (?)`, snapshot.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot.Id, err = res.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pw := range wallets {
+		for _, b := range pw.Balances {
+			if b.Err != nil {
+				continue
+			}
+
+			_, err = tx.Exec(`
+				insert into app_portfolio_balances (snapshot_id, wallet_id, scid, balance)
+				values (?, ?, ?, ?)
+			`, snapshot.Id, b.WalletId, b.Asset.SCID.String(), b.Balance)
+
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
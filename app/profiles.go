@@ -0,0 +1,246 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/g45t345rt/derosphere/config"
+)
+
+// Profile is a data folder with its own config, env dbs, backups and wallet files
+type Profile struct {
+	Name   string
+	Folder string
+	Envs   []string // env dbs found in the folder
+}
+
+func profileExists(name string) bool {
+	info, err := os.Stat(config.ProfileFolder(name))
+	return err == nil && info.IsDir()
+}
+
+// ListProfiles returns the default profile and the ones of the profiles folder
+func ListProfiles() ([]*Profile, error) {
+	names := []string{config.DEFAULT_PROFILE}
+
+	files, err := ioutil.ReadDir(filepath.Join(config.HOME_FOLDER, "profiles"))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, file := range files {
+		if file.IsDir() && config.ValidateProfileName(file.Name()) == nil {
+			names = append(names, file.Name())
+		}
+	}
+
+	sort.Strings(names[1:])
+
+	var profiles []*Profile
+	for _, name := range names {
+		profile := &Profile{Name: name, Folder: config.ProfileFolder(name)}
+		for _, env := range ENVS {
+			if fileExists(filepath.Join(profile.Folder, env+".db")) || fileExists(filepath.Join(profile.Folder, env+".db.enc")) {
+				profile.Envs = append(profile.Envs, env)
+			}
+		}
+
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, nil
+}
+
+func CreateProfile(name string) error {
+	err := config.ValidateProfileName(name)
+	if err != nil {
+		return err
+	}
+
+	if profileExists(name) {
+		return fmt.Errorf("profile [%s] already exists", name)
+	}
+
+	return os.MkdirAll(config.ProfileFolder(name), 0700)
+}
+
+// storedWalletPath keeps the wallet files of the profile relative to its folder - a copied profile uses its own copies
+func storedWalletPath(path string) string {
+	if path == "" {
+		return ""
+	}
+
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return path
+	}
+
+	rel, err := filepath.Rel(config.DATA_FOLDER, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return abs
+	}
+
+	return rel
+}
+
+// loadedWalletPath returns the location of a wallet file stored with storedWalletPath
+func loadedWalletPath(path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(config.DATA_FOLDER, path)
+}
+
+// relativeWalletPaths stores the wallet paths with storedWalletPath - they were relative to the working directory before
+func relativeWalletPaths(tx *sql.Tx) error {
+	rows, err := tx.Query(`select id, wallet_path from app_wallets where wallet_path != ''`)
+	if err != nil {
+		return err
+	}
+
+	paths := make(map[int64]string)
+	for rows.Next() {
+		var id int64
+		var path string
+		err = rows.Scan(&id, &path)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		paths[id] = path
+	}
+
+	rows.Close()
+	err = rows.Err()
+	if err != nil {
+		return err
+	}
+
+	for id, path := range paths {
+		_, err = tx.Exec(`update app_wallets set wallet_path = ? where id = ?`, storedWalletPath(path), id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopyProfile copies the files of a profile to a new one - wallet files inside the profile are stored relative to it
+// so the copy uses its own wallet files while the ones attached from outside keep their original location
+func (app *AppContext) CopyProfile(from string, to string) error {
+	err := config.ValidateProfileName(to)
+	if err != nil {
+		return err
+	}
+
+	if !profileExists(from) {
+		return fmt.Errorf("profile [%s] does not exist", from)
+	}
+
+	if profileExists(to) {
+		return fmt.Errorf("profile [%s] already exists", to)
+	}
+
+	// the open db may still have pages in its wal file - a sealed db is written after every command
+	if from == config.PROFILE && app.masterKey == nil {
+		_, err = app.DB.Exec(`pragma wal_checkpoint(truncate)`)
+		if err != nil {
+			return err
+		}
+	}
+
+	src := config.ProfileFolder(from)
+	dst := config.ProfileFolder(to)
+	profilesFolder := filepath.Join(config.HOME_FOLDER, "profiles")
+
+	err = filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		// the default profile holds the other profiles
+		if info.IsDir() && path == profilesFolder {
+			return filepath.SkipDir
+		}
+
+		if strings.HasSuffix(path, "-wal") || strings.HasSuffix(path, "-shm") {
+			return nil
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dst, rel)
+		if info.IsDir() {
+			return os.MkdirAll(target, 0700)
+		}
+
+		return copyFile(path, target, info.Mode())
+	})
+
+	if err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+
+	return nil
+}
+
+func copyFile(src string, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+	if err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// CheckDeleteProfile returns why a profile can't be deleted - the default and the one in use are kept
+func CheckDeleteProfile(name string) error {
+	if name == config.DEFAULT_PROFILE {
+		return fmt.Errorf("the default profile can't be deleted")
+	}
+
+	if name == config.PROFILE {
+		return fmt.Errorf("profile [%s] is in use", name)
+	}
+
+	if !profileExists(name) {
+		return fmt.Errorf("profile [%s] does not exist", name)
+	}
+
+	return nil
+}
+
+// DeleteProfile removes the folder of a profile with its env dbs and wallet files
+func DeleteProfile(name string) error {
+	err := CheckDeleteProfile(name)
+	if err != nil {
+		return err
+	}
+
+	return os.RemoveAll(config.ProfileFolder(name))
+}
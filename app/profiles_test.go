@@ -0,0 +1,64 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/g45t345rt/derosphere/config"
+)
+
+func TestRelativeWalletPaths(t *testing.T) {
+	newTestContext(t)
+
+	outside := filepath.Join(t.TempDir(), "outside.db")
+	paths := map[int64]string{
+		1: filepath.Join(config.DATA_FOLDER, "wallets", "w1.db"),
+		2: outside,
+		3: "",
+	}
+
+	for id, path := range paths {
+		_, err := Context.DB.Exec(`insert into app_wallets (id, name, wallet_path) values (?, ?, ?)`, id, id, path)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tx, err := Context.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tx.Rollback()
+
+	err = relativeWalletPaths(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[int64]string{
+		1: filepath.Join("wallets", "w1.db"),
+		2: outside,
+		3: "",
+	}
+
+	for id, path := range expected {
+		var stored string
+		err = Context.DB.QueryRow(`select wallet_path from app_wallets where id = ?`, id).Scan(&stored)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if stored != path {
+			t.Fatalf("wallet %d: expected path [%s] got [%s]", id, path, stored)
+		}
+
+		if loadedWalletPath(stored) != paths[id] {
+			t.Fatalf("wallet %d: loaded path [%s]", id, loadedWalletPath(stored))
+		}
+	}
+}
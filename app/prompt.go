@@ -2,14 +2,29 @@ package app
 
 import (
 	"fmt"
+	"os"
 	"strconv"
 	"strings"
 
 	"github.com/chzyer/readline"
 	"github.com/deroproject/derohe/globals"
+	"github.com/g45t345rt/derosphere/utils"
 )
 
 func Prompt(prompt string, defaultValue string) (string, error) {
+	if Context.Batch != nil {
+		line, err := Context.Batch.Answer(prompt)
+		if err != nil {
+			return "", err
+		}
+
+		if line == "" {
+			line = defaultValue
+		}
+
+		return line, nil
+	}
+
 	i := Context.readlineInstance
 
 	if defaultValue != "" {
@@ -91,6 +106,21 @@ func PromptYesNo(prompt string, defaultAnswer bool) (bool, error) {
 }
 
 func PromptChoose(prompt string, choices []string, defaultValue string) (string, error) {
+	if Context.Batch != nil {
+		line, err := Prompt(prompt, defaultValue)
+		if err != nil {
+			return "", err
+		}
+
+		for _, v := range choices {
+			if v == line {
+				return line, nil
+			}
+		}
+
+		return "", fmt.Errorf("invalid answer [%s] for prompt [%s] - expected %s", line, prompt, strings.Join(choices, "/"))
+	}
+
 	i := Context.readlineInstance
 
 prompt:
@@ -125,6 +155,10 @@ prompt:
 }
 
 func PromptPassword(prompt string) (string, error) {
+	if Context.Batch != nil {
+		return Context.Batch.Answer(prompt)
+	}
+
 	i := Context.readlineInstance
 
 	config := i.GenPasswordConfig()
@@ -142,16 +176,10 @@ func PromptPassword(prompt string) (string, error) {
 	return string(line), nil
 }
 
-func HandlePromptErr(err error) bool {
-	if err != nil {
-		if err == readline.ErrInterrupt {
-			fmt.Println("Prompt cancelled")
-		} else {
-			fmt.Println(err)
-		}
-
-		return true
+func PrintCommandErr(err error) {
+	if err == readline.ErrInterrupt {
+		fmt.Println("Prompt cancelled")
+	} else {
+		utils.PrintError(os.Stdout, err)
 	}
-
-	return false
 }
@@ -0,0 +1,597 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/robfig/cron/v3"
+)
+
+var SCHEDULE_CHECK_INTERVAL = 30 * time.Second
+var SCHEDULE_MAX_CATCH_UP = 100             // missed runs looked up at most - older ones are ignored
+var SCHEDULE_GRACE_PERIOD = 5 * time.Minute // a run this late still executes with the skip policy
+
+// Catch-up policies for the runs missed while derosphere was not running
+const (
+	CATCH_UP_SKIP = "skip" // only the runs that are due now
+	CATCH_UP_ONCE = "once" // a single run for all the missed runs
+	CATCH_UP_ALL  = "all"  // every missed run
+)
+
+var CATCH_UP_POLICIES = []string{CATCH_UP_SKIP, CATCH_UP_ONCE, CATCH_UP_ALL}
+
+// Status of a scheduled run
+const (
+	SCHEDULE_RUN_SENDING = "sending" // the tx was being sent when derosphere stopped - it may be on chain
+	SCHEDULE_RUN_SENT    = "sent"
+	SCHEDULE_RUN_FAILED  = "failed"
+	SCHEDULE_RUN_MISSED  = "missed"
+	SCHEDULE_RUN_CAPPED  = "capped" // not sent because of the spending cap
+)
+
+// ScheduledTransfer is a recurring transfer of the wallet executed while derosphere is running
+type ScheduledTransfer struct {
+	Id          int64
+	WalletId    int64
+	Name        string
+	Cron        string
+	Destination string
+	SCID        crypto.Hash
+	Amount      uint64
+	Comment     string
+	Ringsize    uint64
+	CapAmount   uint64 // max amount sent in CapPeriod - 0 for no cap
+	CapPeriod   int64  // seconds
+	CatchUp     string
+	Paused      bool
+	LastRun     int64 // time of the last run handled
+	Timestamp   int64
+}
+
+type ScheduleRun struct {
+	Id            int64
+	ScheduleId    int64
+	ScheduleName  string
+	SCID          crypto.Hash
+	ScheduledTime int64
+	RunTime       int64
+	Status        string
+	Amount        uint64
+	TxId          string
+	Err           string
+}
+
+func initScheduleTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_schedules (
+			id integer primary key,
+			wallet_id integer,
+			name varchar,
+			cron varchar,
+			destination varchar,
+			scid varchar,
+			amount bigint,
+			comment varchar,
+			ringsize bigint,
+			cap_amount bigint,
+			cap_period bigint,
+			catch_up varchar,
+			paused boolean,
+			last_run bigint,
+			timestamp bigint,
+			unique (wallet_id, name)
+		);
+
+		create table if not exists app_schedule_runs (
+			id integer primary key,
+			schedule_id integer,
+			scheduled_time bigint,
+			run_time bigint,
+			status varchar,
+			amount bigint,
+			txid varchar,
+			err varchar
+		);
+
+		create index if not exists app_schedule_runs_schedule on app_schedule_runs (schedule_id, run_time);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+// ParseCron parses a standard cron expression (minute hour day month weekday)
+func ParseCron(expr string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression [%s]: %s", expr, err)
+	}
+
+	return schedule, nil
+}
+
+// NextRun returns the next run after the last run handled
+func (s *ScheduledTransfer) NextRun() (time.Time, error) {
+	schedule, err := ParseCron(s.Cron)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return schedule.Next(time.Unix(s.LastRun, 0)), nil
+}
+
+const scheduleColumns = `id, wallet_id, name, cron, destination, scid, amount, comment, ringsize, cap_amount, cap_period, catch_up, paused, last_run, timestamp`
+
+func scanSchedule(row interface{ Scan(...interface{}) error }) (*ScheduledTransfer, error) {
+	s := new(ScheduledTransfer)
+	var scid string
+	err := row.Scan(&s.Id, &s.WalletId, &s.Name, &s.Cron, &s.Destination, &scid, &s.Amount, &s.Comment, &s.Ringsize, &s.CapAmount, &s.CapPeriod, &s.CatchUp, &s.Paused, &s.LastRun, &s.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	s.SCID = crypto.HashHexToHash(scid)
+	return s, nil
+}
+
+func (app *AppContext) GetSchedules(walletId int64) ([]*ScheduledTransfer, error) {
+	query := fmt.Sprintf(`
+		select %s
+		from app_schedules
+		where wallet_id = ?
+		order by name
+	`, scheduleColumns)
+
+	rows, err := app.DB.Query(query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var schedules []*ScheduledTransfer
+	for rows.Next() {
+		s, err := scanSchedule(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		schedules = append(schedules, s)
+	}
+
+	return schedules, rows.Err()
+}
+
+// GetSchedule returns nil if the wallet has no schedule with this name
+func (app *AppContext) GetSchedule(walletId int64, name string) (*ScheduledTransfer, error) {
+	query := fmt.Sprintf(`
+		select %s
+		from app_schedules
+		where wallet_id = ? and name = ?
+	`, scheduleColumns)
+
+	s, err := scanSchedule(app.DB.QueryRow(query, walletId, name))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return s, err
+}
+
+func (app *AppContext) AddSchedule(s *ScheduledTransfer) error {
+	query := `
+		insert into app_schedules (wallet_id, name, cron, destination, scid, amount, comment, ringsize, cap_amount, cap_period, catch_up, paused, last_run, timestamp)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	res, err := app.DB.Exec(query, s.WalletId, s.Name, s.Cron, s.Destination, s.SCID.String(), s.Amount, s.Comment, s.Ringsize, s.CapAmount, s.CapPeriod, s.CatchUp, s.Paused, s.LastRun, s.Timestamp)
+	if err != nil {
+		return err
+	}
+
+	s.Id, err = res.LastInsertId()
+	return err
+}
+
+// SetSchedulePaused pauses or resumes a schedule - runs missed while paused are not caught up
+func (app *AppContext) SetSchedulePaused(walletId int64, name string, paused bool) error {
+	query := `
+		update app_schedules
+		set paused = ?, last_run = case when ? then last_run else max(last_run, ?) end
+		where wallet_id = ? and name = ?
+	`
+
+	res, err := app.DB.Exec(query, paused, paused, time.Now().Unix(), walletId, name)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("schedule [%s] does not exists", name)
+	}
+
+	return nil
+}
+
+func (app *AppContext) DelSchedule(walletId int64, name string) error {
+	s, err := app.GetSchedule(walletId, name)
+	if err != nil {
+		return err
+	}
+
+	if s == nil {
+		return fmt.Errorf("schedule [%s] does not exists", name)
+	}
+
+	_, err = app.DB.Exec(`delete from app_schedule_runs where schedule_id = ?`, s.Id)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.DB.Exec(`delete from app_schedules where id = ?`, s.Id)
+	return err
+}
+
+func (app *AppContext) setScheduleLastRun(s *ScheduledTransfer, lastRun int64) error {
+	_, err := app.DB.Exec(`update app_schedules set last_run = ? where id = ?`, lastRun, s.Id)
+	if err == nil {
+		s.LastRun = lastRun
+	}
+
+	return err
+}
+
+func (app *AppContext) addScheduleRun(run *ScheduleRun) error {
+	query := `
+		insert into app_schedule_runs (schedule_id, scheduled_time, run_time, status, amount, txid, err)
+		values (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	res, err := app.DB.Exec(query, run.ScheduleId, run.ScheduledTime, run.RunTime, run.Status, run.Amount, run.TxId, run.Err)
+	if err != nil {
+		return err
+	}
+
+	run.Id, err = res.LastInsertId()
+	return err
+}
+
+func (app *AppContext) updateScheduleRun(run *ScheduleRun) error {
+	_, err := app.DB.Exec(`update app_schedule_runs set status = ?, txid = ?, err = ? where id = ?`, run.Status, run.TxId, run.Err, run.Id)
+	return err
+}
+
+// GetScheduleRuns returns the runs of the wallet schedules - only the runs of this schedule if name is not empty
+func (app *AppContext) GetScheduleRuns(walletId int64, name string) ([]*ScheduleRun, error) {
+	query := `
+		select r.id, r.schedule_id, s.name, s.scid, r.scheduled_time, r.run_time, r.status, r.amount, r.txid, r.err
+		from app_schedule_runs as r
+		join app_schedules as s on s.id = r.schedule_id
+		where s.wallet_id = ? and (? = '' or s.name = ?)
+		order by r.run_time, r.id
+	`
+
+	rows, err := app.DB.Query(query, walletId, name, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []*ScheduleRun
+	for rows.Next() {
+		run := new(ScheduleRun)
+		var scid string
+		err = rows.Scan(&run.Id, &run.ScheduleId, &run.ScheduleName, &scid, &run.ScheduledTime, &run.RunTime, &run.Status, &run.Amount, &run.TxId, &run.Err)
+		if err != nil {
+			return nil, err
+		}
+
+		run.SCID = crypto.HashHexToHash(scid)
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// spentSince is the amount sent by a schedule since this time - a run left sending may be on chain and counts
+func (app *AppContext) spentSince(scheduleId int64, since int64) (uint64, error) {
+	var spent uint64
+	err := app.DB.QueryRow(`
+		select coalesce(sum(amount), 0)
+		from app_schedule_runs
+		where schedule_id = ? and status in (?, ?) and run_time >= ?
+	`, scheduleId, SCHEDULE_RUN_SENT, SCHEDULE_RUN_SENDING, since).Scan(&spent)
+	return spent, err
+}
+
+// dueRuns returns the runs of the schedule between the last run handled and now
+func (s *ScheduledTransfer) dueRuns(now time.Time) ([]time.Time, error) {
+	schedule, err := ParseCron(s.Cron)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []time.Time
+	next := schedule.Next(time.Unix(s.LastRun, 0))
+	for !next.After(now) {
+		due = append(due, next)
+		if len(due) > SCHEDULE_MAX_CATCH_UP {
+			due = due[1:]
+		}
+
+		next = schedule.Next(next)
+	}
+
+	return due, nil
+}
+
+// Scheduler executes the due scheduled transfers of the open wallet in the background
+type Scheduler struct {
+	wallet    *WalletInstance
+	stop      chan bool // closed to stop the goroutine of the last Start
+	done      chan bool // closed when that goroutine exited
+	runLock   sync.Mutex
+	lock      sync.Mutex
+	started   bool
+	printRuns bool
+}
+
+func NewScheduler(wallet *WalletInstance) *Scheduler {
+	return &Scheduler{
+		wallet: wallet,
+	}
+}
+
+// send executes a single run of the schedule and records it in the run history
+func (sc *Scheduler) send(s *ScheduledTransfer, scheduledTime time.Time, now time.Time) (*ScheduleRun, error) {
+	run := &ScheduleRun{
+		ScheduleId:    s.Id,
+		ScheduleName:  s.Name,
+		SCID:          s.SCID,
+		ScheduledTime: scheduledTime.Unix(),
+		RunTime:       now.Unix(),
+		Amount:        s.Amount,
+	}
+
+	spent := uint64(0)
+	var err error
+	if s.CapAmount > 0 {
+		spent, err = Context.spentSince(s.Id, now.Unix()-s.CapPeriod)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.CapAmount > 0 && spent+s.Amount > s.CapAmount {
+		run.Status = SCHEDULE_RUN_CAPPED
+		sent := fmt.Sprint(spent)
+		if s.SCID.IsZero() {
+			sent = globals.FormatMoney(spent)
+		}
+
+		run.Err = fmt.Sprintf("spending cap reached - %s already sent", sent)
+	} else {
+		transfer := rpc.Transfer{
+			SCID:        s.SCID,
+			Destination: s.Destination,
+			Amount:      s.Amount,
+		}
+
+		if s.Comment != "" {
+			transfer.Payload_RPC = rpc.Arguments{
+				{Name: rpc.RPC_COMMENT, DataType: rpc.DataString, Value: s.Comment},
+			}
+		}
+
+		// the run is handled and saved as sending before the tx leaves - an interrupted run is never sent again
+		run.Status = SCHEDULE_RUN_SENDING
+		err = Context.addScheduleRun(run)
+		if err != nil {
+			return nil, err
+		}
+
+		err = Context.setScheduleLastRun(s, scheduledTime.Unix())
+		if err != nil {
+			return run, err
+		}
+
+		err = Context.SaveDB()
+		if err != nil {
+			return run, err
+		}
+
+		// runs in the background so a tx requiring password reentry is refused
+		txid, err := sc.wallet.transfer(&rpc.Transfer_Params{
+			Ringsize:  s.Ringsize,
+			Transfers: []rpc.Transfer{transfer},
+		}, false, txOrigin{command: fmt.Sprintf("schedule %s", s.Name)})
+
+		if err != nil {
+			run.Status = SCHEDULE_RUN_FAILED
+			run.Err = err.Error()
+		} else {
+			run.Status = SCHEDULE_RUN_SENT
+			run.TxId = txid
+		}
+
+		err = Context.updateScheduleRun(run)
+		if err != nil {
+			return run, err
+		}
+
+		return run, Context.SaveDB()
+	}
+
+	return run, Context.addScheduleRun(run)
+}
+
+// runSchedule executes the due runs of a schedule following its catch-up policy
+func (sc *Scheduler) runSchedule(s *ScheduledTransfer, now time.Time) ([]*ScheduleRun, error) {
+	due, err := s.dueRuns(now)
+	if err != nil || len(due) == 0 {
+		return nil, err
+	}
+
+	var runs []*ScheduleRun
+	for i, scheduledTime := range due {
+		last := i == len(due)-1
+
+		execute := false
+		switch s.CatchUp {
+		case CATCH_UP_ALL:
+			execute = true
+		case CATCH_UP_ONCE:
+			execute = last
+		default:
+			execute = last && now.Sub(scheduledTime) <= SCHEDULE_GRACE_PERIOD
+		}
+
+		var run *ScheduleRun
+		if execute {
+			run, err = sc.send(s, scheduledTime, now)
+		} else {
+			run = &ScheduleRun{
+				ScheduleId:    s.Id,
+				ScheduleName:  s.Name,
+				SCID:          s.SCID,
+				ScheduledTime: scheduledTime.Unix(),
+				RunTime:       now.Unix(),
+				Status:        SCHEDULE_RUN_MISSED,
+			}
+
+			err = Context.addScheduleRun(run)
+		}
+
+		if err != nil {
+			return runs, err
+		}
+
+		// missed and capped runs are handled here - sent runs are saved before their tx by send
+		err = Context.setScheduleLastRun(s, scheduledTime.Unix())
+		if err != nil {
+			return runs, err
+		}
+
+		runs = append(runs, run)
+	}
+
+	return runs, nil
+}
+
+// RunDue executes the due runs of every active schedule of the wallet
+func (sc *Scheduler) RunDue() ([]*ScheduleRun, error) {
+	sc.runLock.Lock()
+	defer sc.runLock.Unlock()
+
+	schedules, err := Context.GetSchedules(sc.wallet.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var runs []*ScheduleRun
+	for _, s := range schedules {
+		if s.Paused {
+			continue
+		}
+
+		scheduleRuns, err := sc.runSchedule(s, now)
+		runs = append(runs, scheduleRuns...)
+		if err != nil {
+			return runs, err
+		}
+	}
+
+	if len(runs) > 0 {
+		err = Context.SaveDB()
+	}
+
+	return runs, err
+}
+
+func (run *ScheduleRun) String() string {
+	switch run.Status {
+	case SCHEDULE_RUN_SENT:
+		return fmt.Sprintf("schedule %s sent tx %s", run.ScheduleName, shortTxId(run.TxId))
+	case SCHEDULE_RUN_MISSED:
+		return fmt.Sprintf("schedule %s missed run of %s", run.ScheduleName, time.Unix(run.ScheduledTime, 0).Local().Format("2006-01-02 15:04"))
+	default:
+		return fmt.Sprintf("schedule %s %s: %s", run.ScheduleName, run.Status, run.Err)
+	}
+}
+
+// Start checks the schedules in the background - printRuns prints the runs instead of only showing the last one in the prompt
+func (sc *Scheduler) Start(printRuns bool) {
+	if sc.started {
+		return
+	}
+
+	sc.started = true
+	sc.SetPrintRuns(printRuns)
+
+	// every goroutine has its own channels - a restart never shares them with a goroutine still stopping
+	stop, done := make(chan bool), make(chan bool)
+	sc.stop, sc.done = stop, done
+
+	go func() {
+		defer close(done)
+
+		for {
+			runs, err := sc.RunDue()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Scheduled transfers: %s\n", err)
+			}
+
+			sc.lock.Lock()
+			printRuns := sc.printRuns
+			sc.lock.Unlock()
+
+			for _, run := range runs {
+				if printRuns {
+					fmt.Println(run)
+				}
+
+				if sc.wallet.txTracker != nil {
+					sc.wallet.txTracker.setEvent(run.String())
+				}
+			}
+
+			select {
+			case <-stop:
+				return
+			case <-time.After(SCHEDULE_CHECK_INTERVAL):
+			}
+		}
+	}()
+}
+
+// SetPrintRuns prints the runs while the prompt is not used - the serve command
+func (sc *Scheduler) SetPrintRuns(printRuns bool) {
+	sc.lock.Lock()
+	defer sc.lock.Unlock()
+
+	sc.printRuns = printRuns
+}
+
+func (sc *Scheduler) Started() bool {
+	return sc.started
+}
+
+// Stop waits for the goroutine to exit - a run in progress finishes first
+func (sc *Scheduler) Stop() {
+	if sc.started {
+		close(sc.stop)
+		<-sc.done
+		sc.started = false
+	}
+}
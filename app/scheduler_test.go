@@ -0,0 +1,260 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/deroproject/derohe/rpc"
+)
+
+// testLastRun is an hour boundary - an hourly schedule has 3 runs due 3 hours later
+var testLastRun = time.Date(2026, 1, 1, 0, 0, 0, 0, time.Local)
+
+func addTestSchedule(t *testing.T, w *WalletInstance, name string, catchUp string) *ScheduledTransfer {
+	t.Helper()
+
+	s := &ScheduledTransfer{
+		WalletId:    w.Id,
+		Name:        name,
+		Cron:        "0 * * * *",
+		Destination: "dest",
+		Amount:      100,
+		Ringsize:    2,
+		CatchUp:     catchUp,
+		LastRun:     testLastRun.Unix(),
+		Timestamp:   testLastRun.Unix(),
+	}
+
+	err := Context.AddSchedule(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return s
+}
+
+func runStatuses(runs []*ScheduleRun) []string {
+	var statuses []string
+	for _, run := range runs {
+		statuses = append(statuses, run.Status)
+	}
+
+	return statuses
+}
+
+func checkStatuses(t *testing.T, runs []*ScheduleRun, expected ...string) {
+	t.Helper()
+
+	statuses := runStatuses(runs)
+	if len(statuses) != len(expected) {
+		t.Fatalf("expected runs %v got %v", expected, statuses)
+	}
+
+	for i := range expected {
+		if statuses[i] != expected[i] {
+			t.Fatalf("expected runs %v got %v", expected, statuses)
+		}
+	}
+}
+
+func TestSchedulerCatchUp(t *testing.T) {
+	tests := []struct {
+		catchUp  string
+		late     time.Duration
+		expected []string
+	}{
+		{CATCH_UP_SKIP, time.Minute, []string{SCHEDULE_RUN_MISSED, SCHEDULE_RUN_MISSED, SCHEDULE_RUN_SENT}},
+		{CATCH_UP_SKIP, SCHEDULE_GRACE_PERIOD + time.Minute, []string{SCHEDULE_RUN_MISSED, SCHEDULE_RUN_MISSED, SCHEDULE_RUN_MISSED}},
+		{CATCH_UP_ONCE, SCHEDULE_GRACE_PERIOD + time.Minute, []string{SCHEDULE_RUN_MISSED, SCHEDULE_RUN_MISSED, SCHEDULE_RUN_SENT}},
+		{CATCH_UP_ALL, SCHEDULE_GRACE_PERIOD + time.Minute, []string{SCHEDULE_RUN_SENT, SCHEDULE_RUN_SENT, SCHEDULE_RUN_SENT}},
+	}
+
+	for _, test := range tests {
+		newTestContext(t)
+		w, backend := newTestWallet(1)
+		s := addTestSchedule(t, w, "rent", test.catchUp)
+		sc := NewScheduler(w)
+
+		now := testLastRun.Add(3*time.Hour + test.late)
+		runs, err := sc.runSchedule(s, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		checkStatuses(t, runs, test.expected...)
+
+		sent := 0
+		for _, status := range test.expected {
+			if status == SCHEDULE_RUN_SENT {
+				sent++
+			}
+		}
+
+		if len(backend.Transfers) != sent {
+			t.Fatalf("%s: expected %d txs got %d", test.catchUp, sent, len(backend.Transfers))
+		}
+
+		// every run is handled once
+		saved, err := Context.GetSchedule(w.Id, "rent")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if saved.LastRun != testLastRun.Add(3*time.Hour).Unix() {
+			t.Fatalf("%s: last run not saved", test.catchUp)
+		}
+
+		runs, err = sc.runSchedule(saved, now)
+		if err != nil || len(runs) != 0 {
+			t.Fatalf("%s: runs handled again %v %v", test.catchUp, runStatuses(runs), err)
+		}
+
+		history, err := Context.GetScheduleRuns(w.Id, "rent")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if len(history) != len(test.expected) {
+			t.Fatalf("%s: expected %d runs in history got %d", test.catchUp, len(test.expected), len(history))
+		}
+	}
+}
+
+func TestSchedulerMaxCatchUp(t *testing.T) {
+	newTestContext(t)
+	w, _ := newTestWallet(1)
+	s := addTestSchedule(t, w, "rent", CATCH_UP_SKIP)
+
+	due, err := s.dueRuns(testLastRun.Add(time.Duration(SCHEDULE_MAX_CATCH_UP+50) * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(due) != SCHEDULE_MAX_CATCH_UP {
+		t.Fatalf("expected %d due runs got %d", SCHEDULE_MAX_CATCH_UP, len(due))
+	}
+
+	// the oldest runs are dropped
+	last := testLastRun.Add(time.Duration(SCHEDULE_MAX_CATCH_UP+50) * time.Hour)
+	if !due[len(due)-1].Equal(last) {
+		t.Fatalf("last due run is %s", due[len(due)-1])
+	}
+}
+
+func TestSchedulerCap(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+	s := addTestSchedule(t, w, "rent", CATCH_UP_ALL)
+
+	s.CapAmount = 200
+	s.CapPeriod = 24 * 60 * 60
+	_, err := Context.DB.Exec(`update app_schedules set cap_amount = ?, cap_period = ? where id = ?`, s.CapAmount, s.CapPeriod, s.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// scheduled txs are recorded with the schedule instead of the command running in the prompt
+	Context.commandLine = "transfer"
+	runs, err := NewScheduler(w).runSchedule(s, testLastRun.Add(3*time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkStatuses(t, runs, SCHEDULE_RUN_SENT, SCHEDULE_RUN_SENT, SCHEDULE_RUN_CAPPED)
+	if len(backend.Transfers) != 2 {
+		t.Fatalf("capped run was sent: %d txs", len(backend.Transfers))
+	}
+
+	history, err := Context.GetTxHistory(w.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for txid, h := range history {
+		if h.Command != "schedule rent" {
+			t.Fatalf("tx %s recorded with command [%s]", txid, h.Command)
+		}
+	}
+}
+
+func TestSchedulerFailedRun(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+	s := addTestSchedule(t, w, "rent", CATCH_UP_ONCE)
+
+	backend.Err = errors.New("connection refused")
+	runs, err := NewScheduler(w).runSchedule(s, testLastRun.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a failed run is not retried
+	checkStatuses(t, runs, SCHEDULE_RUN_FAILED)
+	if runs[0].Err != "connection refused" || s.LastRun != testLastRun.Add(time.Hour).Unix() {
+		t.Fatalf("unexpected failed run: %+v", runs[0])
+	}
+}
+
+func TestSchedulerPolicyReentry(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+	s := addTestSchedule(t, w, "rent", CATCH_UP_ONCE)
+
+	// the scheduler runs in the background and can't prompt the password
+	err := Context.SetWalletPolicy(&WalletPolicy{WalletId: w.Id, ReentryAbove: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := NewScheduler(w).runSchedule(s, testLastRun.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkStatuses(t, runs, SCHEDULE_RUN_FAILED)
+	if runs[0].Err != ErrPolicyPasswordRequired.Error() || len(backend.Transfers) != 0 {
+		t.Fatalf("unexpected run error: %s", runs[0].Err)
+	}
+}
+
+// hookWalletBackend calls onTransfer before the memory wallet sends the tx
+type hookWalletBackend struct {
+	*MemoryWalletBackend
+	onTransfer func()
+}
+
+func (b *hookWalletBackend) Transfer(params *rpc.Transfer_Params) (string, error) {
+	b.onTransfer()
+	return b.MemoryWalletBackend.Transfer(params)
+}
+
+func TestSchedulerSavesRunBeforeTransfer(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+	s := addTestSchedule(t, w, "rent", CATCH_UP_ONCE)
+
+	// a run interrupted while its tx is sent is never sent again
+	var status string
+	var lastRun int64
+	w.Backend = &hookWalletBackend{MemoryWalletBackend: backend, onTransfer: func() {
+		err := Context.DB.QueryRow(`select status from app_schedule_runs where schedule_id = ?`, s.Id).Scan(&status)
+		if err == nil {
+			err = Context.DB.QueryRow(`select last_run from app_schedules where id = ?`, s.Id).Scan(&lastRun)
+		}
+
+		if err != nil {
+			t.Error(err)
+		}
+	}}
+
+	runs, err := NewScheduler(w).runSchedule(s, testLastRun.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkStatuses(t, runs, SCHEDULE_RUN_SENT)
+	if status != SCHEDULE_RUN_SENDING || lastRun != testLastRun.Add(time.Hour).Unix() {
+		t.Fatalf("run was not saved before the transfer: status [%s] last run %d", status, lastRun)
+	}
+}
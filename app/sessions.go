@@ -0,0 +1,168 @@
+package app
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Sessions returns the wallets opened at the same time - Context.WalletInstance is the one in focus
+func (app *AppContext) Sessions() []*WalletInstance {
+	app.sessionsLock.Lock()
+	defer app.sessionsLock.Unlock()
+
+	return append([]*WalletInstance{}, app.sessions...)
+}
+
+func (app *AppContext) IsSession(w *WalletInstance) bool {
+	for _, s := range app.Sessions() {
+		if s == w {
+			return true
+		}
+	}
+
+	return false
+}
+
+// diskSession returns a wallet file open in this process other than w - they share the daemon of walletapi
+func (app *AppContext) diskSession(w *WalletInstance) *WalletInstance {
+	for _, s := range app.Sessions() {
+		if s == w || s.Daemon == nil {
+			continue
+		}
+
+		if _, ok := s.Backend.(*DiskWalletBackend); ok {
+			return s
+		}
+	}
+
+	return nil
+}
+
+// OpenSession opens the wallet with its own daemon connection and background workers - does nothing if it is already open
+func (app *AppContext) OpenSession(w *WalletInstance) error {
+	if app.IsSession(w) {
+		return nil
+	}
+
+	err := w.Open()
+	if err != nil {
+		w.Close()
+		return err
+	}
+
+	app.sessionsLock.Lock()
+	app.sessions = append(app.sessions, w)
+	app.sessionsLock.Unlock()
+	return nil
+}
+
+// setFocus changes the session and the app used by the commands
+func (app *AppContext) setFocus(w *WalletInstance, useApp string, dappApp *cli.App) {
+	app.focusLock.Lock()
+	defer app.focusLock.Unlock()
+
+	app.WalletInstance, app.UseApp, app.DAppApp = w, useApp, dappApp
+}
+
+// focus returns the session in focus and the dapp in use - safe to call from another goroutine
+func (app *AppContext) focus() (*WalletInstance, *cli.App) {
+	app.focusLock.RLock()
+	defer app.focusLock.RUnlock()
+
+	return app.WalletInstance, app.DAppApp
+}
+
+// FocusSession makes the session the wallet used by the commands
+func (app *AppContext) FocusSession(w *WalletInstance) {
+	app.setFocus(w, app.UseApp, app.DAppApp)
+}
+
+// SetUseApp switches between the wallet and the dapp commands of the session in focus
+func (app *AppContext) SetUseApp(useApp string, dappApp *cli.App) {
+	app.setFocus(app.WalletInstance, useApp, dappApp)
+}
+
+// CloseSession closes the wallet - the focus goes to the last opened session left or back to the root app
+func (app *AppContext) CloseSession(w *WalletInstance) {
+	app.sessionsLock.Lock()
+	for i, s := range app.sessions {
+		if s == w {
+			app.sessions = append(app.sessions[:i], app.sessions[i+1:]...)
+			break
+		}
+	}
+	app.sessionsLock.Unlock()
+
+	w.Close()
+
+	if app.WalletInstance != w {
+		return
+	}
+
+	sessions := app.Sessions()
+	if len(sessions) > 0 {
+		app.FocusSession(sessions[len(sessions)-1])
+		return
+	}
+
+	app.setFocus(nil, "rootApp", nil)
+}
+
+// CloseSessions closes every opened wallet
+func (app *AppContext) CloseSessions() {
+	for _, w := range app.Sessions() {
+		w.Close()
+	}
+
+	app.sessionsLock.Lock()
+	app.sessions = nil
+	app.sessionsLock.Unlock()
+	app.FocusSession(nil)
+}
+
+// targetWallet splits a leading --wallet name from the command args
+func targetWallet(args []string) (string, []string) {
+	if len(args) > 2 && args[1] == "--wallet" {
+		return args[2], append([]string{args[0]}, args[3:]...)
+	}
+
+	if len(args) > 1 && strings.HasPrefix(args[1], "--wallet=") {
+		return strings.TrimPrefix(args[1], "--wallet="), append([]string{args[0]}, args[2:]...)
+	}
+
+	return "", args
+}
+
+// runOnWallet runs the command with the session of the wallet in focus - the wallet is opened if needed
+// and the focus comes back to the previous session after the command
+func (app *AppContext) runOnWallet(name string, args []string) error {
+	_, w := app.GetWalletInstance(name)
+	if w == nil {
+		return fmt.Errorf("wallet [%s] does not exists", name)
+	}
+
+	err := app.OpenSession(w)
+	if err != nil {
+		return err
+	}
+
+	focused, useApp, dappApp := app.WalletInstance, app.UseApp, app.DAppApp
+	defer func() {
+		// the command may have closed the session in focus
+		if focused != nil && !app.IsSession(focused) {
+			focused, useApp, dappApp = nil, "rootApp", nil
+		}
+
+		app.setFocus(focused, useApp, dappApp)
+	}()
+
+	if app.UseApp == "dappApp" {
+		app.FocusSession(w)
+		return app.DAppApp.Run(args)
+	}
+
+	app.setFocus(w, "walletApp", app.DAppApp)
+	return app.walletApp.Run(args)
+}
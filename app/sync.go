@@ -0,0 +1,187 @@
+package app
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/g45t345rt/derosphere/commit_sync"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+var SYNC_INTERVAL = 10 * time.Second
+
+// syncLock makes sure only one engine writes to the db at a time
+var syncLock sync.Mutex
+
+type SyncStatus struct {
+	Name   string
+	At     uint64
+	Count  uint64
+	Synced bool // stored every commit at least once since the wallet opened
+	Err    error
+}
+
+// SyncWorker polls the commit counters of every dapp and applies new commits in the background
+type SyncWorker struct {
+	daemon  *rpc_client.Daemon
+	db      *sql.DB // env db when the worker was created - never the db of another env
+	engines []*commit_sync.Engine
+	stop    chan bool
+	done    chan bool // closed when the goroutine exited
+	lock    sync.RWMutex
+	status  map[string]*SyncStatus
+	height  int64 // daemon height of the last sync
+}
+
+func syncEngine(db *sql.DB, daemon *rpc_client.Daemon, engine *commit_sync.Engine) error {
+	syncLock.Lock()
+	defer syncLock.Unlock()
+
+	return engine.Sync(daemon, db)
+}
+
+// SyncCommits runs a dapp sync engine with the daemon of the open wallet.
+// Returns right away if the background worker already keeps this engine in sync.
+func SyncCommits(engine *commit_sync.Engine) error {
+	w := Context.WalletInstance
+	if w == nil || w.Daemon == nil {
+		return ErrWalletClosed
+	}
+
+	if w.syncWorker != nil && w.syncWorker.IsSynced(engine.Name) {
+		return nil
+	}
+
+	return syncEngine(Context.DB, w.Daemon, engine)
+}
+
+func NewSyncWorker(daemon *rpc_client.Daemon, engines []*commit_sync.Engine) *SyncWorker {
+	w := &SyncWorker{
+		daemon:  daemon,
+		db:      Context.DB,
+		engines: engines,
+		stop:    make(chan bool),
+		done:    make(chan bool),
+		status:  make(map[string]*SyncStatus),
+	}
+
+	for _, engine := range engines {
+		w.status[engine.Name] = &SyncStatus{Name: engine.Name}
+		engine.OnProgress = w.setProgress
+	}
+
+	return w
+}
+
+func (w *SyncWorker) setProgress(name string, at uint64, count uint64) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	status := w.status[name]
+	status.At = at
+	status.Count = count
+}
+
+func (w *SyncWorker) syncAll() {
+	result, err := w.daemon.GetHeight()
+	if err != nil {
+		return
+	}
+
+	for _, engine := range w.engines {
+		select {
+		case <-w.stop:
+			return
+		default:
+		}
+
+		err := syncEngine(w.db, w.daemon, engine)
+
+		w.lock.Lock()
+		status := w.status[engine.Name]
+		status.Err = err
+		if err == nil {
+			status.Synced = true
+		}
+		w.lock.Unlock()
+	}
+
+	w.lock.Lock()
+	w.height = int64(result.Height)
+	w.lock.Unlock()
+
+	// keep the sealed db up to date if the app is closed without exit
+	Context.SaveDB()
+}
+
+func (w *SyncWorker) Start() {
+	go func() {
+		defer close(w.done)
+
+		for {
+			w.syncAll()
+
+			select {
+			case <-w.stop:
+				return
+			case <-time.After(SYNC_INTERVAL):
+			}
+		}
+	}()
+}
+
+// Stop waits for the goroutine to exit - an engine syncing finishes its pass first
+func (w *SyncWorker) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+func (w *SyncWorker) IsSynced(name string) bool {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	status, ok := w.status[name]
+	return ok && status.Synced && status.Err == nil
+}
+
+func (w *SyncWorker) Status() (statuses []SyncStatus, height int64) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+
+	for _, engine := range w.engines {
+		statuses = append(statuses, *w.status[engine.Name])
+	}
+
+	return statuses, w.height
+}
+
+// PromptStatus is a short sync status for the prompt - only engines starting with prefix are displayed
+func (w *SyncWorker) PromptStatus(prefix string) string {
+	statuses, height := w.Status()
+
+	var syncing []string
+	for _, status := range statuses {
+		if !strings.HasPrefix(status.Name, prefix) {
+			continue
+		}
+
+		if status.Err != nil {
+			syncing = append(syncing, fmt.Sprintf("%s error", status.Name))
+		} else if !status.Synced || status.At < status.Count {
+			syncing = append(syncing, fmt.Sprintf("%s %d/%d", status.Name, status.At, status.Count))
+		}
+	}
+
+	if len(syncing) > 0 {
+		return fmt.Sprintf("sync %s", strings.Join(syncing, ", "))
+	}
+
+	if height == 0 {
+		return "sync..."
+	}
+
+	return fmt.Sprintf("synced %d", height)
+}
@@ -0,0 +1,203 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+)
+
+// Kinds of tx recorded in the history
+const (
+	TX_KIND_TRANSFER   = "transfer"
+	TX_KIND_SC_CALL    = "sc_call"
+	TX_KIND_SC_INSTALL = "sc_install"
+)
+
+// TxHistory is a tx created by derosphere with the command and dapp it came from.
+// Txs not created locally only have a note.
+type TxHistory struct {
+	TxId       string
+	WalletId   int64
+	Kind       string
+	Command    string
+	DApp       string
+	SCID       string
+	Entrypoint string
+	Args       string // json of the sc arguments without action, scid and entrypoint
+	Transfers  string // json of the transfers
+	Fees       uint64
+	Ringsize   uint64
+	Note       string
+	Timestamp  int64
+}
+
+func initTxHistoryTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_tx_history (
+			txid varchar primary key,
+			wallet_id integer,
+			kind varchar,
+			command varchar,
+			dapp varchar,
+			scid varchar,
+			entrypoint varchar,
+			args varchar,
+			transfers varchar,
+			fees bigint,
+			ringsize bigint,
+			note varchar,
+			timestamp bigint
+		);
+
+		create index if not exists app_tx_history_wallet on app_tx_history (wallet_id, timestamp);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+// txOrigin is the command and the dapp recorded with a tx
+type txOrigin struct {
+	command string
+	dapp    string
+}
+
+// commandOrigin returns the command running in the prompt - txs sent by background goroutines set their own origin
+func (app *AppContext) commandOrigin() txOrigin {
+	origin := txOrigin{command: app.commandLine}
+	if app.UseApp == "dappApp" && app.DAppApp != nil {
+		origin.dapp = app.DAppApp.Name
+	}
+
+	return origin
+}
+
+func newTxHistory(walletId int64, txid string, p *rpc.Transfer_Params, origin txOrigin) (*TxHistory, error) {
+	h := &TxHistory{
+		TxId:      txid,
+		WalletId:  walletId,
+		Kind:      TX_KIND_TRANSFER,
+		Fees:      p.Fees,
+		Ringsize:  p.Ringsize,
+		Command:   origin.command,
+		DApp:      origin.dapp,
+		Timestamp: time.Now().Unix(),
+	}
+
+	var args rpc.Arguments
+	for _, arg := range p.SC_RPC {
+		switch arg.Name {
+		case rpc.SCACTION:
+		case rpc.SCID:
+			if scid, ok := arg.Value.(crypto.Hash); ok {
+				h.SCID = scid.String()
+			}
+		case "entrypoint":
+			if entrypoint, ok := arg.Value.(string); ok {
+				h.Entrypoint = entrypoint
+			}
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	if p.SC_Code != "" {
+		// the install txid is the scid of the new smart contract
+		h.Kind = TX_KIND_SC_INSTALL
+		h.SCID = txid
+	} else if p.SC_RPC.Has(rpc.SCACTION, rpc.DataUint64) {
+		h.Kind = TX_KIND_SC_CALL
+	}
+
+	if len(args) > 0 {
+		data, err := json.Marshal(args)
+		if err != nil {
+			return nil, err
+		}
+
+		h.Args = string(data)
+	}
+
+	if len(p.Transfers) > 0 {
+		data, err := json.Marshal(p.Transfers)
+		if err != nil {
+			return nil, err
+		}
+
+		h.Transfers = string(data)
+	}
+
+	return h, nil
+}
+
+// recordTx saves a tx sent by the wallet - the tx is already sent so errors are only printed
+func (w *WalletInstance) recordTx(txid string, p *rpc.Transfer_Params, origin txOrigin) {
+	h, err := newTxHistory(w.Id, txid, p, origin)
+	if err == nil {
+		err = Context.SaveTxHistory(h)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not record tx [%s] in history: %s\n", txid, err)
+	}
+}
+
+func (app *AppContext) SaveTxHistory(h *TxHistory) error {
+	query := `
+		insert into app_tx_history (txid, wallet_id, kind, command, dapp, scid, entrypoint, args, transfers, fees, ringsize, note, timestamp)
+		values (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		on conflict(txid) do update
+		set wallet_id = excluded.wallet_id, kind = excluded.kind, command = excluded.command, dapp = excluded.dapp,
+		scid = excluded.scid, entrypoint = excluded.entrypoint, args = excluded.args, transfers = excluded.transfers,
+		fees = excluded.fees, ringsize = excluded.ringsize, timestamp = excluded.timestamp
+	`
+
+	_, err := app.DB.Exec(query, h.TxId, h.WalletId, h.Kind, h.Command, h.DApp, h.SCID, h.Entrypoint, h.Args, h.Transfers, h.Fees, h.Ringsize, h.Note, h.Timestamp)
+	return err
+}
+
+// GetTxHistory returns the recorded txs of a wallet by txid
+func (app *AppContext) GetTxHistory(walletId int64) (map[string]*TxHistory, error) {
+	query := `
+		select txid, wallet_id, kind, command, dapp, scid, entrypoint, args, transfers, fees, ringsize, note, timestamp
+		from app_tx_history
+		where wallet_id = ?
+	`
+
+	rows, err := app.DB.Query(query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	history := make(map[string]*TxHistory)
+	for rows.Next() {
+		h := new(TxHistory)
+		err = rows.Scan(&h.TxId, &h.WalletId, &h.Kind, &h.Command, &h.DApp, &h.SCID, &h.Entrypoint, &h.Args, &h.Transfers, &h.Fees, &h.Ringsize, &h.Note, &h.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		history[h.TxId] = h
+	}
+
+	return history, rows.Err()
+}
+
+// SetTxNote sets the note of a tx - txs not created locally are added with the note only
+func (app *AppContext) SetTxNote(walletId int64, txid string, note string) error {
+	query := `
+		insert into app_tx_history (txid, wallet_id, kind, command, dapp, scid, entrypoint, args, transfers, fees, ringsize, note, timestamp)
+		values (?, ?, '', '', '', '', '', '', '', 0, 0, ?, ?)
+		on conflict(txid) do update
+		set note = excluded.note
+	`
+
+	_, err := app.DB.Exec(query, txid, walletId, note, time.Now().Unix())
+	return err
+}
@@ -0,0 +1,158 @@
+package app
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/transaction"
+)
+
+// TxPayload is an asset payload of a tx - Entry is set if the open wallet can decrypt it
+type TxPayload struct {
+	Index    int
+	SCID     crypto.Hash
+	Burn     uint64
+	RingSize uint64
+	Ring     []string
+	Entry    *rpc.Entry
+}
+
+// TxInspection is a decoded tx with its state on chain
+type TxInspection struct {
+	TxId          string
+	Type          string
+	Signer        string
+	BuildHeight   uint64 // height of the state the tx was built with
+	BlockHeight   int64  // -1 while in pool
+	TopoHeight    int64
+	Confirmations int64
+	InPool        bool
+	ValidBlock    string
+	InvalidBlocks []string
+	Ignored       bool
+	Fees          uint64
+	SCArgs        rpc.Arguments
+	Payloads      []TxPayload
+	History       *TxHistory // nil if the tx was not recorded locally
+}
+
+// InspectTx fetches a tx from the daemon and decodes it
+func (w *WalletInstance) InspectTx(txid string) (*TxInspection, error) {
+	result, err := w.Daemon.GetTransaction(&rpc.GetTransaction_Params{
+		Tx_Hashes: []string{txid},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result.Txs) == 0 || len(result.Txs_as_hex) == 0 || result.Txs_as_hex[0] == "" {
+		return nil, fmt.Errorf("transaction [%s] not found", txid)
+	}
+
+	txBin, err := hex.DecodeString(result.Txs_as_hex[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var tx transaction.Transaction
+	err = tx.Deserialize(txBin)
+	if err != nil {
+		return nil, err
+	}
+
+	info := result.Txs[0]
+	inspection := &TxInspection{
+		TxId:          txid,
+		Type:          tx.TransactionType.String(),
+		Signer:        info.Signer,
+		BuildHeight:   tx.Height,
+		BlockHeight:   info.Block_Height,
+		TopoHeight:    -1,
+		InPool:        info.In_pool,
+		ValidBlock:    info.ValidBlock,
+		InvalidBlocks: info.InvalidBlock,
+		Ignored:       info.Ignored,
+		Fees:          tx.Fees(),
+		SCArgs:        tx.SCDATA,
+	}
+
+	for i, payload := range tx.Payloads {
+		p := TxPayload{
+			Index:    i,
+			SCID:     payload.SCID,
+			Burn:     payload.BurnValue,
+			RingSize: payload.Statement.RingSize,
+		}
+
+		if i < len(info.Ring) {
+			p.Ring = info.Ring[i]
+		}
+
+		inspection.Payloads = append(inspection.Payloads, p)
+	}
+
+	if info.ValidBlock != "" {
+		block, err := w.Daemon.GetBlock(&rpc.GetBlock_Params{Hash: info.ValidBlock})
+		if err != nil {
+			return nil, err
+		}
+
+		inspection.TopoHeight = block.Block_Header.TopoHeight
+
+		height, err := w.Daemon.GetHeight()
+		if err != nil {
+			return nil, err
+		}
+
+		inspection.Confirmations = int64(height.Height) - info.Block_Height + 1
+	}
+
+	err = w.decryptPayloads(inspection)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := Context.GetTxHistory(w.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	inspection.History = history[txid]
+	return inspection, nil
+}
+
+// decryptPayloads sets the wallet entries of the payloads sent or received by the open wallet
+func (w *WalletInstance) decryptPayloads(inspection *TxInspection) error {
+	if w.Backend == nil || inspection.BlockHeight < 0 {
+		return nil
+	}
+
+	for i := range inspection.Payloads {
+		p := &inspection.Payloads[i]
+		entries, err := w.GetTransfers(&rpc.Get_Transfers_Params{
+			SCID:       p.SCID,
+			In:         true,
+			Out:        true,
+			Coinbase:   true,
+			Min_Height: uint64(inspection.BlockHeight),
+			Max_Height: uint64(inspection.BlockHeight),
+		})
+
+		if err != nil {
+			return err
+		}
+
+		for j := range entries {
+			entry := entries[j]
+			if entry.TXID == inspection.TxId && entry.Pos == i {
+				p.Entry = &entry
+				break
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,374 @@
+package app
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+var TX_TRACK_INTERVAL = 5 * time.Second
+var TX_STUCK_BLOCKS = int64(10)    // blocks in mempool before a tx is reported as stuck
+var TX_MISSING_CHECKS = 3          // checks a tx can be missing from the daemon before it is reported as failed
+var TX_EVENT_DISPLAY = time.Minute // time the last event stays in the prompt
+
+const (
+	TX_STATUS_PENDING   = "pending"
+	TX_STATUS_STUCK     = "stuck"
+	TX_STATUS_CONFIRMED = "confirmed"
+	TX_STATUS_FAILED    = "failed"
+)
+
+var ErrTxStuck = errors.New("stuck transaction")
+
+// PendingTx is a broadcasted tx followed by the tracker until it is confirmed or failed
+type PendingTx struct {
+	TxId             string
+	WalletId         int64
+	WalletName       sql.NullString
+	Status           string
+	BroadcastHeight  int64
+	BlockHeight      int64
+	Misses           int
+	Err              string
+	Timestamp        int64
+	UpdatedTimestamp int64
+}
+
+func initPendingTxTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_pending_txs (
+			txid varchar primary key,
+			wallet_id integer,
+			status varchar,
+			broadcast_height bigint,
+			block_height bigint,
+			misses integer,
+			err varchar,
+			timestamp bigint,
+			updated_timestamp bigint
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+func (app *AppContext) AddPendingTx(walletId int64, txid string, broadcastHeight int64) error {
+	query := `
+		insert or replace into app_pending_txs (txid, wallet_id, status, broadcast_height, block_height, misses, err, timestamp, updated_timestamp)
+		values (?, ?, ?, ?, -1, 0, '', ?, ?)
+	`
+
+	now := time.Now().Unix()
+	_, err := app.DB.Exec(query, txid, walletId, TX_STATUS_PENDING, broadcastHeight, now, now)
+	return err
+}
+
+const pendingTxColumns = `p.txid, p.wallet_id, w.name, p.status, p.broadcast_height, p.block_height, p.misses, p.err, p.timestamp, p.updated_timestamp`
+
+func scanPendingTx(row interface{ Scan(...interface{}) error }) (*PendingTx, error) {
+	p := new(PendingTx)
+	err := row.Scan(&p.TxId, &p.WalletId, &p.WalletName, &p.Status, &p.BroadcastHeight, &p.BlockHeight, &p.Misses, &p.Err, &p.Timestamp, &p.UpdatedTimestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// GetPendingTxs returns the txs of a wallet or every wallet if walletId is not valid - resolved txs only if all is true
+func (app *AppContext) GetPendingTxs(walletId sql.NullInt64, all bool) ([]*PendingTx, error) {
+	query := fmt.Sprintf(`
+		select %s
+		from app_pending_txs as p
+		left join app_wallets as w on w.id = p.wallet_id
+		where (? is null or p.wallet_id = ?) and (? or p.status in (?, ?))
+		order by p.timestamp
+	`, pendingTxColumns)
+
+	rows, err := app.DB.Query(query, walletId, walletId, all, TX_STATUS_PENDING, TX_STATUS_STUCK)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var txs []*PendingTx
+	for rows.Next() {
+		p, err := scanPendingTx(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		txs = append(txs, p)
+	}
+
+	return txs, rows.Err()
+}
+
+func (app *AppContext) getPendingTx(txid string) (*PendingTx, error) {
+	query := fmt.Sprintf(`
+		select %s
+		from app_pending_txs as p
+		left join app_wallets as w on w.id = p.wallet_id
+		where p.txid = ?
+	`, pendingTxColumns)
+
+	p, err := scanPendingTx(app.DB.QueryRow(query, txid))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("transaction [%s] is not tracked", txid)
+	}
+
+	return p, err
+}
+
+func (app *AppContext) updatePendingTx(p *PendingTx) error {
+	query := `
+		update app_pending_txs
+		set status = ?, broadcast_height = ?, block_height = ?, misses = ?, err = ?, updated_timestamp = ?
+		where txid = ?
+	`
+
+	p.UpdatedTimestamp = time.Now().Unix()
+	_, err := app.DB.Exec(query, p.Status, p.BroadcastHeight, p.BlockHeight, p.Misses, p.Err, p.UpdatedTimestamp, p.TxId)
+	return err
+}
+
+// ClearResolvedTxs removes the confirmed and failed txs of a wallet or every wallet if walletId is not valid
+func (app *AppContext) ClearResolvedTxs(walletId sql.NullInt64) (int64, error) {
+	query := `
+		delete from app_pending_txs
+		where (? is null or wallet_id = ?) and status in (?, ?)
+	`
+
+	res, err := app.DB.Exec(query, walletId, walletId, TX_STATUS_CONFIRMED, TX_STATUS_FAILED)
+	if err != nil {
+		return 0, err
+	}
+
+	return res.RowsAffected()
+}
+
+// TxTracker checks the pending txs of the open wallet in the background and keeps the last event for the prompt
+type TxTracker struct {
+	walletId  int64
+	daemon    *rpc_client.Daemon
+	stop      chan bool
+	checkLock sync.Mutex // one check at a time - the background loop and commands waiting for a tx
+	lock      sync.RWMutex
+	pending   int
+	stuck     int
+	event     string
+	eventTime time.Time
+}
+
+func NewTxTracker(walletId int64, daemon *rpc_client.Daemon) *TxTracker {
+	return &TxTracker{
+		walletId: walletId,
+		daemon:   daemon,
+		stop:     make(chan bool),
+	}
+}
+
+func shortTxId(txid string) string {
+	if len(txid) > 12 {
+		return txid[:6] + ".." + txid[len(txid)-4:]
+	}
+
+	return txid
+}
+
+func (t *TxTracker) setEvent(event string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.event = event
+	t.eventTime = time.Now()
+}
+
+// checkTx updates the status of a tx from the daemon - returns an event if the status changed
+func (t *TxTracker) checkTx(p *PendingTx, height int64) (string, error) {
+	if p.BroadcastHeight <= 0 {
+		p.BroadcastHeight = height
+	}
+
+	result, err := t.daemon.GetTransaction(&rpc.GetTransaction_Params{
+		Tx_Hashes: []string{p.TxId},
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	status := p.Status
+	if len(result.Txs) > 0 && result.Txs[0].ValidBlock != "" {
+		p.Status = TX_STATUS_CONFIRMED
+		p.BlockHeight = result.Txs[0].Block_Height
+		p.Misses = 0
+	} else if len(result.Txs) > 0 && result.Txs[0].In_pool {
+		p.Misses = 0
+		if height-p.BroadcastHeight >= TX_STUCK_BLOCKS {
+			p.Status = TX_STATUS_STUCK
+		}
+	} else {
+		p.Misses++
+		if p.Misses >= TX_MISSING_CHECKS {
+			p.Status = TX_STATUS_FAILED
+			p.Err = "transaction is not in the mempool or a valid block"
+		}
+	}
+
+	err = Context.updatePendingTx(p)
+	if err != nil || p.Status == status {
+		return "", err
+	}
+
+	switch p.Status {
+	case TX_STATUS_CONFIRMED:
+		return fmt.Sprintf("tx %s confirmed at block %d", shortTxId(p.TxId), p.BlockHeight), nil
+	case TX_STATUS_STUCK:
+		return fmt.Sprintf("tx %s stuck in mempool for %d blocks", shortTxId(p.TxId), height-p.BroadcastHeight), nil
+	case TX_STATUS_FAILED:
+		return fmt.Sprintf("tx %s failed", shortTxId(p.TxId)), nil
+	}
+
+	return "", nil
+}
+
+// CheckAll checks every unresolved tx of the wallet once
+func (t *TxTracker) CheckAll() error {
+	t.checkLock.Lock()
+	defer t.checkLock.Unlock()
+
+	txs, err := Context.GetPendingTxs(sql.NullInt64{Int64: t.walletId, Valid: true}, false)
+	if err != nil {
+		return err
+	}
+
+	if len(txs) > 0 {
+		result, err := t.daemon.GetHeight()
+		if err != nil {
+			return err
+		}
+
+		for _, p := range txs {
+			event, err := t.checkTx(p, int64(result.Height))
+			if err != nil {
+				return err
+			}
+
+			if event != "" {
+				t.setEvent(event)
+			}
+		}
+	}
+
+	pending, stuck := 0, 0
+	for _, p := range txs {
+		switch p.Status {
+		case TX_STATUS_PENDING:
+			pending++
+		case TX_STATUS_STUCK:
+			stuck++
+		}
+	}
+
+	t.lock.Lock()
+	t.pending = pending
+	t.stuck = stuck
+	t.lock.Unlock()
+	return nil
+}
+
+// Track adds a broadcasted tx to the queue
+func (t *TxTracker) Track(txid string) error {
+	height := int64(0)
+	result, err := t.daemon.GetHeight()
+	if err == nil {
+		height = int64(result.Height)
+	}
+
+	err = Context.AddPendingTx(t.walletId, txid, height)
+	if err != nil {
+		return err
+	}
+
+	t.lock.Lock()
+	t.pending++
+	t.lock.Unlock()
+	return nil
+}
+
+// Wait blocks until the tx is confirmed - returns an error if it failed or is stuck
+func (t *TxTracker) Wait(txid string) error {
+	for {
+		err := t.CheckAll()
+		if err != nil {
+			return err
+		}
+
+		p, err := Context.getPendingTx(txid)
+		if err != nil {
+			return err
+		}
+
+		switch p.Status {
+		case TX_STATUS_CONFIRMED:
+			return nil
+		case TX_STATUS_STUCK:
+			return ErrTxStuck
+		case TX_STATUS_FAILED:
+			return errors.New(p.Err)
+		}
+
+		time.Sleep(TX_TRACK_INTERVAL)
+	}
+}
+
+func (t *TxTracker) Start() {
+	go func() {
+		for {
+			err := t.CheckAll()
+			if err == nil {
+				// keep the sealed db up to date if the app is closed without exit
+				Context.SaveDB()
+			}
+
+			select {
+			case <-t.stop:
+				return
+			case <-time.After(TX_TRACK_INTERVAL):
+			}
+		}
+	}()
+}
+
+func (t *TxTracker) Stop() {
+	close(t.stop)
+}
+
+// PromptStatus is the last event or the number of unresolved txs - empty if there is nothing to show
+func (t *TxTracker) PromptStatus() string {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.event != "" && time.Since(t.eventTime) < TX_EVENT_DISPLAY {
+		return t.event
+	}
+
+	var status []string
+	if t.pending > 0 {
+		status = append(status, fmt.Sprintf("%d pending tx", t.pending))
+	}
+
+	if t.stuck > 0 {
+		status = append(status, fmt.Sprintf("%d stuck tx", t.stuck))
+	}
+
+	return strings.Join(status, ", ")
+}
@@ -0,0 +1,346 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/g45t345rt/derosphere/secure"
+)
+
+var ErrVaultNotInitialized = errors.New("vault is not initialized - use vault init")
+var ErrVaultExists = errors.New("vault is already initialized")
+
+var VAULT_PASSPHRASE_ATTEMPTS = 3
+
+// vaultCheck is sealed when the vault is created to verify the passphrase
+var vaultCheck = []byte("derosphere vault")
+
+// Vault keeps the key of the wallet rpc credentials in memory until the unlock window expires
+type Vault struct {
+	key     *secure.Key
+	expires time.Time
+	lock    sync.Mutex
+}
+
+type Credential struct {
+	WalletId         int64
+	WalletName       sql.NullString
+	UpdatedTimestamp int64
+}
+
+type credentialSecret struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+func initVaultTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_vault (
+			id integer primary key,
+			salt blob,
+			check_value blob
+		);
+
+		create table if not exists app_credentials (
+			wallet_id integer primary key,
+			secret blob,
+			updated_timestamp bigint
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+func (app *AppContext) VaultExists() (bool, error) {
+	var count int
+	err := app.DB.QueryRow(`select count(*) from app_vault`).Scan(&count)
+	return count > 0, err
+}
+
+func (app *AppContext) InitVault(passphrase string) error {
+	exists, err := app.VaultExists()
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		return ErrVaultExists
+	}
+
+	key, err := secure.NewKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	check, err := key.Seal(vaultCheck)
+	if err != nil {
+		return err
+	}
+
+	_, err = app.DB.Exec(`insert into app_vault (id, salt, check_value) values (1, ?, ?)`, key.Salt, check)
+	if err != nil {
+		return err
+	}
+
+	app.vault.setKey(key, app.Config.VaultUnlockWindow)
+	return nil
+}
+
+func (v *Vault) setKey(key *secure.Key, window uint64) {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	v.key = key
+	v.expires = time.Now().Add(time.Duration(window) * time.Second)
+}
+
+func (v *Vault) validKey() *secure.Key {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+
+	if v.key != nil && time.Now().Before(v.expires) {
+		return v.key
+	}
+
+	v.key = nil
+	return nil
+}
+
+func (app *AppContext) LockVault() {
+	app.vault.setKey(nil, 0)
+}
+
+func (app *AppContext) IsVaultUnlocked() bool {
+	return app.vault.validKey() != nil
+}
+
+// UnlockVault returns the vault key - the passphrase is prompted if the unlock window expired
+func (app *AppContext) UnlockVault() (*secure.Key, error) {
+	key := app.vault.validKey()
+	if key != nil {
+		return key, nil
+	}
+
+	var salt, check []byte
+	err := app.DB.QueryRow(`select salt, check_value from app_vault where id = 1`).Scan(&salt, &check)
+	if err == sql.ErrNoRows {
+		return nil, ErrVaultNotInitialized
+	} else if err != nil {
+		return nil, err
+	}
+
+	attempts := VAULT_PASSPHRASE_ATTEMPTS
+	if app.Batch != nil {
+		attempts = 1
+	}
+
+	for i := 0; i < attempts; i++ {
+		passphrase, err := PromptPassword("Enter vault passphrase")
+		if err != nil {
+			return nil, err
+		}
+
+		key = secure.DeriveKey(passphrase, salt)
+		_, err = key.Open(check)
+		if err == nil {
+			// a window of 0 keeps the key for this use only
+			app.vault.setKey(key, app.Config.VaultUnlockWindow)
+			return key, nil
+		}
+
+		if i < attempts-1 {
+			fmt.Println("Invalid vault passphrase")
+		}
+	}
+
+	return nil, errors.New("invalid vault passphrase")
+}
+
+func (app *AppContext) SaveCredential(walletId int64, username string, password string) error {
+	key, err := app.UnlockVault()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(credentialSecret{Username: username, Password: password})
+	if err != nil {
+		return err
+	}
+
+	secret, err := key.Seal(data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		insert into app_credentials (wallet_id, secret, updated_timestamp)
+		values (?, ?, ?)
+		on conflict(wallet_id) do update
+		set secret = ?, updated_timestamp = ?
+	`
+
+	now := time.Now().Unix()
+	_, err = app.DB.Exec(query, walletId, secret, now, secret, now)
+	return err
+}
+
+func (app *AppContext) HasCredential(walletId int64) (bool, error) {
+	var count int
+	err := app.DB.QueryRow(`select count(*) from app_credentials where wallet_id = ?`, walletId).Scan(&count)
+	return count > 0, err
+}
+
+// GetCredential returns the stored wallet rpc credentials - ok is false if nothing is stored
+func (app *AppContext) GetCredential(walletId int64) (username string, password string, ok bool, err error) {
+	var secret []byte
+	err = app.DB.QueryRow(`select secret from app_credentials where wallet_id = ?`, walletId).Scan(&secret)
+	if err == sql.ErrNoRows {
+		return "", "", false, nil
+	} else if err != nil {
+		return "", "", false, err
+	}
+
+	key, err := app.UnlockVault()
+	if err != nil {
+		return "", "", false, err
+	}
+
+	data, err := key.Open(secret)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	var credential credentialSecret
+	err = json.Unmarshal(data, &credential)
+	if err != nil {
+		return "", "", false, err
+	}
+
+	return credential.Username, credential.Password, true, nil
+}
+
+func (app *AppContext) ListCredentials() ([]Credential, error) {
+	query := `
+		select c.wallet_id, w.name, c.updated_timestamp
+		from app_credentials as c
+		left join app_wallets as w on w.id = c.wallet_id
+		order by w.name
+	`
+
+	rows, err := app.DB.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var credentials []Credential
+	for rows.Next() {
+		var credential Credential
+		err = rows.Scan(&credential.WalletId, &credential.WalletName, &credential.UpdatedTimestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		credentials = append(credentials, credential)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (app *AppContext) ForgetCredential(walletId int64) (bool, error) {
+	res, err := app.DB.Exec(`delete from app_credentials where wallet_id = ?`, walletId)
+	if err != nil {
+		return false, err
+	}
+
+	count, err := res.RowsAffected()
+	return count > 0, err
+}
+
+// ChangeVaultPassphrase seals every stored credential again with a new passphrase
+func (app *AppContext) ChangeVaultPassphrase(passphrase string) error {
+	oldKey, err := app.UnlockVault()
+	if err != nil {
+		return err
+	}
+
+	newKey, err := secure.NewKey(passphrase)
+	if err != nil {
+		return err
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`select wallet_id, secret from app_credentials`)
+	if err != nil {
+		return err
+	}
+
+	secrets := make(map[int64][]byte)
+	for rows.Next() {
+		var walletId int64
+		var secret []byte
+		err = rows.Scan(&walletId, &secret)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		secrets[walletId] = secret
+	}
+
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	for walletId, secret := range secrets {
+		data, err := oldKey.Open(secret)
+		if err != nil {
+			return err
+		}
+
+		secret, err = newKey.Seal(data)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`update app_credentials set secret = ? where wallet_id = ?`, secret, walletId)
+		if err != nil {
+			return err
+		}
+	}
+
+	check, err := newKey.Seal(vaultCheck)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`update app_vault set salt = ?, check_value = ? where id = 1`, newKey.Salt, check)
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	app.vault.setKey(newKey, app.Config.VaultUnlockWindow)
+	return nil
+}
+
+func (app *AppContext) SetVaultUnlockWindow(window uint64) {
+	app.Config.VaultUnlockWindow = window
+	app.SaveConfig()
+	app.LockVault()
+}
@@ -0,0 +1,304 @@
+package app
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/utils"
+)
+
+// Where the asset discovery found a scid
+const (
+	ASSET_SOURCE_RECORDS    = "records"    // local records of the wallet - tx history, payouts, schedules...
+	ASSET_SOURCE_DAPPS      = "dapps"      // synced dapp tables like the asset-trade orders and auctions
+	ASSET_SOURCE_COLLECTION = "collection" // asset of a G45-C collection seen
+)
+
+// WalletAsset is a token discovered for a wallet with its balance and transfer count at the last discovery
+type WalletAsset struct {
+	WalletId  int64
+	Asset     *Asset
+	Source    string
+	Balance   uint64
+	Transfers int
+	Err       string // the balance could not be read - a watch-only wallet without view for example
+	Updated   int64
+}
+
+// AssetDiscovery is the result of a discovery run
+type AssetDiscovery struct {
+	Assets     []*WalletAsset
+	Candidates int
+	Failed     map[crypto.Hash]error // scids that could not be classified
+}
+
+func initWalletAssetTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_wallet_assets (
+			wallet_id integer,
+			scid varchar,
+			source varchar,
+			balance bigint,
+			transfers integer,
+			error varchar,
+			updated bigint,
+			primary key (wallet_id, scid)
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+type assetCandidates struct {
+	scids   []crypto.Hash
+	sources map[crypto.Hash]string
+}
+
+func (c *assetCandidates) add(scid crypto.Hash, source string) {
+	if scid.IsZero() {
+		return
+	}
+
+	if _, ok := c.sources[scid]; ok {
+		return
+	}
+
+	c.sources[scid] = source
+	c.scids = append(c.scids, scid)
+}
+
+func (c *assetCandidates) addHex(scid string, source string) {
+	if len(scid) == 64 {
+		c.add(crypto.HashHexToHash(scid), source)
+	}
+}
+
+// collectionSCIDs returns the G45-C collections of the asset cache - the ones referenced by a token and the ones classified
+func (app *AppContext) collectionSCIDs() ([]string, error) {
+	query := `
+		select collection from app_assets where collection <> ''
+		union select scid from app_assets where standard = ?
+	`
+
+	rows, err := app.DB.Query(query, ASSET_G45_C)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var scids []string
+	for rows.Next() {
+		var scid string
+		err = rows.Scan(&scid)
+		if err != nil {
+			return nil, err
+		}
+
+		scids = append(scids, scid)
+	}
+
+	return scids, rows.Err()
+}
+
+// collectionAssets reads the assets of a G45-C collection from the daemon
+func (w *WalletInstance) collectionAssets(scid crypto.Hash) ([]string, error) {
+	result, err := w.Daemon.GetSC(&rpc.GetSC_Params{
+		SCID:      scid.String(),
+		Code:      true,
+		Variables: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	collection := utils.G45_C{}
+	err = collection.Parse(scid.String(), result)
+	if err != nil {
+		return nil, err
+	}
+
+	var assets []string
+	for asset := range collection.Assets {
+		assets = append(assets, asset)
+	}
+
+	return assets, nil
+}
+
+// DiscoverAssets finds the tokens of the wallet in its local records, the synced dapp tables and the G45-C collections seen,
+// classifies them and reads their balance and transfer history - the results are cached in the wallet assets.
+// progress is called before each scid is probed.
+func (w *WalletInstance) DiscoverAssets(progress func(done int, total int)) (*AssetDiscovery, error) {
+	candidates := &assetCandidates{sources: make(map[crypto.Hash]string)}
+
+	// assets of the last discovery keep their source
+	cached, err := Context.GetWalletAssets(w.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, wa := range cached {
+		candidates.add(wa.Asset.SCID, wa.Source)
+	}
+
+	known, err := Context.KnownSCIDs(w.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, scid := range known {
+		candidates.add(scid, ASSET_SOURCE_RECORDS)
+	}
+
+	if Context.DAppAssetSCIDs != nil {
+		scids, err := Context.DAppAssetSCIDs()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, scid := range scids {
+			candidates.addHex(scid, ASSET_SOURCE_DAPPS)
+		}
+	}
+
+	collections, err := Context.collectionSCIDs()
+	if err != nil {
+		return nil, err
+	}
+
+	expanded := make(map[crypto.Hash]bool)
+	expand := func(scid crypto.Hash) {
+		if scid.IsZero() || expanded[scid] {
+			return
+		}
+
+		expanded[scid] = true
+		assets, err := w.collectionAssets(scid)
+		if err != nil {
+			return // not a G45-C or not readable - the collection scid itself is probed as a candidate
+		}
+
+		for _, asset := range assets {
+			candidates.addHex(asset, ASSET_SOURCE_COLLECTION)
+		}
+	}
+
+	for _, scid := range collections {
+		if len(scid) == 64 {
+			expand(crypto.HashHexToHash(scid))
+		}
+	}
+
+	discovery := &AssetDiscovery{Failed: make(map[crypto.Hash]error)}
+
+	// the list grows while probing - a new collection adds its assets
+	for i := 0; i < len(candidates.scids); i++ {
+		scid := candidates.scids[i]
+		if progress != nil {
+			progress(i, len(candidates.scids))
+		}
+
+		asset, err := w.ClassifyAsset(scid)
+		if err != nil {
+			discovery.Failed[scid] = err
+			continue
+		}
+
+		if asset.Standard == ASSET_G45_C {
+			expand(scid)
+		} else if len(asset.Collection) == 64 {
+			expand(crypto.HashHexToHash(asset.Collection))
+		}
+
+		if !asset.IsToken() {
+			continue
+		}
+
+		wa := &WalletAsset{WalletId: w.Id, Asset: asset, Source: candidates.sources[scid], Updated: time.Now().Unix()}
+		wa.Balance, err = w.GetBalance(scid)
+		if err != nil {
+			wa.Err = err.Error()
+		}
+
+		entries, err := w.GetTransfers(&rpc.Get_Transfers_Params{
+			SCID:     scid,
+			In:       true,
+			Out:      true,
+			Coinbase: true,
+		})
+		if err == nil {
+			wa.Transfers = len(entries)
+		}
+
+		// a token never held nor transferred is only a candidate
+		if wa.Err == "" && wa.Balance == 0 && wa.Transfers == 0 && wa.Source != ASSET_SOURCE_RECORDS {
+			_, err = Context.DB.Exec(`delete from app_wallet_assets where wallet_id = ? and scid = ?`, w.Id, scid.String())
+			if err != nil {
+				return nil, err
+			}
+
+			continue
+		}
+
+		err = Context.saveWalletAsset(wa)
+		if err != nil {
+			return nil, err
+		}
+
+		discovery.Assets = append(discovery.Assets, wa)
+	}
+
+	discovery.Candidates = len(candidates.scids)
+	if progress != nil {
+		progress(discovery.Candidates, discovery.Candidates)
+	}
+
+	return discovery, nil
+}
+
+func (app *AppContext) saveWalletAsset(wa *WalletAsset) error {
+	query := `
+		insert or replace into app_wallet_assets (wallet_id, scid, source, balance, transfers, error, updated)
+		values (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := app.DB.Exec(query, wa.WalletId, wa.Asset.SCID.String(), wa.Source, wa.Balance, wa.Transfers, wa.Err, wa.Updated)
+	return err
+}
+
+// GetWalletAssets returns the cached assets of the last discovery with their classification
+func (app *AppContext) GetWalletAssets(walletId int64) ([]*WalletAsset, error) {
+	query := `
+		select w.scid, w.source, w.balance, w.transfers, w.error, w.updated,
+			a.standard, a.decimals, a.collection, a.metadata_format, a.metadata, a.checked
+		from app_wallet_assets as w
+		join app_assets as a on a.scid = w.scid
+		where w.wallet_id = ?
+		order by w.balance = 0, w.scid
+	`
+
+	rows, err := app.DB.Query(query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []*WalletAsset
+	for rows.Next() {
+		wa := &WalletAsset{WalletId: walletId, Asset: new(Asset)}
+		var scid string
+		err = rows.Scan(&scid, &wa.Source, &wa.Balance, &wa.Transfers, &wa.Err, &wa.Updated,
+			&wa.Asset.Standard, &wa.Asset.Decimals, &wa.Asset.Collection, &wa.Asset.MetadataFormat, &wa.Asset.Metadata, &wa.Asset.Checked)
+		if err != nil {
+			return nil, err
+		}
+
+		wa.Asset.SCID = crypto.HashHexToHash(scid)
+		assets = append(assets, wa)
+	}
+
+	return assets, rows.Err()
+}
@@ -0,0 +1,63 @@
+package app
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/transaction"
+	"github.com/deroproject/derohe/walletapi"
+)
+
+// WalletBackend is the wallet connection used by a WalletInstance (wallet rpc, wallet file...)
+type WalletBackend interface {
+	GetAddress() (string, error)
+	GetSeed() (string, error)
+	GetBalance(scid crypto.Hash) (uint64, error)
+	GetHeight() (uint64, error)
+	GetTransfers(params *rpc.Get_Transfers_Params) ([]rpc.Entry, error)
+	IsRegistered() bool
+	Register() error
+	Transfer(params *rpc.Transfer_Params) (string, error)
+	HasPassword() bool                  // false if the backend can't check a password
+	CheckPassword(password string) bool // wallet file password or wallet rpc auth password
+	SetDaemon(address string)           // called when the node pool switched to another daemon
+	Close()
+}
+
+// solveRegistrationTx finds a registration tx matching the anti-spam POW - can take a few hours
+func solveRegistrationTx(wallet *walletapi.Wallet_Memory) *transaction.Transaction {
+	fmt.Println("Please wait while the app solves the POW to register the new wallet...")
+	fmt.Println("Can take a few hours!")
+
+	chanTx := make(chan *transaction.Transaction)
+
+	var counter uint64
+	var found int32 // need this to cancel other parallel loop
+	maxThreads := runtime.GOMAXPROCS(0)
+	fmt.Printf("Using %d threads\n", maxThreads)
+
+	for i := 0; i < maxThreads; i++ {
+		go func() {
+			for atomic.LoadInt32(&found) == 0 {
+				tempTx := wallet.GetRegistrationTX()
+				hash := tempTx.GetHash()
+
+				if hash[0] == 0 && hash[1] == 0 && hash[2] == 0 {
+					if atomic.CompareAndSwapInt32(&found, 0, 1) {
+						chanTx <- tempTx
+					}
+					break
+				}
+
+				fmt.Printf("%d tries\r", atomic.AddUint64(&counter, 1))
+			}
+		}()
+	}
+
+	regTx := <-chanTx
+	fmt.Println("Valid registration tx found!")
+	return regTx
+}
@@ -0,0 +1,126 @@
+package app
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/transaction"
+	"github.com/deroproject/derohe/walletapi"
+)
+
+type DiskWalletBackend struct {
+	Wallet *walletapi.Wallet_Disk
+}
+
+func NewDiskWalletBackend(wallet *walletapi.Wallet_Disk) *DiskWalletBackend {
+	return &DiskWalletBackend{Wallet: wallet}
+}
+
+func (b *DiskWalletBackend) GetAddress() (string, error) {
+	return b.Wallet.GetAddress().String(), nil
+}
+
+func (b *DiskWalletBackend) GetSeed() (string, error) {
+	return b.Wallet.GetSeed(), nil
+}
+
+func (b *DiskWalletBackend) GetBalance(scid crypto.Hash) (uint64, error) {
+	err := b.Wallet.Sync_Wallet_Memory_With_Daemon_internal(scid)
+	if err != nil {
+		return 0, err
+	}
+
+	m_balance, _ := b.Wallet.Get_Balance_scid(scid)
+	return m_balance, nil
+}
+
+func (b *DiskWalletBackend) GetHeight() (uint64, error) {
+	return b.Wallet.Get_Height(), nil
+}
+
+func (b *DiskWalletBackend) GetTransfers(params *rpc.Get_Transfers_Params) ([]rpc.Entry, error) {
+	entries := b.Wallet.Show_Transfers(
+		params.SCID,
+		params.Coinbase,
+		params.In,
+		params.Out,
+		params.Min_Height,
+		params.Max_Height,
+		params.Sender,
+		params.Receiver,
+		params.DestinationPort,
+		0,
+	)
+
+	return entries, nil
+}
+
+func (b *DiskWalletBackend) IsRegistered() bool {
+	return b.Wallet.IsRegistered()
+}
+
+func (b *DiskWalletBackend) Register() error {
+	regTx := solveRegistrationTx(b.Wallet.Wallet_Memory)
+	fmt.Println("Sending transaction to blockchain...")
+	return b.Wallet.SendTransaction(regTx)
+}
+
+func (b *DiskWalletBackend) Transfer(p *rpc.Transfer_Params) (string, error) {
+	for _, t := range p.Transfers {
+		_, err := t.Payload_RPC.CheckPack(transaction.PAYLOAD0_LIMIT)
+		if err != nil {
+			return "", &TxNotSentError{err}
+		}
+	}
+
+	if len(p.SC_Code) >= 1 {
+		if sc, err := base64.StdEncoding.DecodeString(p.SC_Code); err == nil {
+			p.SC_Code = string(sc)
+		}
+	}
+
+	if p.SC_Code != "" && p.SC_ID == "" {
+		p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCACTION, DataType: rpc.DataUint64, Value: uint64(rpc.SC_INSTALL)})
+		p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCCODE, DataType: rpc.DataString, Value: p.SC_Code})
+	}
+
+	if p.SC_ID != "" {
+		p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCACTION, DataType: rpc.DataUint64, Value: uint64(rpc.SC_CALL)})
+		p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCID, DataType: rpc.DataHash, Value: crypto.HashHexToHash(p.SC_ID)})
+		if p.SC_Code != "" {
+			p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCCODE, DataType: rpc.DataString, Value: p.SC_Code})
+		}
+	}
+
+	tx, err := b.Wallet.TransferPayload0(p.Transfers, p.Ringsize, false, p.SC_RPC, p.Fees, false)
+	if err != nil {
+		return "", &TxNotSentError{err}
+	}
+
+	err = b.Wallet.SendTransaction(tx)
+	if err != nil {
+		return "", err
+	}
+
+	return tx.GetHash().String(), nil
+}
+
+func (b *DiskWalletBackend) HasPassword() bool {
+	return true
+}
+
+func (b *DiskWalletBackend) CheckPassword(password string) bool {
+	return b.Wallet.Check_Password(password)
+}
+
+// SetDaemon reconnects walletapi - the daemon connection of wallet files is global to walletapi
+func (b *DiskWalletBackend) SetDaemon(address string) {
+	setWalletapiDaemon(address)
+	go walletapi.Connect("")
+}
+
+func (b *DiskWalletBackend) Close() {
+	b.Wallet.Close_Encrypted_Wallet()
+}
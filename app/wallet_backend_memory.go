@@ -0,0 +1,119 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+)
+
+// MemoryWalletBackend is a fake wallet used for tests and dry runs - nothing is sent to the blockchain
+type MemoryWalletBackend struct {
+	Address    string
+	Seed       string
+	Height     uint64
+	Registered bool
+	Balances   map[crypto.Hash]uint64
+	Entries    []rpc.Entry
+	Transfers  []rpc.Transfer_Params // every transfer sent with Transfer()
+	Password   string                // checked by CheckPassword if set
+	Err        error                 // returned by every call if set
+}
+
+func NewMemoryWalletBackend(address string) *MemoryWalletBackend {
+	return &MemoryWalletBackend{
+		Address:  address,
+		Balances: make(map[crypto.Hash]uint64),
+	}
+}
+
+func (b *MemoryWalletBackend) GetAddress() (string, error) {
+	return b.Address, b.Err
+}
+
+func (b *MemoryWalletBackend) GetSeed() (string, error) {
+	return b.Seed, b.Err
+}
+
+func (b *MemoryWalletBackend) GetBalance(scid crypto.Hash) (uint64, error) {
+	return b.Balances[scid], b.Err
+}
+
+func (b *MemoryWalletBackend) GetHeight() (uint64, error) {
+	return b.Height, b.Err
+}
+
+func (b *MemoryWalletBackend) GetTransfers(params *rpc.Get_Transfers_Params) ([]rpc.Entry, error) {
+	if b.Err != nil {
+		return nil, b.Err
+	}
+
+	var entries []rpc.Entry
+	for _, entry := range b.Entries {
+		if (entry.Coinbase && params.Coinbase) || (entry.Incoming && !entry.Coinbase && params.In) || (!entry.Incoming && params.Out) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (b *MemoryWalletBackend) IsRegistered() bool {
+	return b.Registered
+}
+
+func (b *MemoryWalletBackend) Register() error {
+	if b.Err != nil {
+		return b.Err
+	}
+
+	b.Registered = true
+	return nil
+}
+
+func (b *MemoryWalletBackend) Transfer(params *rpc.Transfer_Params) (string, error) {
+	if b.Err != nil {
+		return "", b.Err
+	}
+
+	if !b.Registered {
+		return "", errors.New("wallet is not registered")
+	}
+
+	total := make(map[crypto.Hash]uint64)
+	for _, transfer := range params.Transfers {
+		total[transfer.SCID] += transfer.Amount + transfer.Burn
+	}
+
+	var zeroHash crypto.Hash
+	total[zeroHash] += params.Fees
+
+	for scid, amount := range total {
+		if b.Balances[scid] < amount {
+			return "", fmt.Errorf("insufficient balance for %s", scid)
+		}
+	}
+
+	for scid, amount := range total {
+		b.Balances[scid] -= amount
+	}
+
+	b.Transfers = append(b.Transfers, *params)
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%s%d", b.Address, len(b.Transfers))))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+func (b *MemoryWalletBackend) HasPassword() bool {
+	return b.Password != ""
+}
+
+func (b *MemoryWalletBackend) CheckPassword(password string) bool {
+	return b.Password != "" && b.Password == password
+}
+
+func (b *MemoryWalletBackend) SetDaemon(address string) {}
+
+func (b *MemoryWalletBackend) Close() {}
@@ -0,0 +1,252 @@
+package app
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/walletapi"
+	"github.com/deroproject/derohe/walletapi/rpcserver"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+// WALLET_PROCESS_COMMAND is the hidden command of the child process serving a wallet file
+var WALLET_PROCESS_COMMAND = "wallet-process"
+var WALLET_PROCESS_START_TIMEOUT = 30 * time.Second
+var WALLET_PROCESS_STOP_TIMEOUT = 5 * time.Second
+
+// walletProcessParams is sent to the child on the first line of its stdin - the password never shows in the process args
+type walletProcessParams struct {
+	Env      string `json:"env"`
+	File     string `json:"file"`
+	Password string `json:"password"`
+	Daemon   string `json:"daemon"`
+	Bind     string `json:"bind"`
+	Login    string `json:"login"`
+}
+
+// ProcessWalletBackend is a wallet file opened by a child process serving it with wallet rpc.
+// walletapi connects every wallet file of a process to the same daemon so a wallet file using another daemon
+// than the wallet files already open gets its own process and daemon connection.
+type ProcessWalletBackend struct {
+	*RPCWalletBackend
+	cmd          *exec.Cmd
+	stdin        io.WriteCloser
+	passwordHash [32]byte
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+func freeLocalAddress() (string, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", err
+	}
+
+	address := listener.Addr().String()
+	return address, listener.Close()
+}
+
+// StartProcessWalletBackend opens the wallet file in a child process connected to the daemon - returns the error of the child
+// if the wallet can't be opened ("Invalid Password"...)
+func StartProcessWalletBackend(file string, password string, daemon *rpc_client.Daemon) (*ProcessWalletBackend, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+
+	bind, err := freeLocalAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	username := "derosphere"
+	rpcPassword, err := randomHex(16)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(exe, WALLET_PROCESS_COMMAND)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	err = cmd.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	b := &ProcessWalletBackend{
+		cmd:          cmd,
+		stdin:        stdin,
+		passwordHash: sha256.Sum256([]byte(password)),
+	}
+
+	err = json.NewEncoder(stdin).Encode(walletProcessParams{
+		Env:      Context.Config.Env,
+		File:     file,
+		Password: password,
+		Daemon:   daemon.Address,
+		Bind:     bind,
+		Login:    fmt.Sprintf("%s:%s", username, rpcPassword),
+	})
+
+	if err != nil {
+		b.Close()
+		return nil, err
+	}
+
+	reader := bufio.NewReader(stdout)
+	line, err := reader.ReadString('\n')
+	line = strings.TrimSpace(line)
+	if err != nil || line != "ready" {
+		b.Close()
+		if strings.HasPrefix(line, "error: ") {
+			return nil, errors.New(strings.TrimPrefix(line, "error: "))
+		}
+
+		return nil, fmt.Errorf("wallet process exited before opening the wallet file")
+	}
+
+	// the child keeps running - its stdout is not used after the ready line
+	go io.Copy(ioutil.Discard, reader)
+
+	walletRPC := new(rpc_client.Wallet)
+	walletRPC.SetClientWithAuth(fmt.Sprintf("http://%s", bind), username, rpcPassword)
+	b.RPCWalletBackend = NewRPCWalletBackend(walletRPC, daemon)
+
+	// the rpc server starts listening in the background of the child
+	deadline := time.Now().Add(WALLET_PROCESS_START_TIMEOUT)
+	for {
+		_, err = walletRPC.GetHeight()
+		if err == nil {
+			return b, nil
+		}
+
+		if time.Now().After(deadline) {
+			b.Close()
+			return nil, fmt.Errorf("wallet process rpc did not start: %s", err)
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func (b *ProcessWalletBackend) HasPassword() bool {
+	return true
+}
+
+func (b *ProcessWalletBackend) CheckPassword(password string) bool {
+	hash := sha256.Sum256([]byte(password))
+	return subtle.ConstantTimeCompare(hash[:], b.passwordHash[:]) == 1
+}
+
+// SetDaemon reconnects the walletapi of the child
+func (b *ProcessWalletBackend) SetDaemon(address string) {
+	fmt.Fprintf(b.stdin, "daemon %s\n", address)
+}
+
+// Close closes the stdin of the child so it closes the wallet file and exits - killed if it takes too long
+func (b *ProcessWalletBackend) Close() {
+	b.stdin.Close()
+
+	done := make(chan bool)
+	go func() {
+		b.cmd.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(WALLET_PROCESS_STOP_TIMEOUT):
+		b.cmd.Process.Kill()
+		<-done
+	}
+}
+
+// ServeWalletProcess is the child side of ProcessWalletBackend - serves the wallet file with wallet rpc until stdin is closed.
+// The following stdin lines are commands: "daemon address" reconnects to another daemon.
+func ServeWalletProcess(in io.Reader, out io.Writer) error {
+	reader := bufio.NewReader(in)
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return err
+	}
+
+	var params walletProcessParams
+	err = json.Unmarshal([]byte(line), &params)
+	if err != nil {
+		return err
+	}
+
+	setEnvGlobals(params.Env)
+	setWalletapiDaemon(params.Daemon)
+	globals.Arguments["--rpc-bind"] = params.Bind
+	globals.Arguments["--rpc-login"] = params.Login
+
+	// same balance lookup table as the wallet app - the walletapi default is much smaller
+	walletapi.Initialize_LookupTable(1, 1<<19)
+
+	// errors are returned to the parent on the ready line
+	wallet, err := walletapi.Open_Encrypted_Wallet(params.File, params.Password)
+	if err != nil {
+		fmt.Fprintf(out, "error: %s\n", err)
+		return nil
+	}
+	defer wallet.Close_Encrypted_Wallet()
+
+	wallet.SetNetwork(globals.IsMainnet())
+	wallet.SetOnlineMode()
+	go walletapi.Keep_Connectivity()
+
+	server, err := rpcserver.RPCServer_Start(wallet, "walletrpc")
+	if err != nil {
+		fmt.Fprintf(out, "error: %s\n", err)
+		return nil
+	}
+	defer server.RPCServer_Stop()
+
+	fmt.Fprintln(out, "ready")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// the parent closed the wallet or exited
+			return nil
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "daemon" {
+			setWalletapiDaemon(fields[1])
+			go walletapi.Connect("")
+		}
+	}
+}
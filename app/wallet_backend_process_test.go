@@ -0,0 +1,165 @@
+package app
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/walletapi"
+	"github.com/g45t345rt/derosphere/rpc_client"
+	"github.com/gorilla/websocket"
+)
+
+// TestMain lets the test binary serve a wallet file when it is started by StartProcessWalletBackend
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == WALLET_PROCESS_COMMAND {
+		err := ServeWalletProcess(os.Stdin, os.Stdout)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
+	os.Exit(m.Run())
+}
+
+// fakeWalletDaemon is the websocket daemon walletapi connects to - serves the encrypted balance of one account
+type fakeWalletDaemon struct {
+	balance *crypto.ElGamal
+}
+
+func (d *fakeWalletDaemon) result(method string) (interface{}, error) {
+	switch method {
+	case "DERO.Ping":
+		return "Pong", nil
+	case "DERO.Echo":
+		return "hello world", nil
+	case "DERO.GetInfo":
+		return rpc.GetInfo_Result{Height: 10, TopoHeight: 10, Testnet: true, Network: "Simulator"}, nil
+	case "DERO.GetEncryptedBalance":
+		nb := crypto.NonceBalance{Balance: d.balance}
+		return rpc.GetEncryptedBalance_Result{
+			Data:         hex.EncodeToString(nb.Serialize()),
+			Registration: -1, // no history to sync
+			Height:       10,
+			Topoheight:   10,
+			DHeight:      10,
+			DTopoheight:  10,
+			Status:       "OK",
+		}, nil
+	}
+
+	return nil, fmt.Errorf("method [%s] not found", method)
+}
+
+type fakeRequest struct {
+	Id     interface{} `json:"id"`
+	Method string      `json:"method"`
+}
+
+func (d *fakeWalletDaemon) response(req fakeRequest) map[string]interface{} {
+	res := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id}
+	result, err := d.result(req.Method)
+	if err != nil {
+		res["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+	} else {
+		res["result"] = result
+	}
+
+	return res
+}
+
+// ServeHTTP answers the json rpc of the app on /json_rpc and the json rpc of walletapi on /ws
+func (d *fakeWalletDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !websocket.IsWebSocketUpgrade(r) {
+		var req fakeRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		json.NewEncoder(w).Encode(d.response(req))
+		return
+	}
+
+	conn, err := new(websocket.Upgrader).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	for {
+		var req fakeRequest
+		err := conn.ReadJSON(&req)
+		if err != nil {
+			return
+		}
+
+		err = conn.WriteJSON(d.response(req))
+		if err != nil {
+			return
+		}
+	}
+}
+
+func newTestWalletFile(t *testing.T, password string) (string, *walletapi.Wallet_Disk) {
+	t.Helper()
+
+	file := filepath.Join(t.TempDir(), "wallet.db")
+	wallet, err := walletapi.Create_Encrypted_Wallet_Random(file, password)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wallet.Close_Encrypted_Wallet()
+	return file, wallet
+}
+
+func TestProcessWalletBalance(t *testing.T) {
+	newTestContext(t)
+
+	file, wallet := newTestWalletFile(t, "pass")
+	publicKey := wallet.GetAddress().PublicKey.G1()
+
+	// the child decodes the encrypted balance sent by the daemon
+	balance := crypto.ConstructElGamal(publicKey, crypto.ElGamal_BASE_G).Plus(new(big.Int).SetUint64(12345))
+	server := httptest.NewServer(&fakeWalletDaemon{balance: balance})
+	t.Cleanup(server.Close)
+
+	daemon := new(rpc_client.Daemon)
+	daemon.SetClient(server.URL)
+
+	backend, err := StartProcessWalletBackend(file, "pass", daemon)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer backend.Close()
+
+	// the child connects to the daemon in the background
+	var amount uint64
+	deadline := time.Now().Add(WALLET_PROCESS_START_TIMEOUT)
+	for {
+		amount, err = backend.GetBalance(crypto.Hash{})
+		if err == nil && amount == 12345 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("expected balance 12345 got %d: %v", amount, err)
+		}
+
+		time.Sleep(200 * time.Millisecond)
+	}
+}
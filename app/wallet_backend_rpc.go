@@ -0,0 +1,129 @@
+package app
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/walletapi"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+type RPCWalletBackend struct {
+	Wallet *rpc_client.Wallet
+	Daemon *rpc_client.Daemon
+}
+
+func NewRPCWalletBackend(wallet *rpc_client.Wallet, daemon *rpc_client.Daemon) *RPCWalletBackend {
+	return &RPCWalletBackend{Wallet: wallet, Daemon: daemon}
+}
+
+func (b *RPCWalletBackend) GetAddress() (string, error) {
+	return b.Wallet.GetAddress()
+}
+
+func (b *RPCWalletBackend) GetSeed() (string, error) {
+	return b.Wallet.GetSeed()
+}
+
+func (b *RPCWalletBackend) GetBalance(scid crypto.Hash) (uint64, error) {
+	result, err := b.Wallet.GetBalance(&rpc.GetBalance_Params{
+		SCID: scid,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Balance, nil
+}
+
+func (b *RPCWalletBackend) GetHeight() (uint64, error) {
+	result, err := b.Wallet.GetHeight()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Height, nil
+}
+
+func (b *RPCWalletBackend) GetTransfers(params *rpc.Get_Transfers_Params) ([]rpc.Entry, error) {
+	result, err := b.Wallet.GetTransfers(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Entries, nil
+}
+
+func (b *RPCWalletBackend) IsRegistered() bool {
+	registered, _ := b.Wallet.GetRegistered()
+	return registered
+}
+
+// Register signs the registration tx with an in-memory copy of the wallet (from seed) and sends it to the daemon
+// the wallet rpc server does not expose registration
+func (b *RPCWalletBackend) Register() error {
+	seed, err := b.Wallet.GetSeed()
+	if err != nil {
+		return err
+	}
+
+	wallet, err := walletapi.Create_Encrypted_Wallet_From_Recovery_Words_Memory("", seed)
+	if err != nil {
+		return err
+	}
+
+	wallet.SetNetwork(globals.IsMainnet())
+
+	address, err := b.Wallet.GetAddress()
+	if err != nil {
+		return err
+	}
+
+	if wallet.GetAddress().String() != address {
+		return fmt.Errorf("seed does not match wallet address")
+	}
+
+	regTx := solveRegistrationTx(wallet)
+	fmt.Println("Sending transaction to blockchain...")
+	_, err = b.Daemon.SendRawTransaction(&rpc.SendRawTransaction_Params{
+		Tx_as_hex: hex.EncodeToString(regTx.Serialize()),
+	})
+
+	return err
+}
+
+func (b *RPCWalletBackend) Transfer(params *rpc.Transfer_Params) (string, error) {
+	result, err := b.Wallet.Transfer(params)
+	if err != nil {
+		return "", err
+	}
+
+	return result.TXID, nil
+}
+
+// HasPassword is true if the wallet rpc requires authentication
+func (b *RPCWalletBackend) HasPassword() bool {
+	return b.Wallet.Auth != ""
+}
+
+// CheckPassword compares with the password of the wallet rpc authentication
+func (b *RPCWalletBackend) CheckPassword(password string) bool {
+	auth, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(b.Wallet.Auth, "Basic "))
+	if err != nil {
+		return false
+	}
+
+	parts := strings.SplitN(string(auth), ":", 2)
+	return len(parts) == 2 && subtle.ConstantTimeCompare([]byte(parts[1]), []byte(password)) == 1
+}
+
+// SetDaemon does nothing - the wallet rpc server has its own daemon connection
+func (b *RPCWalletBackend) SetDaemon(address string) {}
+
+func (b *RPCWalletBackend) Close() {}
@@ -0,0 +1,124 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+var ErrWatchOnly = errors.New("watch-only wallet has no spend key - sending and signing are disabled")
+
+// WatchWalletBackend follows a public address without the spend key - DERO balances are encrypted on chain
+// so amounts and incoming transfers come from a view exported by the full wallet
+type WatchWalletBackend struct {
+	WalletId int64
+	Address  string
+	Daemon   *rpc_client.Daemon
+}
+
+func NewWatchWalletBackend(walletId int64, address string, daemon *rpc_client.Daemon) *WatchWalletBackend {
+	return &WatchWalletBackend{WalletId: walletId, Address: address, Daemon: daemon}
+}
+
+func (b *WatchWalletBackend) GetAddress() (string, error) {
+	return b.Address, nil
+}
+
+func (b *WatchWalletBackend) GetSeed() (string, error) {
+	return "", ErrWatchOnly
+}
+
+// GetBalance returns the balance of the view export if the encrypted balance did not change on chain since
+func (b *WatchWalletBackend) GetBalance(scid crypto.Hash) (uint64, error) {
+	view, err := Context.GetWalletView(b.WalletId, scid)
+	if err != nil {
+		return 0, err
+	}
+
+	if view == nil {
+		return 0, fmt.Errorf("balance of [%s] is encrypted on chain - import a view export of the wallet with watch import-view", assetLabel(scid))
+	}
+
+	result, err := b.Daemon.GetEncrypedBalance(&rpc.GetEncryptedBalance_Params{
+		Address:    b.Address,
+		SCID:       scid,
+		TopoHeight: -1,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	if result.Data != view.Data {
+		return 0, fmt.Errorf("encrypted balance of [%s] changed on chain since the view export at topoheight %d - last known balance is %s, import a new view export",
+			assetLabel(scid), view.Topoheight, formatPolicyAmount(scid, view.Balance))
+	}
+
+	return view.Balance, nil
+}
+
+func (b *WatchWalletBackend) GetHeight() (uint64, error) {
+	result, err := b.Daemon.GetHeight()
+	if err != nil {
+		return 0, err
+	}
+
+	return result.Height, nil
+}
+
+// GetTransfers returns the incoming transfers of the view export of the asset
+func (b *WatchWalletBackend) GetTransfers(params *rpc.Get_Transfers_Params) ([]rpc.Entry, error) {
+	view, err := Context.GetWalletView(b.WalletId, params.SCID)
+	if err != nil || view == nil {
+		return nil, err
+	}
+
+	var entries []rpc.Entry
+	for _, entry := range view.Entries {
+		if params.Min_Height > 0 && entry.Height < params.Min_Height {
+			continue
+		}
+
+		if params.Max_Height > 0 && entry.Height > params.Max_Height {
+			continue
+		}
+
+		if (entry.Coinbase && params.Coinbase) || (entry.Incoming && !entry.Coinbase && params.In) {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries, nil
+}
+
+func (b *WatchWalletBackend) IsRegistered() bool {
+	result, err := b.Daemon.GetEncrypedBalance(&rpc.GetEncryptedBalance_Params{
+		Address:    b.Address,
+		TopoHeight: -1,
+	})
+
+	return err == nil && result.Status == "OK"
+}
+
+func (b *WatchWalletBackend) Register() error {
+	return ErrWatchOnly
+}
+
+func (b *WatchWalletBackend) Transfer(params *rpc.Transfer_Params) (string, error) {
+	return "", ErrWatchOnly
+}
+
+func (b *WatchWalletBackend) HasPassword() bool {
+	return false
+}
+
+func (b *WatchWalletBackend) CheckPassword(password string) bool {
+	return false
+}
+
+// SetDaemon does nothing - the backend uses the daemon of the wallet instance
+func (b *WatchWalletBackend) SetDaemon(address string) {}
+
+func (b *WatchWalletBackend) Close() {}
@@ -6,26 +6,47 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
-	"time"
 
 	"github.com/deroproject/derohe/cryptography/crypto"
 	"github.com/deroproject/derohe/globals"
 	"github.com/deroproject/derohe/rpc"
-	"github.com/deroproject/derohe/transaction"
 	"github.com/deroproject/derohe/walletapi"
 	"github.com/g45t345rt/derosphere/rpc_client"
 )
 
+var ErrWalletClosed = errors.New("wallet is not opened")
+
+// TxNotSentError is a transfer error returned before the tx was handed to the network - the tx can't be on chain
+type TxNotSentError struct {
+	Err error
+}
+
+func (e *TxNotSentError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TxNotSentError) Unwrap() error {
+	return e.Err
+}
+
+// IsTxNotSent returns false if the tx of a failed transfer may still have been broadcasted
+func IsTxNotSent(err error) bool {
+	var notSent *TxNotSentError
+	return errors.As(err, &notSent)
+}
+
 type WalletInstance struct {
 	Id            int64
 	Name          string
 	DaemonAddress string
 	WalletAddress string
 	WalletPath    string
+	WatchAddress  string // public address of a watch-only wallet - no spend key
 	Daemon        *rpc_client.Daemon
-	WalletRPC     *rpc_client.Wallet
-	WalletDisk    *walletapi.Wallet_Disk
+	Backend       WalletBackend // nil until the wallet is opened
+	syncWorker    *SyncWorker
+	txTracker     *TxTracker
+	scheduler     *Scheduler
 }
 
 func (w *WalletInstance) SetupDaemon() error {
@@ -40,22 +61,57 @@ func (w *WalletInstance) SetupDaemon() error {
 	return nil
 }
 
+// Open connects the wallet and starts the background sync, tx tracker and scheduler
 func (w *WalletInstance) Open() error {
-	fmt.Println("Connecting to daemon rpc...")
-	err := w.SetupDaemon()
+	err := w.Connect()
 	if err != nil {
 		return err
 	}
 
-	fmt.Println("Daemon rpc connection was successful.")
+	// no background sync in batch mode - commands sync what they need before exiting
+	if Context.Batch == nil && Context.SyncEngines != nil {
+		w.syncWorker = NewSyncWorker(w.Daemon, Context.SyncEngines())
+		w.syncWorker.Start()
+	}
+
+	// batch mode checks pending txs when they are listed or waited for
+	w.txTracker = NewTxTracker(w.Id, w.Daemon)
+	if Context.Batch == nil {
+		w.txTracker.Start()
+	}
+
+	// batch mode runs the due scheduled transfers with schedule run or serve
+	w.scheduler = NewScheduler(w)
+	if Context.Batch == nil {
+		w.scheduler.Start(false)
+	}
+
+	return nil
+}
 
-	if w.WalletAddress != "" {
+// Connect opens the daemon and wallet connections only - used to read a wallet that is not the one in use
+func (w *WalletInstance) Connect() error {
+	fmt.Fprintln(os.Stderr, "Connecting to daemon rpc...")
+	w.Daemon = new(rpc_client.Daemon)
+	w.Daemon.SetClient(w.DaemonAddress)
+	err := w.setupDaemonPool()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Daemon rpc connection was successful.")
+
+	if w.WatchAddress != "" {
+		w.Backend = NewWatchWalletBackend(w.Id, w.WatchAddress, w.Daemon)
+	} else if w.WalletAddress != "" {
 		walletRPC := new(rpc_client.Wallet)
 		walletRPC.SetClient(w.WalletAddress)
 
 		count := 0
+		fromVault := false
+		var username, password string
 	checkAuth:
-		fmt.Println("Connecting to wallet rpc...")
+		fmt.Fprintln(os.Stderr, "Connecting to wallet rpc...")
 		needAuth, err := walletRPC.NeedAuth()
 		if err != nil {
 			return err
@@ -63,17 +119,34 @@ func (w *WalletInstance) Open() error {
 
 		if needAuth {
 			if count == 0 {
-				fmt.Println("Wallet rpc requires authentication...")
+				fmt.Fprintln(os.Stderr, "Wallet rpc requires authentication...")
+
+				var ok bool
+				username, password, ok, err = Context.GetCredential(w.Id)
+				if err != nil {
+					fmt.Fprintln(os.Stderr, err)
+				} else if ok {
+					fromVault = true
+					walletRPC.SetClientWithAuth(w.WalletAddress, username, password)
+					count++
+					goto checkAuth
+				}
+			} else if fromVault {
+				fmt.Fprintln(os.Stderr, "Credentials stored in the vault are invalid...")
+				fromVault = false
+			} else if Context.Batch != nil {
+				// a batch answers the prompts with the same credentials every time
+				return errors.New("invalid username or password")
 			} else {
-				fmt.Println("Invalid username or password. Retry...")
+				fmt.Fprintln(os.Stderr, "Invalid username or password. Retry...")
 			}
 
-			username, err := Prompt("Enter username", "")
+			username, err = Prompt("Enter username", "")
 			if err != nil {
 				return err
 			}
 
-			password, err := PromptPassword("Enter password")
+			password, err = PromptPassword("Enter password")
 			if err != nil {
 				return err
 			}
@@ -83,7 +156,15 @@ func (w *WalletInstance) Open() error {
 			goto checkAuth
 		}
 
-		w.WalletRPC = walletRPC
+		// offer to remember credentials typed by the user if a vault exists
+		if count > 0 && !fromVault {
+			err = w.offerSaveCredential(username, password)
+			if err != nil {
+				return err
+			}
+		}
+
+		w.Backend = NewRPCWalletBackend(walletRPC, w.Daemon)
 	} else if w.WalletPath != "" {
 		/*wd, err := os.Getwd()
 		if err != nil {
@@ -92,71 +173,132 @@ func (w *WalletInstance) Open() error {
 
 		//path := filepath.ToSlash(fmt.Sprintf("%s/%s", wd, w.WalletPath))
 		path := filepath.ToSlash(w.WalletPath)
-		fmt.Println(path)
 		_, err = os.Stat(path)
 
 		if err != nil {
 			return err
 		}
 
+		// a batch answers the prompt with the same password every time - the error is returned instead of retrying
 	retryPass:
 		password, err := PromptPassword("Enter wallet password")
 		if err != nil {
 			return err
 		}
 
+		// walletapi connects every wallet file of the process to the same daemon - a wallet file using another daemon gets its own process
+		if other := Context.diskSession(w); other != nil && other.Daemon.Address != w.Daemon.Address {
+			backend, err := StartProcessWalletBackend(w.WalletPath, password, w.Daemon)
+			if err != nil {
+				if err.Error() == "Invalid Password" && Context.Batch == nil {
+					fmt.Fprintln(os.Stderr, "Invalid password")
+					goto retryPass
+				}
+
+				return err
+			}
+
+			w.Backend = backend
+			return nil
+		}
+
 		wallet, err := walletapi.Open_Encrypted_Wallet(w.WalletPath, password)
 		if err != nil {
-			if err.Error() == "Invalid Password" {
-				fmt.Println("Invalid password")
+			if err.Error() == "Invalid Password" && Context.Batch == nil {
+				fmt.Fprintln(os.Stderr, "Invalid password")
 				goto retryPass
 			}
 
 			return err
 		}
 
-		w.WalletDisk = wallet
+		w.Backend = NewDiskWalletBackend(wallet)
 
-		httpKey, err := regexp.Compile("https?://")
-		if err != nil {
-			return err
-		}
+		setWalletapiDaemon(w.Daemon.Address)
+		wallet.SetNetwork(globals.IsMainnet())
+		wallet.SetOnlineMode()
+		keepConnectivity.Do(func() { go walletapi.Keep_Connectivity() })
+	}
+
+	return nil
+}
 
-		globals.Arguments["--daemon-address"] = httpKey.ReplaceAllString(w.DaemonAddress, "")
-		w.WalletDisk.SetNetwork(globals.IsMainnet())
-		w.WalletDisk.SetOnlineMode()
-		go walletapi.Keep_Connectivity()
+func (w *WalletInstance) offerSaveCredential(username string, password string) error {
+	exists, err := Context.VaultExists()
+	if err != nil || !exists {
+		return err
+	}
+
+	yes, err := PromptYesNo("Save wallet rpc credentials in the vault?", true)
+	if err != nil || !yes {
+		return err
 	}
 
+	err = Context.SaveCredential(w.Id, username, password)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(os.Stderr, "Credentials saved in the vault.")
 	return nil
 }
 
+func (w *WalletInstance) SyncWorker() *SyncWorker {
+	return w.syncWorker
+}
+
+func (w *WalletInstance) TxTracker() *TxTracker {
+	return w.txTracker
+}
+
+func (w *WalletInstance) Scheduler() *Scheduler {
+	return w.scheduler
+}
+
 func (w *WalletInstance) Close() {
+	if w.syncWorker != nil {
+		w.syncWorker.Stop()
+		w.syncWorker = nil
+	}
+
+	if w.scheduler != nil {
+		w.scheduler.Stop()
+		w.scheduler = nil
+	}
+
+	if w.txTracker != nil {
+		w.txTracker.Stop()
+		w.txTracker = nil
+	}
+
+	if w.Daemon != nil {
+		w.Daemon.StopHealthCheck()
+	}
+
 	w.Daemon = nil
-	w.WalletRPC = nil
 
-	if w.WalletDisk != nil {
-		w.WalletDisk.Close_Encrypted_Wallet()
-		w.WalletDisk = nil
+	if w.Backend != nil {
+		w.Backend.Close()
+		w.Backend = nil
 	}
 }
 
 func (w *WalletInstance) Save() error {
 	sql := `
-		update app_wallets set name = ?, daemon_rpc = ?, wallet_rpc = ?, wallet_path = ? where id == ?
+		update app_wallets set name = ?, daemon_rpc = ?, wallet_rpc = ?, wallet_path = ?, watch_address = ? where id == ?
 	`
 
-	_, err := Context.DB.Exec(sql, w.Name, w.DaemonAddress, w.WalletAddress, w.WalletPath, w.Id)
+	_, err := Context.DB.Exec(sql, w.Name, w.DaemonAddress, w.WalletAddress, storedWalletPath(w.WalletPath), w.WatchAddress, w.Id)
 	return err
 }
 
 func (w *WalletInstance) Add() error {
 	sql := `
-		insert into app_wallets(name, daemon_rpc, wallet_rpc, wallet_path)
-		values (?,?,?,?)
+		insert into app_wallets(name, daemon_rpc, wallet_rpc, wallet_path, watch_address)
+		values (?,?,?,?,?)
 	`
 
-	res, err := Context.DB.Exec(sql, w.Name, w.DaemonAddress, w.WalletAddress, w.WalletPath)
+	res, err := Context.DB.Exec(sql, w.Name, w.DaemonAddress, w.WalletAddress, storedWalletPath(w.WalletPath), w.WatchAddress)
 	if err != nil {
 		return err
 	}
@@ -172,11 +314,19 @@ func (w *WalletInstance) Add() error {
 }
 
 func (w *WalletInstance) Del(listIndex int) error {
+	Context.CloseSession(w)
+
 	sql := `
-		delete from app_wallets where id == ?
+		delete from app_wallets where id == ?;
+		delete from app_nodes where wallet_id == ?;
+		delete from app_credentials where wallet_id == ?;
+		delete from app_wallet_views where wallet_id == ?;
+		delete from app_watch_assets where wallet_id == ?;
+		delete from app_portfolio_balances where wallet_id == ?;
+		delete from app_wallet_assets where wallet_id == ?;
 	`
 
-	_, err := Context.DB.Exec(sql, w.Id)
+	_, err := Context.DB.Exec(sql, w.Id, w.Id, w.Id, w.Id, w.Id, w.Id, w.Id)
 	if err != nil {
 		return err
 	}
@@ -186,7 +336,9 @@ func (w *WalletInstance) Del(listIndex int) error {
 }
 
 func (w *WalletInstance) GetConnectionAddress() string {
-	if w.WalletAddress != "" {
+	if w.WatchAddress != "" {
+		return fmt.Sprintf("[watch]%s", w.WatchAddress)
+	} else if w.WalletAddress != "" {
 		return fmt.Sprintf("[rpc]%s", w.WalletAddress)
 	} else if w.WalletPath != "" {
 		return fmt.Sprintf("[file]%s", w.WalletPath)
@@ -194,163 +346,121 @@ func (w *WalletInstance) GetConnectionAddress() string {
 	return ""
 }
 
+func (w *WalletInstance) IsWatchOnly() bool {
+	return w.WatchAddress != ""
+}
+
+// CheckSpend returns ErrWatchOnly if the wallet can't sign txs
+func (w *WalletInstance) CheckSpend() error {
+	if w.IsWatchOnly() {
+		return ErrWatchOnly
+	}
+
+	return nil
+}
+
 func (w *WalletInstance) IsRegistered() bool {
-	registered := false
-	if w.WalletRPC != nil {
-		registered, _ = w.WalletRPC.GetRegistered()
-	} else if w.WalletDisk != nil {
-		registered = w.WalletDisk.IsRegistered()
+	if w.Backend == nil {
+		return false
 	}
 
-	return registered
+	return w.Backend.IsRegistered()
+}
+
+func (w *WalletInstance) Register() error {
+	if w.Backend == nil {
+		return ErrWalletClosed
+	}
+
+	return w.Backend.Register()
 }
 
 func (w *WalletInstance) GetAddress() (string, error) {
-	if w.WalletRPC != nil {
-		result, err := w.WalletRPC.GetAddress()
-		if err != nil {
-			return "", err
-		}
-		return result, nil
-	} else if w.WalletDisk != nil {
-		return w.WalletDisk.GetAddress().String(), nil
+	if w.Backend == nil {
+		return "", ErrWalletClosed
 	}
 
-	return "", nil
+	return w.Backend.GetAddress()
 }
 
 func (w *WalletInstance) GetSeed() (string, error) {
-	if w.WalletRPC != nil {
-		seed, err := w.WalletRPC.GetSeed()
-		if err != nil {
-			return "", err
-		}
-		return seed, nil
-	} else if w.WalletDisk != nil {
-		return w.WalletDisk.GetSeed(), nil
+	if w.Backend == nil {
+		return "", ErrWalletClosed
 	}
 
-	return "", nil
+	return w.Backend.GetSeed()
 }
 
 func (w *WalletInstance) GetBalance(scid crypto.Hash) (uint64, error) {
-	if w.WalletRPC != nil {
-		result, err := w.WalletRPC.GetBalance(&rpc.GetBalance_Params{
-			SCID: scid,
-		})
-		if err != nil {
-			return 0, err
-		}
-
-		return result.Balance, nil
-	} else if w.WalletDisk != nil {
-		err := w.WalletDisk.Sync_Wallet_Memory_With_Daemon_internal(scid)
-		if err != nil {
-			return 0, err
-		}
-
-		m_balance, _ := w.WalletDisk.Get_Balance_scid(scid)
-		return m_balance, nil
+	if w.Backend == nil {
+		return 0, ErrWalletClosed
 	}
 
-	return 0, nil
+	return w.Backend.GetBalance(scid)
 }
 
 func (w *WalletInstance) GetHeight() (uint64, error) {
-	if w.WalletRPC != nil {
-		result, err := w.WalletRPC.GetHeight()
-		if err != nil {
-			return 0, err
-		}
-
-		return result.Height, nil
-	} else if w.WalletDisk != nil {
-		return w.WalletDisk.Get_Height(), nil
+	if w.Backend == nil {
+		return 0, ErrWalletClosed
 	}
 
-	return 0, nil
+	return w.Backend.GetHeight()
 }
 
 func (w *WalletInstance) GetTransfers(params *rpc.Get_Transfers_Params) ([]rpc.Entry, error) {
-	if w.WalletRPC != nil {
-		result, err := w.WalletRPC.GetTransfers(params)
-		if err != nil {
-			return nil, err
-		}
-
-		return result.Entries, nil
-	} else if w.WalletDisk != nil {
-		entries := w.WalletDisk.Show_Transfers(
-			params.SCID,
-			params.Coinbase,
-			params.In,
-			params.Out,
-			params.Min_Height,
-			params.Max_Height,
-			params.Sender,
-			params.Receiver,
-			params.DestinationPort,
-			0,
-		)
-
-		return entries, nil
+	if w.Backend == nil {
+		return nil, ErrWalletClosed
 	}
 
-	return nil, nil
+	return w.Backend.GetTransfers(params)
 }
 
+// Transfer sends the tx if it passes the wallet policy - every command and dapp sends through it
 func (w *WalletInstance) Transfer(p *rpc.Transfer_Params) (string, error) {
-	if w.WalletRPC != nil {
-		result, err := w.WalletRPC.Transfer(p)
-		if err != nil {
-			return "", err
-		}
+	return w.transfer(p, true, Context.commandOrigin())
+}
 
-		return result.TXID, nil
-	} else if w.WalletDisk != nil {
-		for _, t := range p.Transfers {
-			_, err := t.Payload_RPC.CheckPack(transaction.PAYLOAD0_LIMIT)
-			if err != nil {
-				return "", err
-			}
-		}
+func (w *WalletInstance) transfer(p *rpc.Transfer_Params, promptPassword bool, origin txOrigin) (string, error) {
+	if w.Backend == nil {
+		return "", &TxNotSentError{ErrWalletClosed}
+	}
 
-		if len(p.SC_Code) >= 1 {
-			if sc, err := base64.StdEncoding.DecodeString(p.SC_Code); err == nil {
-				p.SC_Code = string(sc)
-			}
-		}
+	err := w.CheckSpend()
+	if err != nil {
+		return "", &TxNotSentError{err}
+	}
 
-		if p.SC_Code != "" && p.SC_ID == "" {
-			p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCACTION, DataType: rpc.DataUint64, Value: uint64(rpc.SC_INSTALL)})
-			p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCCODE, DataType: rpc.DataString, Value: p.SC_Code})
-		}
+	err = w.CheckPolicy(p, promptPassword)
+	if err != nil {
+		return "", &TxNotSentError{err}
+	}
 
-		if p.SC_ID != "" {
-			p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCACTION, DataType: rpc.DataUint64, Value: uint64(rpc.SC_CALL)})
-			p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCID, DataType: rpc.DataHash, Value: crypto.HashHexToHash(p.SC_ID)})
-			if p.SC_Code != "" {
-				p.SC_RPC = append(p.SC_RPC, rpc.Argument{Name: rpc.SCCODE, DataType: rpc.DataString, Value: p.SC_Code})
-			}
+	txid, err := w.Backend.Transfer(p)
+	if err != nil {
+		// the tx may be on chain - its amounts count in the daily cap
+		if !IsTxNotSent(err) {
+			w.recordUncertainSpend(p, err)
 		}
 
-		tx, err := w.WalletDisk.TransferPayload0(p.Transfers, p.Ringsize, false, p.SC_RPC, p.Fees, false)
-		if err != nil {
-			return "", err
-		}
+		return "", err
+	}
 
-		err = w.WalletDisk.SendTransaction(tx)
+	w.recordTx(txid, p, origin)
+	if w.txTracker != nil {
+		err = w.txTracker.Track(txid)
 		if err != nil {
-			return "", err
+			fmt.Fprintf(os.Stderr, "Could not track tx [%s]: %s\n", txid, err)
 		}
-
-		return tx.GetHash().String(), nil
 	}
 
-	return "", nil
+	return txid, nil
 }
 
 func (w *WalletInstance) EstimateFeesAndTransfer(transfer *rpc.Transfer_Params) (string, error) {
+	err := w.CheckSpend()
+	if err != nil {
+		return "", err
+	}
 
 	params := rpc.GasEstimate_Params{
 		Ringsize:  transfer.Ringsize,
@@ -374,7 +484,7 @@ func (w *WalletInstance) EstimateFeesAndTransfer(transfer *rpc.Transfer_Params)
 
 	transfer.Fees = estimate.GasStorage
 	yes, err := PromptYesNo(fmt.Sprintf("TX fees are %s. Do you want to send the transaction?", rpc.FormatMoney(transfer.Fees)), false)
-	if HandlePromptErr(err) {
+	if err != nil {
 		return "", err
 	}
 
@@ -392,6 +502,11 @@ func (w *WalletInstance) EstimateFeesAndTransfer(transfer *rpc.Transfer_Params)
 }
 
 func (walletInstance *WalletInstance) InstallSmartContract(code []byte, ringsize uint64, args []rpc.Argument, promptFees bool) (string, error) {
+	err := walletInstance.CheckSpend()
+	if err != nil {
+		return "", err
+	}
+
 	codeBase64 := base64.StdEncoding.EncodeToString(code)
 	signer, err := walletInstance.GetAddress()
 	if err != nil {
@@ -441,17 +556,25 @@ func (walletInstance *WalletInstance) InstallSmartContract(code []byte, ringsize
 	return txid, nil
 }
 
-func (walletInstance *WalletInstance) CallSmartContract(ringsize uint64, scid string, entrypoint string, args []rpc.Argument, transfers []rpc.Transfer, promptFees bool) (string, error) {
+func SCCallArgs(scid string, entrypoint string, args []rpc.Argument) rpc.Arguments {
 	sc_rpc := rpc.Arguments{
 		{Name: rpc.SCACTION, DataType: rpc.DataUint64, Value: uint64(rpc.SC_CALL)},  // 'SC_ACTION' value should be of type uint64
 		{Name: rpc.SCID, DataType: rpc.DataHash, Value: crypto.HashHexToHash(scid)}, // 'SC_ID' value should be of type Hash
 		{Name: "entrypoint", DataType: rpc.DataString, Value: entrypoint},
 	}
 
-	sc_rpc = append(sc_rpc, args[:]...)
+	return append(sc_rpc, args[:]...)
+}
+
+func (walletInstance *WalletInstance) CallSmartContract(ringsize uint64, scid string, entrypoint string, args []rpc.Argument, transfers []rpc.Transfer, promptFees bool) (string, error) {
+	err := walletInstance.CheckSpend()
+	if err != nil {
+		return "", err
+	}
+
+	sc_rpc := SCCallArgs(scid, entrypoint, args)
 
 	signer := ""
-	var err error
 	if ringsize == 2 {
 		signer, err = walletInstance.GetAddress()
 		if err != nil {
@@ -497,99 +620,31 @@ func (walletInstance *WalletInstance) CallSmartContract(ringsize uint64, scid st
 	return txid, nil
 }
 
-func (walletInstance *WalletInstance) RunTxChecker(txid string) {
-	tries := 25
-	waitInterval := 2 * time.Second
-	var i int
-
-	fmt.Printf("Checking transaction... TXID: %s\n", txid)
-	// TODO fmt.Println("Type anything to skip")
-	for i = 0; i < tries; i++ {
-		result, err := walletInstance.Daemon.GetTransaction(&rpc.GetTransaction_Params{
-			Tx_Hashes: []string{txid},
-		})
+// TxSent asks for a note of the tx - the confirmation is followed in the background by the tx tracker
+func (walletInstance *WalletInstance) TxSent(txid string) {
+	// batch runs add notes with tx-note
+	if Context.Batch == nil {
+		note, err := Prompt("Transaction note (empty to skip)", "")
+		if err == nil && note != "" {
+			err = Context.SetTxNote(walletInstance.Id, txid, note)
+		}
 
 		if err != nil {
 			fmt.Println(err)
-			break
 		}
-
-		txInfo := result.Txs[0]
-		if !txInfo.In_pool && txInfo.ValidBlock == "" {
-			fmt.Println("Invalid transaction")
-			break
-		}
-
-		txBlockHeight := txInfo.Block_Height
-
-		if txBlockHeight != -1 {
-			fmt.Printf("Successful transaction at block %d\n", txBlockHeight)
-			break
-		}
-
-		time.Sleep(waitInterval)
 	}
 
-	if i == tries {
-		fmt.Println("Can't confirm transaction. Number of tries exceeded.")
-	}
+	fmt.Printf("Transaction sent. TXID: %s\n", txid)
+	fmt.Println("Confirmation is tracked in the background - use tx pending to see it.")
 }
 
+// WaitTransaction blocks until the tx is confirmed - only for batches of txs sent one after the other by dapp commands.
+// Other commands return after the broadcast and the tx tracker confirms the tx in the background.
 func (walletInstance *WalletInstance) WaitTransaction(txid string) error {
-	fmt.Printf("Waiting for transaction... %s\n", txid)
-
-	startHeight := uint64(0)
-	tries := 25
-	waitInterval := 2 * time.Second
-	var i int
-	var err error
-
-	for i = 0; i < tries; i++ {
-		time.Sleep(waitInterval)
-
-		var result *rpc.GetTransaction_Result
-		result, err = walletInstance.Daemon.GetTransaction(&rpc.GetTransaction_Params{
-			Tx_Hashes: []string{txid},
-		})
-
-		if err != nil {
-			continue
-		}
-
-		txInfo := result.Txs[0]
-		if !txInfo.In_pool && txInfo.ValidBlock == "" {
-			err = errors.New("invalid transaction")
-			break
-		}
-
-		txBlockHeight := txInfo.Block_Height
-
-		var currentHeight uint64
-		currentHeight, err = walletInstance.GetHeight()
-		if err != nil {
-			continue
-		}
-
-		if startHeight == 0 {
-			startHeight = currentHeight
-		}
-
-		//fmt.Printf("%d %d %d\n", currentHeight, txBlockHeight, startHeight)
-
-		if txBlockHeight != -1 {
-			err = nil
-			break
-		}
-
-		if currentHeight >= startHeight+2 {
-			err = errors.New("stuck transaction")
-			break
-		}
-	}
-
-	if i == tries {
-		err = errors.New(`maximum tries exceeded`)
+	if walletInstance.txTracker == nil {
+		return ErrWalletClosed
 	}
 
-	return err
+	fmt.Printf("Waiting for transaction... %s\n", txid)
+	return walletInstance.txTracker.Wait(txid)
 }
@@ -0,0 +1,40 @@
+package app
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestConnectBatchInvalidPassword(t *testing.T) {
+	newTestContext(t)
+	setEnvGlobals(Context.Config.Env)
+	file, _ := newTestWalletFile(t, "pass")
+
+	server := httptest.NewServer(&fakeWalletDaemon{})
+	t.Cleanup(server.Close)
+
+	// the keyed answer comes back on every prompt
+	Context.Batch = NewBatch()
+	err := Context.Batch.SetAnswer("Enter wallet password=wrong")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w := &WalletInstance{Id: 1, Name: "wallet1", DaemonAddress: server.URL, WalletPath: file}
+	done := make(chan error, 1)
+	go func() {
+		done <- w.Connect()
+	}()
+
+	select {
+	case err = <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("connect is prompting the password again")
+	}
+
+	w.Daemon.StopHealthCheck()
+	if err == nil || err.Error() != "Invalid Password" {
+		t.Fatalf("expected invalid password got %v", err)
+	}
+}
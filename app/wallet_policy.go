@@ -0,0 +1,442 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/secure"
+)
+
+// Kinds of allowlist entries - a wallet with entries of a kind only sends to them
+const (
+	ALLOW_DESTINATION = "destination"
+	ALLOW_SCID        = "scid"
+)
+
+var POLICY_DAY = int64(24 * 60 * 60)
+
+var ErrPolicyPasswordRequired = errors.New("password reentry is required by the wallet policy")
+
+// WalletPolicy limits the amounts of an asset sent by a wallet - 0 is no limit
+type WalletPolicy struct {
+	WalletId     int64
+	SCID         crypto.Hash
+	MaxPerTx     uint64
+	MaxPerDay    uint64 // last 24 hours of txs recorded in the tx history
+	ReentryAbove uint64 // password reentry required for a tx sending more
+}
+
+type AllowlistEntry struct {
+	WalletId int64
+	Kind     string
+	Value    string
+	Note     string
+}
+
+func initWalletPolicyTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_wallet_policies (
+			wallet_id integer,
+			scid varchar,
+			max_per_tx bigint,
+			max_per_day bigint,
+			reentry_above bigint,
+			primary key (wallet_id, scid)
+		);
+
+		create table if not exists app_wallet_allowlist (
+			wallet_id integer,
+			kind varchar,
+			value varchar,
+			note varchar,
+			primary key (wallet_id, kind, value)
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+// initUncertainSpendTables keeps the transfers of txs that failed after they were handed to the wallet - they may be on chain
+// so they count in the daily cap like the txs of the tx history
+func initUncertainSpendTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_uncertain_spends (
+			id integer primary key,
+			wallet_id integer,
+			transfers varchar,
+			err varchar,
+			timestamp bigint
+		);
+
+		create index if not exists app_uncertain_spends_wallet on app_uncertain_spends (wallet_id, timestamp);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+// addAmount stops at the max amount instead of wrapping around - a limit is never passed by overflowing it
+func addAmount(a uint64, b uint64) uint64 {
+	if a > math.MaxUint64-b {
+		return math.MaxUint64
+	}
+
+	return a + b
+}
+
+func (app *AppContext) GetWalletPolicies(walletId int64) ([]*WalletPolicy, error) {
+	query := `
+		select wallet_id, scid, max_per_tx, max_per_day, reentry_above
+		from app_wallet_policies
+		where wallet_id = ?
+		order by scid
+	`
+
+	rows, err := app.DB.Query(query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []*WalletPolicy
+	for rows.Next() {
+		p := new(WalletPolicy)
+		var scid string
+		err = rows.Scan(&p.WalletId, &scid, &p.MaxPerTx, &p.MaxPerDay, &p.ReentryAbove)
+		if err != nil {
+			return nil, err
+		}
+
+		p.SCID = crypto.HashHexToHash(scid)
+		policies = append(policies, p)
+	}
+
+	return policies, rows.Err()
+}
+
+// GetWalletPolicy returns a policy without limits if the asset has none
+func (app *AppContext) GetWalletPolicy(walletId int64, scid crypto.Hash) (*WalletPolicy, error) {
+	policies, err := app.GetWalletPolicies(walletId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range policies {
+		if p.SCID == scid {
+			return p, nil
+		}
+	}
+
+	return &WalletPolicy{WalletId: walletId, SCID: scid}, nil
+}
+
+func (app *AppContext) SetWalletPolicy(p *WalletPolicy) error {
+	query := `
+		insert or replace into app_wallet_policies (wallet_id, scid, max_per_tx, max_per_day, reentry_above)
+		values (?, ?, ?, ?, ?)
+	`
+
+	_, err := app.DB.Exec(query, p.WalletId, p.SCID.String(), p.MaxPerTx, p.MaxPerDay, p.ReentryAbove)
+	return err
+}
+
+func (app *AppContext) DelWalletPolicy(walletId int64, scid crypto.Hash) error {
+	res, err := app.DB.Exec(`delete from app_wallet_policies where wallet_id = ? and scid = ?`, walletId, scid.String())
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("no policy for asset [%s]", scid)
+	}
+
+	return nil
+}
+
+func (app *AppContext) GetAllowlist(walletId int64) ([]*AllowlistEntry, error) {
+	query := `
+		select wallet_id, kind, value, note
+		from app_wallet_allowlist
+		where wallet_id = ?
+		order by kind, value
+	`
+
+	rows, err := app.DB.Query(query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []*AllowlistEntry
+	for rows.Next() {
+		e := new(AllowlistEntry)
+		err = rows.Scan(&e.WalletId, &e.Kind, &e.Value, &e.Note)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (app *AppContext) AddAllowlistEntry(e *AllowlistEntry) error {
+	query := `
+		insert or replace into app_wallet_allowlist (wallet_id, kind, value, note)
+		values (?, ?, ?, ?)
+	`
+
+	_, err := app.DB.Exec(query, e.WalletId, e.Kind, e.Value, e.Note)
+	return err
+}
+
+func (app *AppContext) DelAllowlistEntry(walletId int64, value string) error {
+	res, err := app.DB.Exec(`delete from app_wallet_allowlist where wallet_id = ? and value = ?`, walletId, value)
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("[%s] is not in the allowlist", value)
+	}
+
+	return nil
+}
+
+// recordUncertainSpend saves the transfers of a tx that may have been sent - errors are only printed like recordTx
+func (w *WalletInstance) recordUncertainSpend(p *rpc.Transfer_Params, txErr error) {
+	if len(p.Transfers) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(p.Transfers)
+	if err == nil {
+		query := `insert into app_uncertain_spends (wallet_id, transfers, err, timestamp) values (?, ?, ?, ?)`
+		_, err = Context.DB.Exec(query, w.Id, string(data), txErr.Error(), time.Now().Unix())
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not record the transfers of the failed tx: %s\n", err)
+	}
+}
+
+// WalletSpentSince sums by asset the amounts of the txs recorded in the tx history and of the txs that may have been sent since this time
+func (app *AppContext) WalletSpentSince(walletId int64, since int64) (map[crypto.Hash]uint64, error) {
+	query := `
+		select transfers
+		from app_tx_history
+		where wallet_id = ? and timestamp >= ? and transfers != ''
+		union all
+		select transfers
+		from app_uncertain_spends
+		where wallet_id = ? and timestamp >= ?
+	`
+
+	rows, err := app.DB.Query(query, walletId, since, walletId, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	spent := make(map[crypto.Hash]uint64)
+	for rows.Next() {
+		var data string
+		err = rows.Scan(&data)
+		if err != nil {
+			return nil, err
+		}
+
+		var transfers []rpc.Transfer
+		err = json.Unmarshal([]byte(data), &transfers)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range transfers {
+			spent[t.SCID] = addAmount(spent[t.SCID], addAmount(t.Amount, t.Burn))
+		}
+	}
+
+	return spent, rows.Err()
+}
+
+func formatPolicyAmount(scid crypto.Hash, amount uint64) string {
+	if scid.IsZero() {
+		return fmt.Sprintf("%s DERO", globals.FormatMoney(amount))
+	}
+
+	return fmt.Sprintf("%d of asset %s", amount, scid)
+}
+
+func baseAddress(address string) string {
+	addr, err := rpc.NewAddress(address)
+	if err != nil {
+		return address
+	}
+
+	return addr.BaseAddress().String()
+}
+
+// txSCIDs returns the assets transferred and the smart contract called by the tx
+func txSCIDs(p *rpc.Transfer_Params) []string {
+	var scids []string
+	for _, t := range p.Transfers {
+		if !t.SCID.IsZero() {
+			scids = append(scids, t.SCID.String())
+		}
+	}
+
+	if p.SC_ID != "" {
+		scids = append(scids, p.SC_ID)
+	}
+
+	if p.SC_RPC.Has(rpc.SCID, rpc.DataHash) {
+		if scid, ok := p.SC_RPC.Value(rpc.SCID, rpc.DataHash).(crypto.Hash); ok {
+			scids = append(scids, scid.String())
+		}
+	}
+
+	return scids
+}
+
+// checkAllowlist returns an error if a destination or scid of the tx is not allowed
+func (w *WalletInstance) checkAllowlist(p *rpc.Transfer_Params) error {
+	entries, err := Context.GetAllowlist(w.Id)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	allowed := map[string]map[string]bool{
+		ALLOW_DESTINATION: {},
+		ALLOW_SCID:        {},
+	}
+
+	for _, e := range entries {
+		allowed[e.Kind][e.Value] = true
+	}
+
+	if len(allowed[ALLOW_DESTINATION]) > 0 {
+		for _, t := range p.Transfers {
+			// burns and zero transfers to ring members don't send anything to the destination
+			if t.Amount > 0 && !allowed[ALLOW_DESTINATION][baseAddress(t.Destination)] {
+				return fmt.Errorf("destination [%s] is not in the wallet allowlist", t.Destination)
+			}
+		}
+	}
+
+	if len(allowed[ALLOW_SCID]) > 0 {
+		for _, scid := range txSCIDs(p) {
+			if !allowed[ALLOW_SCID][scid] {
+				return fmt.Errorf("scid [%s] is not in the wallet allowlist", scid)
+			}
+		}
+	}
+
+	return nil
+}
+
+// CheckPolicy enforces the allowlist and the amount limits of the wallet - prompts the password if an amount is above the reentry threshold.
+// The password is never prompted if promptPassword is false and the tx is refused instead.
+func (w *WalletInstance) CheckPolicy(p *rpc.Transfer_Params, promptPassword bool) error {
+	err := w.checkAllowlist(p)
+	if err != nil {
+		return err
+	}
+
+	policies, err := Context.GetWalletPolicies(w.Id)
+	if err != nil || len(policies) == 0 {
+		return err
+	}
+
+	amounts := make(map[crypto.Hash]uint64)
+	for _, t := range p.Transfers {
+		amounts[t.SCID] = addAmount(amounts[t.SCID], addAmount(t.Amount, t.Burn))
+	}
+
+	spent, err := Context.WalletSpentSince(w.Id, time.Now().Unix()-POLICY_DAY)
+	if err != nil {
+		return err
+	}
+
+	reentry := false
+	for _, policy := range policies {
+		amount := amounts[policy.SCID]
+		if amount == 0 {
+			continue
+		}
+
+		if policy.MaxPerTx > 0 && amount > policy.MaxPerTx {
+			return fmt.Errorf("tx sends %s - above the wallet limit of %s per tx", formatPolicyAmount(policy.SCID, amount), formatPolicyAmount(policy.SCID, policy.MaxPerTx))
+		}
+
+		if policy.MaxPerDay > 0 && addAmount(spent[policy.SCID], amount) > policy.MaxPerDay {
+			return fmt.Errorf("tx sends %s with %s already sent in the last 24 hours - above the wallet limit of %s per day",
+				formatPolicyAmount(policy.SCID, amount), formatPolicyAmount(policy.SCID, spent[policy.SCID]), formatPolicyAmount(policy.SCID, policy.MaxPerDay))
+		}
+
+		if policy.ReentryAbove > 0 && amount > policy.ReentryAbove {
+			reentry = true
+		}
+	}
+
+	if !reentry {
+		return nil
+	}
+
+	if !promptPassword {
+		return ErrPolicyPasswordRequired
+	}
+
+	return w.promptPasswordReentry()
+}
+
+// promptPasswordReentry checks the wallet password or the master password if the wallet has none
+func (w *WalletInstance) promptPasswordReentry() error {
+	if w.Backend != nil && w.Backend.HasPassword() {
+		password, err := PromptPassword("Amount above the wallet policy threshold - enter wallet password")
+		if err != nil {
+			return err
+		}
+
+		if !w.Backend.CheckPassword(password) {
+			return errors.New("invalid wallet password")
+		}
+
+		return nil
+	}
+
+	if Context.HasMasterPassword() {
+		password, err := PromptPassword("Amount above the wallet policy threshold - enter master password")
+		if err != nil {
+			return err
+		}
+
+		if Context.CheckMasterPassword(password) != nil {
+			return secure.ErrInvalidPassword
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("%s but the wallet has no password - set a master password", ErrPolicyPasswordRequired)
+}
@@ -0,0 +1,231 @@
+package app
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+)
+
+func TestCheckPolicyLimits(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+
+	err := Context.SetWalletPolicy(&WalletPolicy{
+		WalletId:     w.Id,
+		MaxPerTx:     500,
+		MaxPerDay:    1000,
+		ReentryAbove: 300,
+	})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = w.transfer(deroTransfer("dest", 600), false, txOrigin{})
+	if err == nil || !IsTxNotSent(err) {
+		t.Fatalf("tx above max per tx: got %v", err)
+	}
+
+	_, err = w.transfer(deroTransfer("dest", 400), false, txOrigin{})
+	if !errors.Is(err, ErrPolicyPasswordRequired) || !IsTxNotSent(err) {
+		t.Fatalf("tx above reentry threshold: got %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		_, err = w.transfer(deroTransfer("dest", 300), false, txOrigin{})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, err = w.transfer(deroTransfer("dest", 200), false, txOrigin{})
+	if err == nil || !IsTxNotSent(err) {
+		t.Fatalf("tx above max per day: got %v", err)
+	}
+
+	if len(backend.Transfers) != 3 {
+		t.Fatalf("refused txs reached the wallet: %d transfers", len(backend.Transfers))
+	}
+
+	// txs older than a day are out of the daily cap
+	_, err = Context.DB.Exec(`update app_tx_history set timestamp = timestamp - ?`, POLICY_DAY+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = w.transfer(deroTransfer("dest", 200), false, txOrigin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckPolicyOverflow(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+
+	err := Context.SetWalletPolicy(&WalletPolicy{WalletId: w.Id, MaxPerDay: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = w.transfer(deroTransfer("dest", 500), false, txOrigin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the sent amount plus a huge amount wraps to a small one without the overflow check
+	_, err = w.transfer(deroTransfer("dest", math.MaxUint64-100), false, txOrigin{})
+	if err == nil || !IsTxNotSent(err) {
+		t.Fatalf("tx overflowing the daily cap: got %v", err)
+	}
+
+	p := deroTransfer("dest", math.MaxUint64)
+	p.Transfers = append(p.Transfers, p.Transfers[0])
+	_, err = w.transfer(p, false, txOrigin{})
+	if err == nil || !IsTxNotSent(err) {
+		t.Fatalf("transfers overflowing the tx amount: got %v", err)
+	}
+
+	if len(backend.Transfers) != 1 {
+		t.Fatalf("refused txs reached the wallet: %d transfers", len(backend.Transfers))
+	}
+}
+
+func TestCheckPolicyUncertainSpend(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+
+	err := Context.SetWalletPolicy(&WalletPolicy{WalletId: w.Id, MaxPerDay: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the wallet failed after getting the tx - it may be on chain
+	backend.Err = errors.New("connection reset")
+	_, err = w.transfer(deroTransfer("dest", 800), false, txOrigin{})
+	if err == nil || IsTxNotSent(err) {
+		t.Fatalf("ambiguous tx failure: got %v", err)
+	}
+
+	spent, err := Context.WalletSpentSince(w.Id, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spent[crypto.Hash{}] != 800 {
+		t.Fatalf("uncertain spend not counted: %v", spent)
+	}
+
+	backend.Err = nil
+	_, err = w.transfer(deroTransfer("dest", 300), false, txOrigin{})
+	if err == nil || !IsTxNotSent(err) {
+		t.Fatalf("tx above max per day with an uncertain spend: got %v", err)
+	}
+
+	// a tx refused before it was sent is not counted
+	_, err = w.transfer(deroTransfer("dest", 200), false, txOrigin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spent, err = Context.WalletSpentSince(w.Id, 0)
+	if err != nil || spent[crypto.Hash{}] != 1000 {
+		t.Fatalf("unexpected spent amounts: %v %v", spent, err)
+	}
+}
+
+func TestCheckPolicyAsset(t *testing.T) {
+	newTestContext(t)
+	w, backend := newTestWallet(1)
+
+	scid := crypto.HashHexToHash("aa00000000000000000000000000000000000000000000000000000000000000")
+	backend.Balances[scid] = 100
+
+	err := Context.SetWalletPolicy(&WalletPolicy{WalletId: w.Id, SCID: scid, MaxPerDay: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the asset limit does not apply to DERO
+	_, err = w.transfer(deroTransfer("dest", 5000), false, txOrigin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := deroTransfer("dest", 6)
+	p.Transfers[0].SCID = scid
+	_, err = w.transfer(p, false, txOrigin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = w.transfer(p, false, txOrigin{})
+	if err == nil {
+		t.Fatalf("asset tx above max per day: got %v", err)
+	}
+
+	spent, err := Context.WalletSpentSince(w.Id, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spent[scid] != 6 || spent[crypto.Hash{}] != 5000 {
+		t.Fatalf("unexpected spent amounts: %v", spent)
+	}
+}
+
+func TestCheckPolicyAllowlist(t *testing.T) {
+	newTestContext(t)
+	w, _ := newTestWallet(1)
+
+	err := Context.AddAllowlistEntry(&AllowlistEntry{WalletId: w.Id, Kind: ALLOW_DESTINATION, Value: "friend"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = w.transfer(deroTransfer("stranger", 1), false, txOrigin{})
+	if err == nil {
+		t.Fatalf("destination outside the allowlist: got %v", err)
+	}
+
+	_, err = w.transfer(deroTransfer("friend", 1), false, txOrigin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a zero transfer to a ring member sends nothing to the destination
+	_, err = w.transfer(deroTransfer("stranger", 0), false, txOrigin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecordOfflineTxDailyCap(t *testing.T) {
+	newTestContext(t)
+
+	// the watch-only wallet built the tx signed offline
+	w := &WalletInstance{Id: 2, Name: "watch", WatchAddress: "deto1signer"}
+	Context.walletInstances = []*WalletInstance{w}
+
+	err := Context.SetWalletPolicy(&WalletPolicy{WalletId: w.Id, MaxPerDay: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	offlineTx := &OfflineTx{
+		Signer:    "deto1signer",
+		Ringsize:  2,
+		Transfers: deroTransfer("dest", 800).Transfers,
+		TxId:      "txid",
+	}
+
+	Context.RecordOfflineTx(offlineTx)
+
+	// a broadcasted offline tx counts in the daily cap of the wallet
+	err = w.CheckPolicy(deroTransfer("dest", 300), false)
+	if err == nil {
+		t.Fatal("offline tx not counted in the daily cap")
+	}
+}
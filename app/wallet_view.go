@@ -0,0 +1,375 @@
+package app
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/secure"
+)
+
+var WALLET_VIEW_VERSION = 1
+
+// WalletViewAsset is the decrypted balance of an asset and the encrypted balance it matches on chain
+type WalletViewAsset struct {
+	SCID       crypto.Hash `json:"scid"`
+	Balance    uint64      `json:"balance"`
+	Data       string      `json:"data"` // hex encrypted balance on chain when exported - empty if the asset was never received
+	Topoheight int64       `json:"topoheight"`
+	Entries    []rpc.Entry `json:"entries"` // incoming transfers and coinbase
+}
+
+// WalletView is exported by a wallet with its spend key and imported in a watch-only wallet of the same address.
+// DERO has no view key - the export is a snapshot that stays valid until the encrypted balance changes on chain.
+type WalletView struct {
+	Version   int                `json:"version"`
+	Env       string             `json:"env"`
+	Address   string             `json:"address"`
+	Timestamp int64              `json:"timestamp"`
+	Assets    []*WalletViewAsset `json:"assets"`
+}
+
+// WatchAsset follows the encrypted balance of an asset of a watch-only wallet
+type WatchAsset struct {
+	WalletId          int64
+	SCID              crypto.Hash
+	Data              string
+	Changes           int64 // times the encrypted balance changed since the asset is watched
+	ChangedTopoheight int64
+	Checked           int64
+}
+
+func initWatchTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_wallet_views (
+			wallet_id integer,
+			scid varchar,
+			balance bigint,
+			data varchar,
+			topoheight bigint,
+			entries varchar,
+			imported bigint,
+			primary key (wallet_id, scid)
+		);
+
+		create table if not exists app_watch_assets (
+			wallet_id integer,
+			scid varchar,
+			data varchar,
+			changes integer,
+			changed_topoheight bigint,
+			checked bigint,
+			primary key (wallet_id, scid)
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+func LoadWalletView(filename string) (*WalletView, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var view WalletView
+	err = json.Unmarshal(content, &view)
+	if err != nil {
+		return nil, err
+	}
+
+	if view.Version != WALLET_VIEW_VERSION {
+		return nil, fmt.Errorf("unsupported wallet view version %d", view.Version)
+	}
+
+	return &view, nil
+}
+
+// Save writes the view readable by the owner only - it reveals the balances and incoming transfers
+func (v *WalletView) Save(filename string) error {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return secure.WriteFile(filename, content)
+}
+
+func assetLabel(scid crypto.Hash) string {
+	if scid.IsZero() {
+		return "DERO"
+	}
+
+	return scid.String()
+}
+
+// ExportView decrypts the balances and incoming transfers of the assets for a watch-only wallet
+func (w *WalletInstance) ExportView(scids []crypto.Hash) (*WalletView, error) {
+	if w.IsWatchOnly() {
+		return nil, errors.New("a watch-only wallet can't export a view - export it from the wallet with the spend key")
+	}
+
+	address, err := w.GetAddress()
+	if err != nil {
+		return nil, err
+	}
+
+	view := &WalletView{
+		Version:   WALLET_VIEW_VERSION,
+		Env:       Context.Config.Env,
+		Address:   address,
+		Timestamp: time.Now().Unix(),
+	}
+
+	for _, scid := range scids {
+		before, err := w.encryptedBalance(scid, address, -1)
+		if err != nil {
+			return nil, err
+		}
+
+		balance, err := w.GetBalance(scid)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := w.GetTransfers(&rpc.Get_Transfers_Params{SCID: scid, In: true, Coinbase: true})
+		if err != nil {
+			return nil, err
+		}
+
+		// a wallet rpc may not filter - outgoing transfers would reveal the payees
+		var incoming []rpc.Entry
+		for _, entry := range entries {
+			if entry.Incoming || entry.Coinbase {
+				entry.Data = nil // raw decrypted payload - parsed values are kept
+				incoming = append(incoming, entry)
+			}
+		}
+
+		after, err := w.encryptedBalance(scid, address, -1)
+		if err != nil {
+			return nil, err
+		}
+
+		if before.Data != after.Data {
+			return nil, fmt.Errorf("balance of [%s] changed while exporting - export again", assetLabel(scid))
+		}
+
+		view.Assets = append(view.Assets, &WalletViewAsset{
+			SCID:       scid,
+			Balance:    balance,
+			Data:       after.Data,
+			Topoheight: after.DTopoheight,
+			Entries:    incoming,
+		})
+	}
+
+	return view, nil
+}
+
+// ImportWalletView replaces the views of the assets exported and watches them
+func (app *AppContext) ImportWalletView(walletId int64, watchAddress string, view *WalletView) error {
+	if view.Env != app.Config.Env {
+		return fmt.Errorf("view was exported on env [%s] - current env is [%s]", view.Env, app.Config.Env)
+	}
+
+	if baseAddress(view.Address) != baseAddress(watchAddress) {
+		return fmt.Errorf("view of [%s] does not match the watched address [%s]", view.Address, watchAddress)
+	}
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, asset := range view.Assets {
+		entries, err := json.Marshal(asset.Entries)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			insert or replace into app_wallet_views (wallet_id, scid, balance, data, topoheight, entries, imported)
+			values (?, ?, ?, ?, ?, ?, ?)
+		`, walletId, asset.SCID.String(), asset.Balance, asset.Data, asset.Topoheight, string(entries), view.Timestamp)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec(`
+			insert or ignore into app_watch_assets (wallet_id, scid, data, changes, changed_topoheight, checked)
+			values (?, ?, '', 0, 0, 0)
+		`, walletId, asset.SCID.String())
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (app *AppContext) GetWalletViews(walletId int64) ([]*WalletViewAsset, error) {
+	query := `
+		select scid, balance, data, topoheight, entries
+		from app_wallet_views
+		where wallet_id = ?
+		order by scid
+	`
+
+	rows, err := app.DB.Query(query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var views []*WalletViewAsset
+	for rows.Next() {
+		view := new(WalletViewAsset)
+		var scid, entries string
+		err = rows.Scan(&scid, &view.Balance, &view.Data, &view.Topoheight, &entries)
+		if err != nil {
+			return nil, err
+		}
+
+		err = json.Unmarshal([]byte(entries), &view.Entries)
+		if err != nil {
+			return nil, err
+		}
+
+		view.SCID = crypto.HashHexToHash(scid)
+		views = append(views, view)
+	}
+
+	return views, rows.Err()
+}
+
+// GetWalletView returns nil if no view of the asset was imported
+func (app *AppContext) GetWalletView(walletId int64, scid crypto.Hash) (*WalletViewAsset, error) {
+	views, err := app.GetWalletViews(walletId)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, view := range views {
+		if view.SCID == scid {
+			return view, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (app *AppContext) GetWatchAssets(walletId int64) ([]*WatchAsset, error) {
+	query := `
+		select wallet_id, scid, data, changes, changed_topoheight, checked
+		from app_watch_assets
+		where wallet_id = ?
+		order by scid
+	`
+
+	rows, err := app.DB.Query(query, walletId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var assets []*WatchAsset
+	for rows.Next() {
+		asset := new(WatchAsset)
+		var scid string
+		err = rows.Scan(&asset.WalletId, &scid, &asset.Data, &asset.Changes, &asset.ChangedTopoheight, &asset.Checked)
+		if err != nil {
+			return nil, err
+		}
+
+		asset.SCID = crypto.HashHexToHash(scid)
+		assets = append(assets, asset)
+	}
+
+	return assets, rows.Err()
+}
+
+func (app *AppContext) WatchAsset(walletId int64, scid crypto.Hash) error {
+	query := `
+		insert or ignore into app_watch_assets (wallet_id, scid, data, changes, changed_topoheight, checked)
+		values (?, ?, '', 0, 0, 0)
+	`
+
+	_, err := app.DB.Exec(query, walletId, scid.String())
+	return err
+}
+
+// UnwatchAsset stops watching the asset and removes its imported view
+func (app *AppContext) UnwatchAsset(walletId int64, scid crypto.Hash) error {
+	res, err := app.DB.Exec(`delete from app_watch_assets where wallet_id = ? and scid = ?`, walletId, scid.String())
+	if err != nil {
+		return err
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if count == 0 {
+		return fmt.Errorf("asset [%s] is not watched", assetLabel(scid))
+	}
+
+	_, err = app.DB.Exec(`delete from app_wallet_views where wallet_id = ? and scid = ?`, walletId, scid.String())
+	return err
+}
+
+// RefreshWatchAssets compares the encrypted balances on chain with the last ones seen - DERO is always watched.
+// A change is an incoming or outgoing transfer of the asset or the address used as a ring member.
+func (w *WalletInstance) RefreshWatchAssets() ([]*WatchAsset, error) {
+	if !w.IsWatchOnly() {
+		return nil, errors.New("wallet is not watch-only")
+	}
+
+	var zeroscid crypto.Hash
+	err := Context.WatchAsset(w.Id, zeroscid)
+	if err != nil {
+		return nil, err
+	}
+
+	assets, err := Context.GetWatchAssets(w.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().Unix()
+	for _, asset := range assets {
+		result, err := w.encryptedBalance(asset.SCID, w.WatchAddress, -1)
+		if err != nil {
+			return nil, err
+		}
+
+		if asset.Checked > 0 && result.Data != asset.Data {
+			asset.Changes++
+			asset.ChangedTopoheight = result.DTopoheight
+		}
+
+		asset.Data = result.Data
+		asset.Checked = now
+
+		_, err = Context.DB.Exec(`
+			update app_watch_assets set data = ?, changes = ?, changed_topoheight = ?, checked = ?
+			where wallet_id = ? and scid = ?
+		`, asset.Data, asset.Changes, asset.ChangedTopoheight, asset.Checked, w.Id, asset.SCID.String())
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return assets, nil
+}
@@ -0,0 +1,95 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+// assetMetadata shortens the metadata of an asset in table output - json and csv keep the full value
+func assetMetadata(a *app.Asset) string {
+	metadata := a.Metadata
+	if utils.Output == utils.OUTPUT_TABLE && len(metadata) > 40 {
+		metadata = metadata[:37] + "..."
+	}
+
+	return metadata
+}
+
+func CommandWalletAssets() *cli.Command {
+	return &cli.Command{
+		Name:    "assets",
+		Aliases: []string{"as"},
+		Usage:   "G45 tokens of the wallet found by the asset discovery with their balance",
+		Description: `Scids are taken from the local records of the wallet, the synced asset-trade orders and auctions and the assets of the G45-C collections seen.
+Each one is classified, its balance probed and its transfer history counted. The discovery runs on the first use and with --refresh.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "refresh",
+				Usage: "Run the discovery again instead of listing the cached results",
+			},
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Include zero balances",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
+
+			assets, err := app.Context.GetWalletAssets(walletInstance.Id)
+			if err != nil {
+				return err
+			}
+
+			if len(assets) == 0 || ctx.Bool("refresh") {
+				discovery, err := walletInstance.DiscoverAssets(func(done int, total int) {
+					fmt.Fprintf(os.Stderr, "Discovering assets %d/%d\r", done, total)
+				})
+				fmt.Fprintln(os.Stderr)
+				if err != nil {
+					return err
+				}
+
+				for scid, err := range discovery.Failed {
+					fmt.Fprintf(os.Stderr, "Asset [%s] not classified: %s\n", scid, err)
+				}
+
+				fmt.Fprintf(os.Stderr, "%d scid(s) checked - %d asset(s) found.\n", discovery.Candidates, len(discovery.Assets))
+
+				assets, err = app.Context.GetWalletAssets(walletInstance.Id)
+				if err != nil {
+					return err
+				}
+			}
+
+			if !ctx.Bool("all") {
+				var held []*app.WalletAsset
+				for _, wa := range assets {
+					if wa.Balance > 0 || wa.Err != "" {
+						held = append(held, wa)
+					}
+				}
+
+				assets = held
+			}
+
+			app.Context.DisplayTable(len(assets), func(i int) []interface{} {
+				wa := assets[i]
+				balance := wa.Asset.FormatAmount(wa.Balance)
+				if wa.Err != "" {
+					balance = wa.Err
+				}
+
+				return []interface{}{
+					wa.Asset.Name(), wa.Asset.Standard, balance, wa.Transfers, wa.Source, wa.Asset.SCID.String(), assetMetadata(wa.Asset),
+					time.Unix(wa.Updated, 0).Local().Format("2006-01-02 15:04"),
+				}
+			}, []interface{}{"Name", "Type", "Balance", "Transfers", "Source", "SCID", "Metadata", "Updated"}, 25)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,206 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/dapps"
+	"github.com/urfave/cli/v2"
+)
+
+type scriptLine struct {
+	Number  int
+	Command string
+	Answers []string
+}
+
+// initFromFlags applies global --env, --output and --wallet flags to the app context
+func initFromFlags(ctx *cli.Context) error {
+	output := ctx.String("output")
+	if output != "" {
+		err := app.Context.UseOutput(output)
+		if err != nil {
+			return err
+		}
+	}
+
+	env := ctx.String("env")
+	if env != "" {
+		err := app.Context.UseEnv(env)
+		if err != nil {
+			return err
+		}
+	}
+
+	walletName := ctx.String("wallet")
+	if walletName != "" {
+		err := app.Context.RunLine(fmt.Sprintf("wallet open %s", walletName))
+		if err != nil {
+			return fmt.Errorf("can't open wallet [%s]: %w", walletName, err)
+		}
+	}
+
+	return nil
+}
+
+// retryPrompt prints why the prompt is asked again - a batch answers it with the same value every time
+// so the message is returned as an error instead of looping
+func retryPrompt(message string) error {
+	if app.Context.Batch != nil {
+		return errors.New(message)
+	}
+
+	fmt.Println(message)
+	return nil
+}
+
+func initBatch(ctx *cli.Context) (*app.Batch, error) {
+	batch := app.NewBatch()
+	for _, keyValue := range ctx.StringSlice("set") {
+		err := batch.SetAnswer(keyValue)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	app.InitAppContext(RootApp(), WalletApp(), batch)
+	app.Context.DAppAssetSCIDs = dapps.AssetSCIDs
+	err := initFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return batch, nil
+}
+
+// parseScript reads a script file. Lines starting with # are comments and lines starting with > answer the prompts of the previous command.
+// $1, $2... are replaced by script params and ${NAME} by environment variables.
+func parseScript(filename string, params []string) ([]scriptLine, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	expand := func(key string) string {
+		index, err := strconv.Atoi(key)
+		if err == nil {
+			if index > 0 && index <= len(params) {
+				return params[index-1]
+			}
+
+			return ""
+		}
+
+		return os.Getenv(key)
+	}
+
+	var lines []scriptLine
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, ">") {
+			if len(lines) == 0 {
+				return nil, fmt.Errorf("line %d: answer without a command", lineNumber)
+			}
+
+			answer := os.Expand(strings.TrimSpace(strings.TrimPrefix(line, ">")), expand)
+			last := &lines[len(lines)-1]
+			last.Answers = append(last.Answers, answer)
+			continue
+		}
+
+		lines = append(lines, scriptLine{
+			Number:  lineNumber,
+			Command: os.Expand(line, expand),
+		})
+	}
+
+	err = scanner.Err()
+	if err != nil {
+		return nil, err
+	}
+
+	return lines, nil
+}
+
+func CommandBatchExec() *cli.Command {
+	return &cli.Command{
+		Name:      "exec",
+		Usage:     "Run commands without the interactive prompt and exit",
+		ArgsUsage: "\"command\" [\"command\"...]",
+		Action: func(ctx *cli.Context) error {
+			if !ctx.Args().Present() {
+				return fmt.Errorf("missing command - derosphere exec \"wallet balance\"")
+			}
+
+			batch, err := initBatch(ctx)
+			if err != nil {
+				return err
+			}
+
+			defer app.Context.ResetRootApp()
+
+			batch.QueueAnswers(ctx.StringSlice("answer")...)
+			for _, line := range ctx.Args().Slice() {
+				err = app.Context.RunLine(line)
+				if err != nil {
+					return fmt.Errorf("[%s]: %w", line, err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
+
+func CommandBatchRun() *cli.Command {
+	return &cli.Command{
+		Name:      "run",
+		Usage:     "Run a script file without the interactive prompt and exit",
+		ArgsUsage: "script.dsh [params...]",
+		Action: func(ctx *cli.Context) error {
+			filename := ctx.Args().First()
+			if filename == "" {
+				return fmt.Errorf("missing script file - derosphere run script.dsh")
+			}
+
+			lines, err := parseScript(filename, ctx.Args().Tail())
+			if err != nil {
+				return err
+			}
+
+			batch, err := initBatch(ctx)
+			if err != nil {
+				return err
+			}
+
+			defer app.Context.ResetRootApp()
+
+			for _, line := range lines {
+				batch.ClearQueue()
+				batch.QueueAnswers(line.Answers...)
+
+				err = app.Context.RunLine(line.Command)
+				if err != nil {
+					return fmt.Errorf("%s line %d [%s]: %w", filename, line.Number, line.Command, err)
+				}
+			}
+
+			return nil
+		},
+	}
+}
@@ -2,12 +2,102 @@ package cli
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/dapps"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
 )
 
+// initDataFolder chooses the data folder before the config and the db are loaded
+func initDataFolder(ctx *cli.Context) error {
+	home := ctx.String("data-dir")
+	if home == "" {
+		var err error
+		home, err = config.DefaultHome()
+		if err != nil {
+			return err
+		}
+	}
+
+	return config.SetDataFolder(home, ctx.String("profile"))
+}
+
 func Run() {
-	app.InitAppContext(RootApp(), WalletApp())
-	fmt.Println("Welcome to DeroSphere. Type 'help' for a list of commands")
-	app.Context.Run()
+	cliApp := &cli.App{
+		Name:    "derosphere",
+		Usage:   "Dero wallet and dApps CLI. Starts the interactive prompt if no command is given.",
+		Version: config.Version.String(),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "data-dir",
+				Usage:   "Folder of the config, env dbs and wallet files - default ./data if it exists or $XDG_DATA_HOME/derosphere",
+				EnvVars: []string{"DEROSPHERE_HOME"},
+			},
+			&cli.StringFlag{
+				Name:    "profile",
+				Usage:   "Profile of the data folder with its own config, env dbs and wallet files",
+				Value:   config.DEFAULT_PROFILE,
+				EnvVars: []string{"DEROSPHERE_PROFILE"},
+			},
+			&cli.StringFlag{
+				Name:    "env",
+				Usage:   "Environment to use (mainnet, testnet or simulator) - does not change the saved config",
+				EnvVars: []string{"DEROSPHERE_ENV"},
+			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Usage:   "Output format (table, json or csv) - does not change the saved config",
+				EnvVars: []string{"DEROSPHERE_OUTPUT"},
+			},
+			&cli.StringFlag{
+				Name:    "wallet",
+				Usage:   "Open this wallet before running commands",
+				EnvVars: []string{"DEROSPHERE_WALLET"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "answer",
+				Aliases: []string{"a"},
+				Usage:   "Answer the next prompt of exec commands - in order and empty value uses the prompt default",
+				EnvVars: []string{"DEROSPHERE_ANSWERS"},
+			},
+			&cli.StringSliceFlag{
+				Name:    "set",
+				Usage:   "Always answer a prompt with a value - \"Enter wallet password=secret\"",
+				EnvVars: []string{"DEROSPHERE_SET"},
+			},
+		},
+		Commands: []*cli.Command{
+			CommandBatchExec(),
+			CommandBatchRun(),
+			CommandWalletProcess(),
+		},
+		Before: initDataFolder,
+		Action: func(ctx *cli.Context) error {
+			if ctx.Args().Present() {
+				return fmt.Errorf("unknown command [%s]", ctx.Args().First())
+			}
+
+			app.InitAppContext(RootApp(), WalletApp(), nil)
+			app.Context.SyncEngines = dapps.SyncEngines
+			app.Context.DAppAssetSCIDs = dapps.AssetSCIDs
+			err := initFromFlags(ctx)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Welcome to DeroSphere. Type 'help' for a list of commands")
+			app.Context.Run()
+			return nil
+		},
+	}
+
+	err := cliApp.Run(os.Args)
+	if err != nil {
+		utils.PrintError(os.Stderr, err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,242 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/rpc_client"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func promptContactLabel(ctx *cli.Context) (string, error) {
+	label := ctx.Args().First()
+	if label != "" {
+		return label, nil
+	}
+
+	return app.Prompt("Enter contact label", "")
+}
+
+// promptContact prompts every field of the contact with its current values as default
+func promptContact(contact *app.Contact) error {
+	label, err := app.Prompt("Enter contact label", contact.Label)
+	if err != nil {
+		return err
+	}
+
+	if label == "" {
+		return errors.New("contact label can't be empty")
+	}
+
+	addressOrName, err := app.Prompt("Enter address/name", contact.Address)
+	if err != nil {
+		return err
+	}
+
+	if addressOrName != contact.Address {
+		var daemon *rpc_client.Daemon
+		if app.Context.WalletInstance != nil {
+			daemon = app.Context.WalletInstance.Daemon
+		}
+
+		resolved, err := app.Context.ResolveAddress(daemon, addressOrName)
+		if err != nil {
+			return err
+		}
+
+		if resolved.Source != app.ADDRESS_SOURCE_RAW {
+			fmt.Printf("Address found: %s\n", resolved)
+		}
+
+		addressOrName = resolved.Address
+	}
+
+	note, err := app.Prompt("Note", contact.Note)
+	if err != nil {
+		return err
+	}
+
+	comment, err := app.Prompt("Default comment", contact.Comment)
+	if err != nil {
+		return err
+	}
+
+	sPortNumber := ""
+	if contact.DestinationPort.Valid {
+		sPortNumber = fmt.Sprint(contact.DestinationPort.Int64)
+	}
+
+	sPortNumber, err = app.Prompt("Default destination port number (empty for none)", sPortNumber)
+	if err != nil {
+		return err
+	}
+
+	destinationPort := sql.NullInt64{}
+	if sPortNumber != "" {
+		portNumber, err := strconv.ParseUint(sPortNumber, 10, 63)
+		if err != nil {
+			return err
+		}
+
+		destinationPort = sql.NullInt64{Int64: int64(portNumber), Valid: true}
+	}
+
+	contact.Label = label
+	contact.Address = addressOrName
+	contact.Note = note
+	contact.Comment = comment
+	contact.DestinationPort = destinationPort
+	return nil
+}
+
+func CommandAddContact() *cli.Command {
+	return &cli.Command{
+		Name:    "add",
+		Aliases: []string{"a"},
+		Usage:   "Add a contact to the address book",
+		Action: func(ctx *cli.Context) error {
+			contact := &app.Contact{Label: ctx.Args().First()}
+
+			err := promptContact(contact)
+			if err != nil {
+				return err
+			}
+
+			existing, err := app.Context.GetContact(contact.Label)
+			if err != nil {
+				return err
+			}
+
+			if existing != nil {
+				return fmt.Errorf("contact [%s] already exists", contact.Label)
+			}
+
+			err = app.Context.AddContact(contact)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Contact [%s] added.\n", contact.Label)
+			return nil
+		},
+	}
+}
+
+func CommandListContacts() *cli.Command {
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Usage:   "List contacts of the address book",
+		Action: func(ctx *cli.Context) error {
+			contacts, err := app.Context.GetContacts()
+			if err != nil {
+				return err
+			}
+
+			app.Context.DisplayTable(len(contacts), func(i int) []interface{} {
+				c := contacts[i]
+				port := ""
+				if c.DestinationPort.Valid {
+					port = fmt.Sprint(c.DestinationPort.Int64)
+				}
+
+				return []interface{}{
+					c.Label, c.Address, c.Note, c.Comment, port,
+				}
+			}, []interface{}{"Label", "Address", "Note", "Comment", "Port"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandEditContact() *cli.Command {
+	return &cli.Command{
+		Name:    "edit",
+		Aliases: []string{"e"},
+		Usage:   "Edit a contact of the address book",
+		Action: func(ctx *cli.Context) error {
+			label, err := promptContactLabel(ctx)
+			if err != nil {
+				return err
+			}
+
+			contact, err := app.Context.GetContact(label)
+			if err != nil {
+				return err
+			}
+
+			if contact == nil {
+				return fmt.Errorf("contact [%s] does not exists", label)
+			}
+
+			err = promptContact(contact)
+			if err != nil {
+				return err
+			}
+
+			if contact.Label != label {
+				existing, err := app.Context.GetContact(contact.Label)
+				if err != nil {
+					return err
+				}
+
+				if existing != nil {
+					return fmt.Errorf("contact [%s] already exists", contact.Label)
+				}
+			}
+
+			err = app.Context.EditContact(contact)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Contact [%s] edited.\n", contact.Label)
+			return nil
+		},
+	}
+}
+
+func CommandRemoveContact() *cli.Command {
+	return &cli.Command{
+		Name:    "remove",
+		Aliases: []string{"r"},
+		Usage:   "Remove a contact from the address book",
+		Action: func(ctx *cli.Context) error {
+			label, err := promptContactLabel(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.DelContact(label)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Contact [%s] removed.\n", label)
+			return nil
+		},
+	}
+}
+
+func ContactCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "contacts",
+		Aliases:            []string{"ct"},
+		Usage:              "Address book used to resolve transfer destinations",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandAddContact(),
+			CommandListContacts(),
+			CommandEditContact(),
+			CommandRemoveContact(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/dapps"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func migrationModules() []app.MigrationModule {
+	return append([]app.MigrationModule{app.AppMigrations()}, dapps.Migrations()...)
+}
+
+func displayMigrations(migrations []app.PendingMigration) {
+	app.Context.DisplayTable(len(migrations), func(i int) []interface{} {
+		m := migrations[i]
+		return []interface{}{m.Module, m.Version, m.Name}
+	}, []interface{}{"Module", "Version", "Name"}, 25)
+}
+
+func CommandMigrate() *cli.Command {
+	return &cli.Command{
+		Name:    "migrate",
+		Aliases: []string{"m"},
+		Usage:   "Apply pending schema migrations of the app and every dapp - the database is backed up first",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List pending migrations without applying them",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			modules := migrationModules()
+
+			if ctx.Bool("dry-run") {
+				pending, err := app.Context.PendingMigrations(modules)
+				if err != nil {
+					return err
+				}
+
+				if len(pending) == 0 && utils.Output == utils.OUTPUT_TABLE {
+					fmt.Println("Database is up to date.")
+					return nil
+				}
+
+				displayMigrations(pending)
+				return nil
+			}
+
+			applied, err := app.Context.Migrate(modules...)
+			if err != nil {
+				return err
+			}
+
+			if len(applied) == 0 && utils.Output == utils.OUTPUT_TABLE {
+				fmt.Println("Database is up to date.")
+				return nil
+			}
+
+			displayMigrations(applied)
+			return nil
+		},
+	}
+}
+
+func DBCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "db",
+		Usage:              "Env database schema",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandMigrate(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/deroproject/derohe/globals"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/rpc_client"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func displayNodeStatus(status rpc_client.NodeStatus) string {
+	if errors.Is(status.Err, rpc_client.ErrNodeUnavailable) {
+		return "offline"
+	}
+
+	if status.Err != nil {
+		return status.Err.Error()
+	}
+
+	if status.Testnet == globals.IsMainnet() {
+		return "wrong network"
+	}
+
+	return fmt.Sprintf("online (%d)", status.Height)
+}
+
+func CommandListNodes() *cli.Command {
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Usage:   "List daemon nodes used for failover",
+		Action: func(ctx *cli.Context) error {
+			nodes, err := app.Context.GetNodes()
+			if err != nil {
+				return err
+			}
+
+			app.Context.DisplayTable(len(nodes), func(i int) []interface{} {
+				n := nodes[i]
+				wallet := "all"
+				if n.WalletId.Valid {
+					wallet = n.WalletName.String
+				}
+
+				status := rpc_client.GetNodeStatus(n.Address)
+				return []interface{}{
+					n.Id, wallet, n.Address, n.Priority, displayNodeStatus(status),
+				}
+			}, []interface{}{"Id", "Wallet", "Address", "Priority", "Status"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandAddNode() *cli.Command {
+	return &cli.Command{
+		Name:    "add",
+		Aliases: []string{"a"},
+		Usage:   "Add daemon node to a wallet or every wallet of the environment",
+		Action: func(ctx *cli.Context) error {
+			address := ctx.Args().First()
+			var err error
+
+			if address == "" {
+				address, err = app.Prompt("Enter node rpc address", fmt.Sprintf("http://localhost:%d", getDaemonPort()))
+				if err != nil {
+					return err
+				}
+			}
+
+			walletName, err := app.Prompt("Enter wallet name (empty for every wallet)", "")
+			if err != nil {
+				return err
+			}
+
+			var walletId sql.NullInt64
+			if walletName != "" {
+				_, walletInstance := app.Context.GetWalletInstance(walletName)
+				if walletInstance == nil {
+					return fmt.Errorf("wallet [%s] does not exists", walletName)
+				}
+
+				walletId = sql.NullInt64{Int64: walletInstance.Id, Valid: true}
+			}
+
+			status := rpc_client.GetNodeStatus(address)
+			if status.Err != nil {
+				fmt.Printf("Node is unreachable and will only be used when available: %s\n", status.Err)
+			} else if status.Testnet == globals.IsMainnet() {
+				return fmt.Errorf("Can't add node from another network to %s environment.", app.Context.Config.Env)
+			}
+
+			err = app.Context.AddNode(walletId, address)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Node added.")
+			return app.Context.RefreshNodes()
+		},
+	}
+}
+
+func CommandRemoveNode() *cli.Command {
+	return &cli.Command{
+		Name:    "remove",
+		Aliases: []string{"r"},
+		Usage:   "Remove daemon node",
+		Action: func(ctx *cli.Context) error {
+			sId := ctx.Args().First()
+			var err error
+
+			if sId == "" {
+				sId, err = app.Prompt("Enter node id", "")
+				if err != nil {
+					return err
+				}
+			}
+
+			id, err := strconv.ParseInt(sId, 10, 64)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.DelNode(id)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Node removed.")
+			return app.Context.RefreshNodes()
+		},
+	}
+}
+
+func NodeCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "node",
+		Aliases:            []string{"n"},
+		Usage:              "Daemon nodes used for failover",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandListNodes(),
+			CommandAddNode(),
+			CommandRemoveNode(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
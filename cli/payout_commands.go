@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/urfave/cli/v2"
+)
+
+type payoutAssetSummary struct {
+	scid    crypto.Hash
+	rows    int
+	invalid int
+	sent    int
+	unknown int
+	pending int
+	amount  uint64
+	txs     int
+}
+
+func payoutRowStatus(row *app.PayoutRow) string {
+	if row.Err != nil {
+		return fmt.Sprintf("invalid: %s", row.Err)
+	}
+
+	return row.Status
+}
+
+// summarizePayout returns the rows, amounts and txs per asset - DERO first
+func summarizePayout(rows []*app.PayoutRow, chunks [][]*app.PayoutRow) []*payoutAssetSummary {
+	assets := make(map[crypto.Hash]*payoutAssetSummary)
+	asset := func(row *app.PayoutRow) *payoutAssetSummary {
+		summary, ok := assets[row.SCID]
+		if !ok {
+			summary = &payoutAssetSummary{scid: row.SCID}
+			assets[row.SCID] = summary
+		}
+
+		return summary
+	}
+
+	for _, row := range rows {
+		summary := asset(row)
+		summary.rows++
+
+		switch {
+		case row.Err != nil:
+			summary.invalid++
+		case row.Status == app.PAYOUT_STATUS_SENT:
+			summary.sent++
+		case row.Status == app.PAYOUT_STATUS_SENDING:
+			summary.unknown++
+		}
+	}
+
+	for _, chunk := range chunks {
+		txAssets := make(map[*payoutAssetSummary]bool)
+		for _, row := range chunk {
+			summary := asset(row)
+			summary.pending++
+			summary.amount += row.Amount
+			txAssets[summary] = true
+		}
+
+		for summary := range txAssets {
+			summary.txs++
+		}
+	}
+
+	var summaries []*payoutAssetSummary
+	for _, summary := range assets {
+		summaries = append(summaries, summary)
+	}
+
+	sort.Slice(summaries, func(i, j int) bool {
+		iDero := summaries[i].scid.IsZero()
+		if iDero != summaries[j].scid.IsZero() {
+			return iDero
+		}
+
+		return summaries[i].scid.String() < summaries[j].scid.String()
+	})
+
+	return summaries
+}
+
+func CommandWalletPayout() *cli.Command {
+	return &cli.Command{
+		Name:      "payout",
+		Usage:     "Send a batch payout from a csv file (address/name, amount, asset scid, comment)",
+		ArgsUsage: "[file.csv]",
+		Before:    requireSpendKey,
+		Description: `DERO amounts are in Dero and asset amounts in atomic value. The transfers are split in multiple txs.
+Every row is saved in the journal of the payout name before and after its tx is sent - running the payout again with the same name
+only sends the rows left. A name is used once: a new payout (next month's file...) needs a new name.`,
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Validate the file and show the summary without sending",
+			},
+			&cli.StringFlag{
+				Name:  "name",
+				Usage: "Name of the payout in the journal - prompted if not set",
+			},
+			&cli.IntFlag{
+				Name:  "per-tx",
+				Usage: "Max transfers per tx (default: as many as fit in a tx)",
+			},
+			&cli.BoolFlag{
+				Name:  "resend",
+				Usage: "Send again the rows of a tx interrupted while sending - check the wallet transactions first",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
+
+			filePath := ctx.Args().First()
+			if filePath == "" {
+				var err error
+				filePath, err = app.Prompt("Filepath", "")
+				if err != nil {
+					return err
+				}
+			}
+
+			// the file name is not used as default - the same file is often sent again with new amounts
+			payout := ctx.String("name")
+			if payout == "" {
+				var err error
+				payout, err = app.Prompt("Payout name", "")
+				if err != nil {
+					return err
+				}
+			}
+
+			if payout == "" {
+				return fmt.Errorf("payout name can't be empty")
+			}
+
+			complete, err := app.Context.IsPayoutComplete(walletInstance.Id, payout)
+			if err != nil {
+				return err
+			}
+
+			if complete {
+				return fmt.Errorf("payout [%s] is already complete - use another name for a new payout", payout)
+			}
+
+			rows, err := app.ReadPayoutFile(filePath)
+			if err != nil {
+				return err
+			}
+
+			if len(rows) == 0 {
+				return fmt.Errorf("no payout rows in [%s]", filePath)
+			}
+
+			walletInstance.ValidatePayoutRows(rows)
+
+			err = app.Context.LoadPayoutJournal(walletInstance.Id, payout, rows)
+			if err != nil {
+				return err
+			}
+
+			var toSend []*app.PayoutRow
+			for _, row := range rows {
+				if row.Err != nil || row.Status == app.PAYOUT_STATUS_SENT {
+					continue
+				}
+
+				if row.Status == app.PAYOUT_STATUS_SENDING && !ctx.Bool("resend") {
+					continue
+				}
+
+				toSend = append(toSend, row)
+			}
+
+			app.Context.DisplayTable(len(rows), func(i int) []interface{} {
+				row := rows[i]
+				address := row.Input
+				if row.Resolved != nil {
+					address = row.Resolved.String()
+				}
+
+				return []interface{}{
+					row.Line, address, formatAssetAmount(row.SCID, row.Amount), assetName(row.SCID), row.Comment, payoutRowStatus(row), row.TxId,
+				}
+			}, []interface{}{"Line", "Address", "Amount", "Asset", "Comment", "Status", "TXID"}, 25)
+
+			ringsize, err := app.PromptUInt("Set ringsize", 2)
+			if err != nil {
+				return err
+			}
+
+			chunks := app.ChunkPayoutRows(toSend, ringsize, ctx.Int("per-tx"))
+			summaries := summarizePayout(rows, chunks)
+
+			fmt.Printf("Payout [%s] - %d transfer(s) per tx with ringsize %d\n", payout, app.PayoutTransfersPerTx(ringsize, ctx.Int("per-tx")), ringsize)
+			app.Context.DisplayTable(len(summaries), func(i int) []interface{} {
+				s := summaries[i]
+				return []interface{}{
+					assetName(s.scid), s.rows, s.invalid, s.sent, s.unknown, s.pending, formatAssetAmount(s.scid, s.amount), s.txs,
+				}
+			}, []interface{}{"Asset", "Rows", "Invalid", "Sent", "Unknown", "To Send", "Amount To Send", "Txs"}, 25)
+
+			unknown := 0
+			for _, s := range summaries {
+				unknown += s.unknown
+			}
+
+			if unknown > 0 && !ctx.Bool("resend") {
+				fmt.Printf("%d row(s) were being sent when the payout stopped and are skipped. Check the wallet transactions and use --resend if they were not sent.\n", unknown)
+			}
+
+			if ctx.Bool("dry-run") || len(chunks) == 0 {
+				return nil
+			}
+
+			if unknown > 0 && ctx.Bool("resend") {
+				yes, err := app.PromptYesNo(fmt.Sprintf("%d row(s) may already be on chain. Did you check the wallet transactions and want to send them again?", unknown), false)
+				if err != nil {
+					return err
+				}
+
+				if !yes {
+					return nil
+				}
+			}
+
+			yes, err := app.PromptYesNo(fmt.Sprintf("Send %d transfer(s) in %d tx(s)?", len(toSend), len(chunks)), false)
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				return nil
+			}
+
+			for i, chunk := range chunks {
+				txid, err := walletInstance.SendPayout(payout, chunk, ringsize)
+				if err != nil && !app.IsTxNotSent(err) {
+					return fmt.Errorf("tx %d/%d may have been sent: %s - check the wallet transactions before sending its rows again with --resend", i+1, len(chunks), err)
+				}
+
+				if err != nil {
+					return fmt.Errorf("tx %d/%d failed: %s - run the payout again to send the rows left", i+1, len(chunks), err)
+				}
+
+				fmt.Printf("Tx %d/%d sent with %d transfer(s). TXID: %s\n", i+1, len(chunks), len(chunk), txid)
+			}
+
+			fmt.Println("Payout sent. Confirmations are tracked in the background - use tx pending to see them.")
+			return nil
+		},
+	}
+}
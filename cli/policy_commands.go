@@ -0,0 +1,262 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func promptAssetArg(ctx *cli.Context) (crypto.Hash, error) {
+	assetToken := ctx.Args().First()
+	if assetToken == "" {
+		var err error
+		assetToken, err = app.Prompt("Enter asset token (empty for DERO)", "")
+		if err != nil {
+			return crypto.Hash{}, err
+		}
+	}
+
+	if assetToken != "" && len(assetToken) != 64 {
+		return crypto.Hash{}, fmt.Errorf("invalid asset token [%s]", assetToken)
+	}
+
+	return crypto.HashHexToHash(assetToken), nil
+}
+
+func CommandShowPolicy() *cli.Command {
+	return &cli.Command{
+		Name:    "show",
+		Aliases: []string{"s"},
+		Usage:   "Show the limits and allowlist of the wallet",
+		Action: func(ctx *cli.Context) error {
+			walletId := app.Context.WalletInstance.Id
+
+			policies, err := app.Context.GetWalletPolicies(walletId)
+			if err != nil {
+				return err
+			}
+
+			spent, err := app.Context.WalletSpentSince(walletId, time.Now().Unix()-app.POLICY_DAY)
+			if err != nil {
+				return err
+			}
+
+			limit := func(scid crypto.Hash, amount uint64) string {
+				if amount == 0 {
+					return "none"
+				}
+
+				return formatAssetAmount(scid, amount)
+			}
+
+			app.Context.DisplayTable(len(policies), func(i int) []interface{} {
+				p := policies[i]
+				return []interface{}{
+					assetName(p.SCID), limit(p.SCID, p.MaxPerTx), limit(p.SCID, p.MaxPerDay), formatAssetAmount(p.SCID, spent[p.SCID]), limit(p.SCID, p.ReentryAbove),
+				}
+			}, []interface{}{"Asset", "Max Per Tx", "Max Per Day", "Sent 24h", "Password Above"}, 25)
+
+			entries, err := app.Context.GetAllowlist(walletId)
+			if err != nil {
+				return err
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No allowlist - every destination and scid is allowed.")
+				return nil
+			}
+
+			app.Context.DisplayTable(len(entries), func(i int) []interface{} {
+				e := entries[i]
+				return []interface{}{e.Kind, e.Value, e.Note}
+			}, []interface{}{"Allowed", "Value", "Note"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandSetPolicy() *cli.Command {
+	return &cli.Command{
+		Name:      "set",
+		Usage:     "Set the limits of an asset - 0 for no limit",
+		ArgsUsage: "[asset token]",
+		Action: func(ctx *cli.Context) error {
+			walletId := app.Context.WalletInstance.Id
+
+			scid, err := promptAssetArg(ctx)
+			if err != nil {
+				return err
+			}
+
+			policy, err := app.Context.GetWalletPolicy(walletId, scid)
+			if err != nil {
+				return err
+			}
+
+			promptAmount := func(prompt string, defaultValue uint64) (uint64, error) {
+				if scid.IsZero() {
+					return app.PromptDero(fmt.Sprintf("%s (in Dero)", prompt), defaultValue)
+				}
+
+				return app.PromptUInt(fmt.Sprintf("%s (atomic value)", prompt), defaultValue)
+			}
+
+			policy.MaxPerTx, err = promptAmount("Max amount per tx", policy.MaxPerTx)
+			if err != nil {
+				return err
+			}
+
+			policy.MaxPerDay, err = promptAmount("Max amount per 24 hours", policy.MaxPerDay)
+			if err != nil {
+				return err
+			}
+
+			policy.ReentryAbove, err = promptAmount("Require password above", policy.ReentryAbove)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.SetWalletPolicy(policy)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Policy of [%s] saved.\n", assetName(scid))
+			return nil
+		},
+	}
+}
+
+func CommandRemovePolicy() *cli.Command {
+	return &cli.Command{
+		Name:      "remove",
+		Aliases:   []string{"r"},
+		Usage:     "Remove the limits of an asset",
+		ArgsUsage: "[asset token]",
+		Action: func(ctx *cli.Context) error {
+			scid, err := promptAssetArg(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.DelWalletPolicy(app.Context.WalletInstance.Id, scid)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Policy of [%s] removed.\n", assetName(scid))
+			return nil
+		},
+	}
+}
+
+// promptAllowlistValue returns the kind and value of a scid or of the base address of a destination
+func promptAllowlistValue(ctx *cli.Context) (string, string, error) {
+	input := ctx.Args().First()
+	if input == "" {
+		var err error
+		input, err = app.Prompt("Enter scid or address/name/contact", "")
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	if len(input) == 64 {
+		return app.ALLOW_SCID, input, nil
+	}
+
+	resolved, err := app.Context.ResolveAddress(app.Context.WalletInstance.Daemon, input)
+	if err != nil {
+		return "", "", err
+	}
+
+	if resolved.Source != app.ADDRESS_SOURCE_RAW {
+		fmt.Printf("Address found: %s\n", resolved)
+	}
+
+	address, err := resolved.BaseAddress()
+	if err != nil {
+		return "", "", err
+	}
+
+	return app.ALLOW_DESTINATION, address, nil
+}
+
+func CommandAllowPolicy() *cli.Command {
+	return &cli.Command{
+		Name:      "allow",
+		Usage:     "Add a destination or scid to the allowlist - once a kind has entries only them are allowed",
+		ArgsUsage: "[scid or address/name/contact]",
+		Action: func(ctx *cli.Context) error {
+			kind, value, err := promptAllowlistValue(ctx)
+			if err != nil {
+				return err
+			}
+
+			note, err := app.Prompt("Note", "")
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.AddAllowlistEntry(&app.AllowlistEntry{
+				WalletId: app.Context.WalletInstance.Id,
+				Kind:     kind,
+				Value:    value,
+				Note:     note,
+			})
+
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("[%s] added to the %s allowlist.\n", value, kind)
+			return nil
+		},
+	}
+}
+
+func CommandDisallowPolicy() *cli.Command {
+	return &cli.Command{
+		Name:      "disallow",
+		Usage:     "Remove a destination or scid from the allowlist",
+		ArgsUsage: "[scid or address/name/contact]",
+		Action: func(ctx *cli.Context) error {
+			_, value, err := promptAllowlistValue(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.DelAllowlistEntry(app.Context.WalletInstance.Id, value)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("[%s] removed from the allowlist.\n", value)
+			return nil
+		},
+	}
+}
+
+func PolicyCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "policy",
+		Aliases:            []string{"pol"},
+		Usage:              "Spending limits and allowlist enforced on every tx of the wallet",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandShowPolicy(),
+			CommandSetPolicy(),
+			CommandRemovePolicy(),
+			CommandAllowPolicy(),
+			CommandDisallowPolicy(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,152 @@
+package cli
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/urfave/cli/v2"
+)
+
+type portfolioTotal struct {
+	asset    *app.Asset
+	wallets  int
+	total    uint64
+	previous uint64
+}
+
+func portfolioChange(b *app.PortfolioBalance) string {
+	if !b.HasPrevious {
+		return "new"
+	}
+
+	return b.Asset.FormatDelta(b.Previous, b.Balance)
+}
+
+// sumPortfolio returns the totals per asset of the balances read - DERO first
+func sumPortfolio(wallets []*app.PortfolioWallet) []*portfolioTotal {
+	totals := make(map[crypto.Hash]*portfolioTotal)
+	for _, pw := range wallets {
+		for _, b := range pw.Balances {
+			if b.Err != nil {
+				continue
+			}
+
+			total, ok := totals[b.Asset.SCID]
+			if !ok {
+				total = &portfolioTotal{asset: b.Asset}
+				totals[b.Asset.SCID] = total
+			}
+
+			total.wallets++
+			total.total += b.Balance
+			total.previous += b.Previous
+		}
+	}
+
+	var list []*portfolioTotal
+	for _, total := range totals {
+		list = append(list, total)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		iDero := list[i].asset.SCID.IsZero()
+		if iDero != list[j].asset.SCID.IsZero() {
+			return iDero
+		}
+
+		return list[i].asset.Name() < list[j].asset.Name()
+	})
+
+	return list
+}
+
+func CommandPortfolio() *cli.Command {
+	return &cli.Command{
+		Name:    "portfolio",
+		Aliases: []string{"pf"},
+		Usage:   "Balances of DERO and G45 tokens across the attached wallets with the changes since the last snapshot",
+		Description: `Wallets not in use are connected only to read their balances - their password is prompted if needed.
+G45-AT, G45-FAT and G45-NFT tokens are found in the tx history, payouts, schedules, policies, watched assets and discovered assets of each wallet.`,
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "wallet",
+				Usage: "Only this wallet - repeat for more wallets",
+			},
+			&cli.BoolFlag{
+				Name:  "no-snapshot",
+				Usage: "Don't save the balances as the new snapshot",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			walletInstances := app.Context.GetWalletInstances()
+			if names := ctx.StringSlice("wallet"); len(names) > 0 {
+				walletInstances = nil
+				for _, name := range names {
+					_, walletInstance := app.Context.GetWalletInstance(name)
+					if walletInstance == nil {
+						return fmt.Errorf("wallet [%s] does not exists", name)
+					}
+
+					walletInstances = append(walletInstances, walletInstance)
+				}
+			}
+
+			if len(walletInstances) == 0 {
+				fmt.Println("No wallet attached.")
+				return nil
+			}
+
+			wallets := app.Context.ReadPortfolio(walletInstances)
+
+			var balances []*app.PortfolioBalance
+			for _, pw := range wallets {
+				balances = append(balances, pw.Balances...)
+			}
+
+			app.Context.DisplayTable(len(balances), func(i int) []interface{} {
+				b := balances[i]
+				since := ""
+				if b.HasPrevious {
+					since = time.Unix(b.Since, 0).Local().Format("2006-01-02 15:04")
+				}
+
+				if b.Err != nil {
+					return []interface{}{b.WalletName, b.Asset.Name(), b.Asset.Standard, "", "", since, b.Err.Error()}
+				}
+
+				return []interface{}{
+					b.WalletName, b.Asset.Name(), b.Asset.Standard, b.Asset.FormatAmount(b.Balance), portfolioChange(b), since, "",
+				}
+			}, []interface{}{"Wallet", "Asset", "Type", "Balance", "Change", "Since", "Error"}, 25)
+
+			totals := sumPortfolio(wallets)
+			app.Context.DisplayTable(len(totals), func(i int) []interface{} {
+				t := totals[i]
+				return []interface{}{
+					t.asset.Name(), t.asset.Standard, t.wallets, t.asset.FormatAmount(t.total), t.asset.FormatDelta(t.previous, t.total),
+				}
+			}, []interface{}{"Asset", "Type", "Wallets", "Total", "Change"}, 25)
+
+			for _, pw := range wallets {
+				if pw.Err != nil {
+					fmt.Printf("Wallet [%s] not read: %s\n", pw.Wallet.Name, pw.Err)
+				}
+			}
+
+			if ctx.Bool("no-snapshot") {
+				return nil
+			}
+
+			snapshot, err := app.Context.SavePortfolioSnapshot(wallets)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Snapshot saved at %s.\n", time.Unix(snapshot.Timestamp, 0).Local().Format("2006-01-02 15:04"))
+			return nil
+		},
+	}
+}
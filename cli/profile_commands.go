@@ -0,0 +1,151 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func promptProfileName(ctx *cli.Context, index int, label string) (string, error) {
+	name := ctx.Args().Get(index)
+	if name != "" {
+		return name, nil
+	}
+
+	return app.Prompt(label, "")
+}
+
+func CommandProfileList() *cli.Command {
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Usage:   "Profiles of the data folder - * is the one in use",
+		Action: func(ctx *cli.Context) error {
+			profiles, err := app.ListProfiles()
+			if err != nil {
+				return err
+			}
+
+			app.Context.DisplayTable(len(profiles), func(i int) []interface{} {
+				p := profiles[i]
+				current := ""
+				if p.Name == config.PROFILE {
+					current = "*"
+				}
+
+				return []interface{}{current, p.Name, p.Folder, strings.Join(p.Envs, ", ")}
+			}, []interface{}{"", "Name", "Folder", "Envs"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandProfileCreate() *cli.Command {
+	return &cli.Command{
+		Name:      "create",
+		Aliases:   []string{"c"},
+		Usage:     "Create an empty profile",
+		ArgsUsage: "[name]",
+		Action: func(ctx *cli.Context) error {
+			name, err := promptProfileName(ctx, 0, "Enter profile name")
+			if err != nil {
+				return err
+			}
+
+			err = app.CreateProfile(name)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Profile [%s] created - start derosphere with --profile %s to use it.\n", name, name)
+			return nil
+		},
+	}
+}
+
+func CommandProfileCopy() *cli.Command {
+	return &cli.Command{
+		Name:      "copy",
+		Aliases:   []string{"cp"},
+		Usage:     "Copy the config, env dbs, backups and wallet files of a profile to a new one",
+		ArgsUsage: "[from] [to]",
+		Action: func(ctx *cli.Context) error {
+			from, err := promptProfileName(ctx, 0, "Enter profile to copy")
+			if err != nil {
+				return err
+			}
+
+			to, err := promptProfileName(ctx, 1, "Enter new profile name")
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.CopyProfile(from, to)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Profile [%s] copied to [%s] - wallet files of the profile were copied, wallet files attached from outside the profile are shared.\n", from, to)
+			return nil
+		},
+	}
+}
+
+func CommandProfileDelete() *cli.Command {
+	return &cli.Command{
+		Name:      "delete",
+		Aliases:   []string{"d"},
+		Usage:     "Delete a profile with its env dbs and wallet files",
+		ArgsUsage: "[name]",
+		Action: func(ctx *cli.Context) error {
+			name, err := promptProfileName(ctx, 0, "Enter profile name")
+			if err != nil {
+				return err
+			}
+
+			err = app.CheckDeleteProfile(name)
+			if err != nil {
+				return err
+			}
+
+			yes, err := app.PromptYesNo(fmt.Sprintf("Delete profile [%s] and its wallet files?", name), false)
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				return nil
+			}
+
+			err = app.DeleteProfile(name)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Profile [%s] deleted.\n", name)
+			return nil
+		},
+	}
+}
+
+func ProfileCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "profile",
+		Usage:              "Separate setups in the data folder - chosen at start with --profile",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandProfileList(),
+			CommandProfileCreate(),
+			CommandProfileCopy(),
+			CommandProfileDelete(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -74,7 +74,11 @@ setDaemon:
 
 	err = walletInstance.SetupDaemon()
 	if err != nil {
-		fmt.Println(err)
+		err = retryPrompt(err.Error())
+		if err != nil {
+			return err
+		}
+
 		goto setDaemon
 	}
 
@@ -97,8 +101,14 @@ setDaemon:
 	return nil
 }
 
+// checkWalletConnection connects the wallet once - it is opened later with wallet open
+func checkWalletConnection(walletInstance *app.WalletInstance) error {
+	defer walletInstance.Close()
+	return walletInstance.Connect()
+}
+
 func editWalletInstanceWallet(walletInstance *app.WalletInstance) error {
-	walletType, err := app.PromptChoose("Set wallet connection from", []string{"rpc", "file"}, "rpc")
+	walletType, err := app.PromptChoose("Set wallet connection from", []string{"rpc", "file", "watch"}, "rpc")
 	if err != nil {
 		return err
 	}
@@ -112,9 +122,9 @@ func editWalletInstanceWallet(walletInstance *app.WalletInstance) error {
 		}
 
 		walletInstance.WalletAddress = address
-		err = walletInstance.Open()
+		walletInstance.WatchAddress = ""
+		err = checkWalletConnection(walletInstance)
 		if err != nil {
-			fmt.Println(err)
 			return err
 		}
 
@@ -125,10 +135,35 @@ func editWalletInstanceWallet(walletInstance *app.WalletInstance) error {
 		}
 
 		walletInstance.WalletPath = walletFilePath
-		err = walletInstance.Open()
+		walletInstance.WatchAddress = ""
+		err = checkWalletConnection(walletInstance)
+
+		if err != nil {
+			return err
+		}
+
+	case "watch":
+		input, err := app.Prompt("Enter address/contact to watch", "")
+		if err != nil {
+			return err
+		}
 
+		resolved, err := app.Context.ResolveAddress(nil, input)
+		if err != nil {
+			return err
+		}
+
+		address, err := resolved.BaseAddress()
+		if err != nil {
+			return err
+		}
+
+		// a watch-only wallet never keeps a connection able to sign
+		walletInstance.WatchAddress = address
+		walletInstance.WalletAddress = ""
+		walletInstance.WalletPath = ""
+		err = checkWalletConnection(walletInstance)
 		if err != nil {
-			fmt.Println(err)
 			return err
 		}
 	}
@@ -148,14 +183,18 @@ func CommandAttachWallet() *cli.Command {
 		name:
 			if name == "" {
 				name, err = app.Prompt("Enter wallet name", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			_, walletInstance := app.Context.GetWalletInstance(name)
 			if walletInstance != nil {
-				fmt.Println("A wallet with this name is already attached.")
+				err = retryPrompt("A wallet with this name is already attached.")
+				if err != nil {
+					return err
+				}
+
 				name = ""
 				goto name
 			}
@@ -163,26 +202,29 @@ func CommandAttachWallet() *cli.Command {
 			walletInstance = new(app.WalletInstance)
 
 			if name == "" {
-				fmt.Println("Name cannot be empty.")
+				err = retryPrompt("Name cannot be empty.")
+				if err != nil {
+					return err
+				}
+
 				goto name
 			}
 
 			walletInstance.Name = name
 
 			err = editWalletInstanceDaemon(walletInstance)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			err = editWalletInstanceWallet(walletInstance)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			err = walletInstance.Add()
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Printf("New wallet %s attached and saved.", name)
@@ -205,8 +247,8 @@ func CommandDetachWallet() *cli.Command {
 			}
 
 			yes, err := app.PromptYesNo("Are you sure?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if !yes {
@@ -215,8 +257,7 @@ func CommandDetachWallet() *cli.Command {
 
 			err = walletInstance.Del(listIndex)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Printf("Wallet %s detached.\n", name)
@@ -238,33 +279,35 @@ func CommandEditWallet() *cli.Command {
 				return nil
 			}
 
+			if app.Context.IsSession(walletInstance) {
+				return fmt.Errorf("wallet [%s] is open - close its session before editing it", name)
+			}
+
 			editType, err := app.PromptChoose("What do you want to change?", []string{"daemon", "wallet"}, "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			switch editType {
 			case "daemon":
 				err = editWalletInstanceDaemon(walletInstance)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				err := walletInstance.Save()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			case "wallet":
 				err = editWalletInstanceWallet(walletInstance)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				err := walletInstance.Save()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			}
 
@@ -298,40 +341,49 @@ func OpenWalletAction(ctx *cli.Context, useApp string) error {
 setWalletName:
 	if walletName == "" {
 		walletName, err = app.Prompt("Enter wallet name", "")
-		if app.HandlePromptErr(err) {
-			return nil
+		if err != nil {
+			return err
 		}
 	}
 
 	_, walletInstance := app.Context.GetWalletInstance(walletName)
 	if walletInstance == nil {
-		fmt.Println("Wallet does not exists.")
+		err = retryPrompt("Wallet does not exists.")
+		if err != nil {
+			return err
+		}
+
 		walletName = ""
 		goto setWalletName
 	}
 
-	if app.Context.WalletInstance != nil {
-		if app.Context.WalletInstance.Name == walletName {
-			fmt.Println("Already connected to this wallet.")
-			return nil
-		}
+	if app.Context.WalletInstance == walletInstance {
+		fmt.Println("Already connected to this wallet.")
+		return nil
 	}
 
-	err = walletInstance.Open()
-	if app.HandlePromptErr(err) {
+	// the other wallets stay open - see session list
+	if app.Context.IsSession(walletInstance) {
+		app.Context.FocusSession(walletInstance)
+		if useApp != "" {
+			app.Context.SetUseApp(useApp, app.Context.DAppApp) // "walletApp"
+		}
+
+		fmt.Fprintf(os.Stderr, "Wallet [%s] in focus.\n", walletName)
 		return nil
 	}
 
-	if app.Context.WalletInstance != nil {
-		app.Context.WalletInstance.Close()
+	err = app.Context.OpenSession(walletInstance)
+	if err != nil {
+		return err
 	}
 
-	app.Context.WalletInstance = walletInstance
+	app.Context.FocusSession(walletInstance)
 	if useApp != "" {
 		app.Context.UseApp = useApp // "walletApp"
 	}
 
-	fmt.Println("Wallet connection successful.")
+	fmt.Fprintln(os.Stderr, "Wallet connection successful.")
 	return nil
 }
 
@@ -358,13 +410,17 @@ func CommandCreateWallet() *cli.Command {
 		setWalletName:
 			if walletFileName == "" {
 				walletFileName, err = app.Prompt("Enter new wallet filename", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			if walletFileName == "" {
-				fmt.Println("Wallet filename can't be empty.")
+				err = retryPrompt("Wallet filename can't be empty.")
+				if err != nil {
+					return err
+				}
+
 				goto setWalletName
 			}
 
@@ -374,27 +430,30 @@ func CommandCreateWallet() *cli.Command {
 
 			_, err = os.Stat(filePath)
 			if !errors.Is(err, os.ErrNotExist) {
-				fmt.Printf("A wallet already exists at this location: %s\n", filePath)
+				err = retryPrompt(fmt.Sprintf("A wallet already exists at this location: %s", filePath))
+				if err != nil {
+					return err
+				}
+
 				walletFileName = ""
 				goto setWalletName
 			}
 
 			createType, err := app.PromptChoose("Create disk wallet from", []string{"seed-random", "seed-words", "seed-hex"}, "seed-random")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			switch createType {
 			case "seed-random":
 				password, err := app.PromptPassword("Enter new wallet password")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				wallet, err := walletapi.Create_Encrypted_Wallet_Random(filePath, password)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				fmt.Println("####SEED####")
@@ -403,35 +462,32 @@ func CommandCreateWallet() *cli.Command {
 
 				err = wallet.Save_Wallet()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			case "seed-words":
 				seed, err := app.Prompt("Enter seed (25 words)", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				password, err := app.PromptPassword("Enter new wallet password")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				wallet, err := walletapi.Create_Encrypted_Wallet_From_Recovery_Words(filePath, password, seed)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				err = wallet.Save_Wallet()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			case "seed-hex":
 				seed, err := app.Prompt("Enter seed (64 chars)", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				if len(seed) >= 65 {
@@ -441,25 +497,22 @@ func CommandCreateWallet() *cli.Command {
 
 				seedRaw, err := hex.DecodeString(seed)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				password, err := app.PromptPassword("Enter new wallet password")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				wallet, err := walletapi.Create_Encrypted_Wallet(filePath, password, new(crypto.BNRed).SetBytes(seedRaw))
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				err = wallet.Save_Wallet()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			}
 
@@ -476,7 +529,7 @@ func CommandVersion(name string, version semver.Version) *cli.Command {
 		Aliases: []string{"v"},
 		Usage:   "Display current version",
 		Action: func(ctx *cli.Context) error {
-			fmt.Printf("%s v%s\n", name, version)
+			utils.PrintValue("Version", fmt.Sprintf("%s v%s", name, version))
 			return nil
 		},
 	}
@@ -488,7 +541,13 @@ func CommandExit() *cli.Command {
 		Aliases: []string{"quit", "q"},
 		Usage:   "Quit CLI application",
 		Action: func(ctx *cli.Context) error {
-			os.Exit(1)
+			app.Context.ResetRootApp()
+			err := app.Context.CloseDB()
+			if err != nil {
+				return err
+			}
+
+			os.Exit(0)
 			return nil
 		},
 	}
@@ -503,23 +562,33 @@ func CommandSetEnv() *cli.Command {
 			var err error = nil
 
 			if env == "" {
-				env, err = app.PromptChoose("Enter environment", []string{"mainnet", "testnet", "simulator"}, "")
-				if app.HandlePromptErr(err) {
-					return nil
+				env, err = app.PromptChoose("Enter environment", app.ENVS, "")
+				if err != nil {
+					return err
 				}
 			}
 
-			switch env {
-			case "mainnet":
-			case "testnet":
-			case "simulator":
-			default:
-				fmt.Println("Can't set. Invalid environment. Valid env are `mainnet`, `testnet` or `simulator`")
-				return nil
+			return app.Context.SetEnv(env)
+		},
+	}
+}
+
+func CommandSetOutput() *cli.Command {
+	return &cli.Command{
+		Name:  "set-output",
+		Usage: "Change output format - table, json or csv",
+		Action: func(ctx *cli.Context) error {
+			output := ctx.Args().First()
+			var err error = nil
+
+			if output == "" {
+				output, err = app.PromptChoose("Enter output format", utils.OUTPUTS, app.Context.Config.Output)
+				if err != nil {
+					return err
+				}
 			}
 
-			app.Context.SetEnv(env)
-			return nil
+			return app.Context.SetOutput(output)
 		},
 	}
 }
@@ -536,13 +605,12 @@ func CommandSetWalletInactivity() *cli.Command {
 			if timeoutString != "" {
 				timeout, err = strconv.ParseUint(timeoutString, 10, 64)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			} else {
 				timeout, err = app.PromptUInt("Enter timeout in second", 300)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -556,7 +624,16 @@ func CommandSetWalletInactivity() *cli.Command {
 func Commands() []*cli.Command {
 	return []*cli.Command{
 		WalletCommands(),
+		SessionCommands(),
+		CommandPortfolio(),
+		NodeCommands(),
+		DBCommands(),
+		ProfileCommands(),
+		SecurityCommands(),
+		VaultCommands(),
+		ContactCommands(),
 		CommandSetEnv(),
+		CommandSetOutput(),
 		CommandSetWalletInactivity(),
 		CommandVersion("derosphere", config.Version),
 		CommandExit(),
@@ -577,8 +654,7 @@ func RootApp() *cli.App {
 		},
 		CustomAppHelpTemplate: utils.AppTemplate,
 		Action: func(ctx *cli.Context) error {
-			fmt.Println("Command not found. Type 'help' for a list of commands.")
-			return nil
+			return errors.New("Command not found. Type 'help' for a list of commands.")
 		},
 	}
 }
@@ -0,0 +1,317 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/transaction"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func promptScheduleName(ctx *cli.Context) (string, error) {
+	name := ctx.Args().First()
+	if name != "" {
+		return name, nil
+	}
+
+	return app.Prompt("Enter schedule name", "")
+}
+
+func formatScheduleTime(timestamp int64) string {
+	return time.Unix(timestamp, 0).Local().Format("2006-01-02 15:04")
+}
+
+func CommandAddSchedule() *cli.Command {
+	return &cli.Command{
+		Name:      "add",
+		Aliases:   []string{"a"},
+		Usage:     "Schedule a recurring transfer",
+		ArgsUsage: "[name]",
+		Before:    requireSpendKey,
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
+
+			name, err := promptScheduleName(ctx)
+			if err != nil {
+				return err
+			}
+
+			if name == "" {
+				return errors.New("schedule name can't be empty")
+			}
+
+			existing, err := app.Context.GetSchedule(walletInstance.Id, name)
+			if err != nil {
+				return err
+			}
+
+			if existing != nil {
+				return fmt.Errorf("schedule [%s] already exists", name)
+			}
+
+			expr, err := app.Prompt("Enter cron expression (minute hour day month weekday)", "")
+			if err != nil {
+				return err
+			}
+
+			_, err = app.ParseCron(expr)
+			if err != nil {
+				return err
+			}
+
+			assetToken, err := app.Prompt("Enter asset token (empty for sending DERO)", "")
+			if err != nil {
+				return err
+			}
+
+			resolved, err := app.PromptAddress("Enter address/name/contact")
+			if err != nil {
+				return err
+			}
+
+			scid := crypto.HashHexToHash(assetToken)
+			promptAmount := func(prompt string) (uint64, error) {
+				if scid.IsZero() {
+					return app.PromptDero(fmt.Sprintf("%s (in Dero)", prompt), 0)
+				}
+
+				return app.PromptUInt(fmt.Sprintf("%s (atomic value)", prompt), 0)
+			}
+
+			amount, err := promptAmount("Enter amount")
+			if err != nil {
+				return err
+			}
+
+			defaultComment := ""
+			if resolved.Contact != nil {
+				defaultComment = resolved.Contact.Comment
+			}
+
+			comment, err := app.Prompt("Comment", defaultComment)
+			if err != nil {
+				return err
+			}
+
+			if comment != "" {
+				args := rpc.Arguments{{Name: rpc.RPC_COMMENT, DataType: rpc.DataString, Value: comment}}
+				_, err = args.CheckPack(transaction.PAYLOAD0_LIMIT)
+				if err != nil {
+					return err
+				}
+			}
+
+			ringsize, err := app.PromptUInt("Set ringsize", 2)
+			if err != nil {
+				return err
+			}
+
+			capAmount, err := promptAmount("Spending cap per period, 0 for none")
+			if err != nil {
+				return err
+			}
+
+			capDays := uint64(0)
+			if capAmount > 0 {
+				if capAmount < amount {
+					return errors.New("spending cap is lower than the amount")
+				}
+
+				capDays, err = app.PromptUInt("Spending cap period in days", 30)
+				if err != nil {
+					return err
+				}
+			}
+
+			catchUp, err := app.PromptChoose("Catch-up policy for missed runs", app.CATCH_UP_POLICIES, app.CATCH_UP_ONCE)
+			if err != nil {
+				return err
+			}
+
+			now := time.Now().Unix()
+			schedule := &app.ScheduledTransfer{
+				WalletId:    walletInstance.Id,
+				Name:        name,
+				Cron:        expr,
+				Destination: resolved.Address,
+				SCID:        scid,
+				Amount:      amount,
+				Comment:     comment,
+				Ringsize:    ringsize,
+				CapAmount:   capAmount,
+				CapPeriod:   int64(capDays) * 24 * 60 * 60,
+				CatchUp:     catchUp,
+				LastRun:     now,
+				Timestamp:   now,
+			}
+
+			err = app.Context.AddSchedule(schedule)
+			if err != nil {
+				return err
+			}
+
+			next, err := schedule.NextRun()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Schedule [%s] added. Next run: %s\n", name, next.Local().Format("2006-01-02 15:04"))
+			return nil
+		},
+	}
+}
+
+func CommandListSchedules() *cli.Command {
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Usage:   "List scheduled transfers",
+		Action: func(ctx *cli.Context) error {
+			schedules, err := app.Context.GetSchedules(app.Context.WalletInstance.Id)
+			if err != nil {
+				return err
+			}
+
+			app.Context.DisplayTable(len(schedules), func(i int) []interface{} {
+				s := schedules[i]
+				status := "active"
+				nextRun := ""
+				if s.Paused {
+					status = "paused"
+				} else if next, err := s.NextRun(); err == nil {
+					nextRun = next.Local().Format("2006-01-02 15:04")
+				}
+
+				spendingCap := ""
+				if s.CapAmount > 0 {
+					spendingCap = fmt.Sprintf("%s / %dd", formatAssetAmount(s.SCID, s.CapAmount), s.CapPeriod/(24*60*60))
+				}
+
+				return []interface{}{
+					s.Name, s.Cron, s.Destination, formatAssetAmount(s.SCID, s.Amount), assetName(s.SCID), s.Comment, spendingCap, s.CatchUp, status, nextRun,
+				}
+			}, []interface{}{"Name", "Cron", "Destination", "Amount", "Asset", "Comment", "Cap", "Catch-up", "Status", "Next Run"}, 25)
+			return nil
+		},
+	}
+}
+
+func commandPauseSchedule(name string, usage string, paused bool) *cli.Command {
+	return &cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: "[name]",
+		Action: func(ctx *cli.Context) error {
+			scheduleName, err := promptScheduleName(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.SetSchedulePaused(app.Context.WalletInstance.Id, scheduleName, paused)
+			if err != nil {
+				return err
+			}
+
+			if paused {
+				fmt.Printf("Schedule [%s] paused.\n", scheduleName)
+			} else {
+				fmt.Printf("Schedule [%s] resumed - runs missed while paused are skipped.\n", scheduleName)
+			}
+
+			return nil
+		},
+	}
+}
+
+func CommandRemoveSchedule() *cli.Command {
+	return &cli.Command{
+		Name:      "remove",
+		Aliases:   []string{"r"},
+		Usage:     "Remove a scheduled transfer and its run history",
+		ArgsUsage: "[name]",
+		Action: func(ctx *cli.Context) error {
+			name, err := promptScheduleName(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.DelSchedule(app.Context.WalletInstance.Id, name)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Schedule [%s] removed.\n", name)
+			return nil
+		},
+	}
+}
+
+func displayScheduleRuns(runs []*app.ScheduleRun) {
+	app.Context.DisplayTable(len(runs), func(i int) []interface{} {
+		r := runs[i]
+		return []interface{}{
+			r.ScheduleName, formatScheduleTime(r.ScheduledTime), formatScheduleTime(r.RunTime), r.Status, formatAssetAmount(r.SCID, r.Amount), r.TxId, r.Err,
+		}
+	}, []interface{}{"Schedule", "Scheduled", "Run", "Status", "Amount", "TXID", "Error"}, 25)
+}
+
+func CommandScheduleHistory() *cli.Command {
+	return &cli.Command{
+		Name:      "history",
+		Usage:     "Show the runs of scheduled transfers",
+		ArgsUsage: "[name]",
+		Action: func(ctx *cli.Context) error {
+			runs, err := app.Context.GetScheduleRuns(app.Context.WalletInstance.Id, ctx.Args().First())
+			if err != nil {
+				return err
+			}
+
+			displayScheduleRuns(runs)
+			return nil
+		},
+	}
+}
+
+func CommandRunSchedules() *cli.Command {
+	return &cli.Command{
+		Name:  "run",
+		Usage: "Execute the due scheduled transfers now - for batch mode",
+		Action: func(ctx *cli.Context) error {
+			runs, err := app.Context.WalletInstance.Scheduler().RunDue()
+			if len(runs) == 0 && err == nil {
+				fmt.Println("No scheduled transfer due.")
+				return nil
+			}
+
+			displayScheduleRuns(runs)
+			return err
+		},
+	}
+}
+
+func ScheduleCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "schedule",
+		Aliases:            []string{"sch"},
+		Usage:              "Recurring transfers executed while derosphere or serve is running",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandAddSchedule(),
+			CommandListSchedules(),
+			commandPauseSchedule("pause", "Pause a scheduled transfer", true),
+			commandPauseSchedule("resume", "Resume a paused scheduled transfer", false),
+			CommandRemoveSchedule(),
+			CommandScheduleHistory(),
+			CommandRunSchedules(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+var ErrCloseWallet = errors.New("close the wallet first")
+
+func promptNewMasterPassword() (string, error) {
+	password, err := app.PromptPassword("Enter new master password")
+	if err != nil {
+		return "", err
+	}
+
+	if password == "" {
+		return "", errors.New("master password can't be empty")
+	}
+
+	confirm, err := app.PromptPassword("Confirm new master password")
+	if err != nil {
+		return "", err
+	}
+
+	if password != confirm {
+		return "", errors.New("passwords don't match")
+	}
+
+	return password, nil
+}
+
+func promptCurrentMasterPassword() error {
+	password, err := app.PromptPassword("Enter current master password")
+	if err != nil {
+		return err
+	}
+
+	return app.Context.CheckMasterPassword(password)
+}
+
+func CommandSetMasterPassword() *cli.Command {
+	return &cli.Command{
+		Name:    "set-master-password",
+		Aliases: []string{"smp"},
+		Usage:   "Encrypt the config and the database of every env with a master password - also changes the current one",
+		Action: func(ctx *cli.Context) error {
+			if app.Context.WalletInstance != nil {
+				return ErrCloseWallet
+			}
+
+			if app.Context.HasMasterPassword() {
+				err := promptCurrentMasterPassword()
+				if err != nil {
+					return err
+				}
+			}
+
+			password, err := promptNewMasterPassword()
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.SetMasterPassword(password)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Master password set. Config and databases are encrypted.")
+			return nil
+		},
+	}
+}
+
+func CommandRemoveMasterPassword() *cli.Command {
+	return &cli.Command{
+		Name:    "remove-master-password",
+		Aliases: []string{"rmp"},
+		Usage:   "Decrypt the config and the database of every env",
+		Action: func(ctx *cli.Context) error {
+			if app.Context.WalletInstance != nil {
+				return ErrCloseWallet
+			}
+
+			if !app.Context.HasMasterPassword() {
+				return app.ErrMasterPasswordNotSet
+			}
+
+			err := promptCurrentMasterPassword()
+			if err != nil {
+				return err
+			}
+
+			yes, err := app.PromptYesNo("Config and databases will be stored in plain files. Continue?", false)
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				return nil
+			}
+
+			err = app.Context.RemoveMasterPassword()
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Master password removed.")
+			return nil
+		},
+	}
+}
+
+func SecurityCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "security",
+		Usage:              "Master password encrypting the config and databases",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandSetMasterPassword(),
+			CommandRemoveMasterPassword(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/rpc_server"
+	"github.com/urfave/cli/v2"
+)
+
+func CommandServe() *cli.Command {
+	return &cli.Command{
+		Name:  "serve",
+		Usage: "Start local JSON-RPC server (POST /json_rpc) - stop with Ctrl+C",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "address",
+				Usage: "Listen address",
+				Value: "127.0.0.1:20500",
+			},
+			&cli.StringFlag{
+				Name:    "token",
+				Usage:   "Bearer token required by clients - random if empty",
+				EnvVars: []string{"DEROSPHERE_RPC_TOKEN"},
+			},
+			&cli.StringSliceFlag{
+				Name:  "allow",
+				Usage: "Allowed methods or * for all - default to read only methods",
+				Value: cli.NewStringSlice(rpc_server.DEFAULT_ALLOW...),
+			},
+			&cli.StringFlag{
+				Name:  "confirm",
+				Usage: fmt.Sprintf("Confirm policy of methods moving funds (%s)", strings.Join(rpc_server.CONFIRM_POLICIES, ", ")),
+				Value: rpc_server.CONFIRM_PROMPT,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			token := ctx.String("token")
+			if token == "" {
+				buf := make([]byte, 32)
+				_, err := rand.Read(buf)
+				if err != nil {
+					return err
+				}
+
+				token = hex.EncodeToString(buf)
+				fmt.Printf("Token: %s\n", token)
+			}
+
+			server := &rpc_server.Server{
+				Address: ctx.String("address"),
+				Token:   token,
+				Allow:   ctx.StringSlice("allow"),
+				Confirm: ctx.String("confirm"),
+			}
+
+			err := server.Start()
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("JSON-RPC server listening on http://%s/json_rpc\n", server.Address)
+			fmt.Printf("Allowed methods: %s\n", strings.Join(server.Names(), ", "))
+			// scheduled transfers of the open wallet are executed while serving, even in batch mode
+			if app.Context.WalletInstance != nil && app.Context.WalletInstance.Scheduler() != nil {
+				scheduler := app.Context.WalletInstance.Scheduler()
+				if scheduler.Started() {
+					scheduler.SetPrintRuns(true)
+					defer scheduler.SetPrintRuns(false)
+				} else {
+					scheduler.Start(true)
+					defer scheduler.Stop()
+				}
+
+				fmt.Println("Scheduled transfers are executed while the server is running.")
+			}
+
+			fmt.Println("Press Ctrl+C to stop.")
+
+			stop := make(chan os.Signal, 1)
+			signal.Notify(stop, os.Interrupt)
+			<-stop
+			signal.Stop(stop)
+
+			fmt.Println("JSON-RPC server stopped.")
+			return server.Stop()
+		},
+	}
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func promptSession(ctx *cli.Context) (*app.WalletInstance, error) {
+	name := ctx.Args().First()
+	if name == "" {
+		var err error
+		name, err = app.Prompt("Enter wallet name", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, walletInstance := app.Context.GetWalletInstance(name)
+	if walletInstance == nil {
+		return nil, fmt.Errorf("wallet [%s] does not exists", name)
+	}
+
+	return walletInstance, nil
+}
+
+func sessionHeights(w *app.WalletInstance) string {
+	daemonHeight, err := w.Daemon.GetHeight()
+	if err != nil {
+		return "offline"
+	}
+
+	walletHeight, err := w.GetHeight()
+	if err != nil {
+		return "offline"
+	}
+
+	return fmt.Sprintf("%d/%d", walletHeight, daemonHeight.Height)
+}
+
+func CommandSessionList() *cli.Command {
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Usage:   "Wallets open at the same time - * is the one in focus",
+		Action: func(ctx *cli.Context) error {
+			sessions := app.Context.Sessions()
+			app.Context.DisplayTable(len(sessions), func(i int) []interface{} {
+				w := sessions[i]
+				focus := ""
+				if w == app.Context.WalletInstance {
+					focus = "*"
+				}
+
+				sync := ""
+				if syncWorker := w.SyncWorker(); syncWorker != nil {
+					sync = syncWorker.PromptStatus("")
+				}
+
+				txs := ""
+				if txTracker := w.TxTracker(); txTracker != nil {
+					txs = txTracker.PromptStatus()
+				}
+
+				return []interface{}{
+					focus, w.Name, w.Daemon.Address, w.GetConnectionAddress(), sessionHeights(w), sync, txs,
+				}
+			}, []interface{}{"", "Name", "Daemon", "Wallet", "Height", "Sync", "Txs"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandSessionFocus() *cli.Command {
+	return &cli.Command{
+		Name:      "focus",
+		Aliases:   []string{"f"},
+		Usage:     "Use a wallet for the next commands - opened if it has no session",
+		ArgsUsage: "[wallet name]",
+		Action: func(ctx *cli.Context) error {
+			walletInstance, err := promptSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.OpenSession(walletInstance)
+			if err != nil {
+				return err
+			}
+
+			app.Context.FocusSession(walletInstance)
+			if app.Context.UseApp == "rootApp" {
+				app.Context.SetUseApp("walletApp", app.Context.DAppApp)
+			}
+
+			fmt.Printf("Wallet [%s] in focus.\n", walletInstance.Name)
+			return nil
+		},
+	}
+}
+
+func CommandSessionClose() *cli.Command {
+	return &cli.Command{
+		Name:      "close",
+		Aliases:   []string{"c"},
+		Usage:     "Close a wallet session",
+		ArgsUsage: "[wallet name]",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Close every session",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			if ctx.Bool("all") {
+				count := len(app.Context.Sessions())
+				app.Context.ResetRootApp()
+				fmt.Printf("%d session(s) closed.\n", count)
+				return nil
+			}
+
+			walletInstance, err := promptSession(ctx)
+			if err != nil {
+				return err
+			}
+
+			if !app.Context.IsSession(walletInstance) {
+				return fmt.Errorf("wallet [%s] is not open", walletInstance.Name)
+			}
+
+			app.Context.CloseSession(walletInstance)
+			fmt.Printf("Wallet [%s] closed.\n", walletInstance.Name)
+			return nil
+		},
+	}
+}
+
+// CommandWalletProcess serves a wallet file session using another daemon than the wallet files open - started by the app
+func CommandWalletProcess() *cli.Command {
+	return &cli.Command{
+		Name:   app.WALLET_PROCESS_COMMAND,
+		Usage:  "Serve a wallet file with wallet rpc for the derosphere process that started it",
+		Hidden: true,
+		Action: func(ctx *cli.Context) error {
+			return app.ServeWalletProcess(os.Stdin, os.Stdout)
+		},
+	}
+}
+
+func SessionCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "session",
+		Aliases:            []string{"ss"},
+		Usage:              "Wallets open at the same time - any command can target one with --wallet name",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandSessionList(),
+			CommandSessionFocus(),
+			CommandSessionClose(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,310 @@
+package cli
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deroproject/derohe/globals"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/deroproject/derohe/walletapi"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/rpc_client"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func printOfflineTx(offlineTx *app.OfflineTx) {
+	app.Context.DisplayTable(len(offlineTx.Transfers), func(i int) []interface{} {
+		t := offlineTx.Transfers[i]
+		return []interface{}{
+			i, t.SCID, t.Destination, t.Amount, t.Burn,
+		}
+	}, []interface{}{"", "SCID", "Destination", "Amount", "Burn"}, 25)
+
+	utils.PrintFields(
+		utils.Field{Name: "Signer", Value: offlineTx.Signer},
+		utils.Field{Name: "Env", Value: offlineTx.Env},
+		utils.Field{Name: "Ringsize", Value: offlineTx.Ringsize},
+		utils.Field{Name: "SC Call", Value: len(offlineTx.SC_RPC) > 0},
+		utils.Field{Name: "Fees", Value: globals.FormatMoney(offlineTx.Fees)},
+		utils.Field{Name: "Height", Value: offlineTx.Height},
+		utils.Field{Name: "Signed", Value: offlineTx.IsSigned()},
+		utils.Field{Name: "TXID", Value: offlineTx.TxId},
+	)
+}
+
+func promptOfflineTxFile(ctx *cli.Context) (string, error) {
+	filename := ctx.Args().First()
+	if filename != "" {
+		return filename, nil
+	}
+
+	return app.Prompt("Enter tx filepath", "")
+}
+
+func CommandBuildTx() *cli.Command {
+	return &cli.Command{
+		Name:    "build",
+		Aliases: []string{"b"},
+		Usage:   "Build unsigned transaction file that can be signed offline",
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
+			if walletInstance == nil {
+				return app.ErrWalletClosed
+			}
+
+			txType, err := app.PromptChoose("Transaction type", []string{"transfer", "sc-call"}, "transfer")
+			if err != nil {
+				return err
+			}
+
+			var offlineTx *app.OfflineTx
+			switch txType {
+			case "transfer":
+				transfer, ringsize, err := promptTransfer(walletInstance)
+				if err != nil {
+					return err
+				}
+
+				offlineTx, err = walletInstance.BuildOfflineTx([]rpc.Transfer{transfer}, nil, ringsize)
+				if err != nil {
+					return err
+				}
+			case "sc-call":
+				scid, entrypoint, args, transfers, ringsize, err := promptSCCall(walletInstance)
+				if err != nil {
+					return err
+				}
+
+				offlineTx, err = walletInstance.BuildOfflineTx(transfers, app.SCCallArgs(scid, entrypoint, args), ringsize)
+				if err != nil {
+					return err
+				}
+			}
+
+			filename, err := app.Prompt("Output filepath", "unsigned-tx.json")
+			if err != nil {
+				return err
+			}
+
+			err = offlineTx.Save(filename)
+			if err != nil {
+				return err
+			}
+
+			printOfflineTx(offlineTx)
+			fmt.Fprintf(os.Stderr, "Unsigned tx saved to %s\n", filename)
+			return nil
+		},
+	}
+}
+
+func CommandSignTx() *cli.Command {
+	return &cli.Command{
+		Name:    "sign",
+		Aliases: []string{"s"},
+		Usage:   "Sign transaction file with a wallet file - does not need a daemon",
+		Action: func(ctx *cli.Context) error {
+			filename, err := promptOfflineTxFile(ctx)
+			if err != nil {
+				return err
+			}
+
+			offlineTx, err := app.LoadOfflineTx(filename)
+			if err != nil {
+				return err
+			}
+
+			if offlineTx.IsSigned() {
+				return errors.New("transaction is already signed")
+			}
+
+			defaultWalletPath := ""
+			walletInstance := app.Context.WalletInstance
+			if walletInstance != nil {
+				defaultWalletPath = walletInstance.WalletPath
+			}
+
+			walletPath, err := app.Prompt("Enter wallet filepath", defaultWalletPath)
+			if err != nil {
+				return err
+			}
+
+			password, err := app.PromptPassword("Enter wallet password")
+			if err != nil {
+				return err
+			}
+
+			wallet, err := walletapi.Open_Encrypted_Wallet(walletPath, password)
+			if err != nil {
+				return err
+			}
+
+			defer wallet.Close_Encrypted_Wallet()
+			wallet.SetNetwork(globals.IsMainnet())
+
+			printOfflineTx(offlineTx)
+			yes, err := app.PromptYesNo("Are you sure you want to sign this transaction?", false)
+			if err != nil {
+				return err
+			}
+
+			if !yes {
+				return nil
+			}
+
+			err = app.SignOfflineTx(offlineTx, wallet.Wallet_Memory)
+			if err != nil {
+				return err
+			}
+
+			signedFilename, err := app.Prompt("Output filepath", filepath.Join(filepath.Dir(filename), "signed-"+filepath.Base(filename)))
+			if err != nil {
+				return err
+			}
+
+			err = offlineTx.Save(signedFilename)
+			if err != nil {
+				return err
+			}
+
+			utils.PrintValue("TXID", offlineTx.TxId)
+			fmt.Fprintf(os.Stderr, "Signed tx saved to %s\n", signedFilename)
+			return nil
+		},
+	}
+}
+
+func CommandBroadcastTx() *cli.Command {
+	return &cli.Command{
+		Name:    "broadcast",
+		Aliases: []string{"bc"},
+		Usage:   "Send signed transaction file to the daemon",
+		Action: func(ctx *cli.Context) error {
+			filename, err := promptOfflineTxFile(ctx)
+			if err != nil {
+				return err
+			}
+
+			offlineTx, err := app.LoadOfflineTx(filename)
+			if err != nil {
+				return err
+			}
+
+			walletInstance := app.Context.WalletInstance
+			var daemon *rpc_client.Daemon
+			if walletInstance != nil && walletInstance.Daemon != nil {
+				daemon = walletInstance.Daemon
+			} else {
+				daemonAddress, err := app.Prompt("Enter daemon rpc address", "")
+				if err != nil {
+					return err
+				}
+
+				daemon = new(rpc_client.Daemon)
+				daemon.SetClient(daemonAddress)
+			}
+
+			err = app.BroadcastOfflineTx(offlineTx, daemon)
+			if err != nil {
+				return err
+			}
+
+			app.Context.RecordOfflineTx(offlineTx)
+
+			if walletInstance != nil && walletInstance.TxTracker() != nil {
+				err = walletInstance.TxTracker().Track(offlineTx.TxId)
+				if err != nil {
+					return err
+				}
+
+				walletInstance.TxSent(offlineTx.TxId)
+				return nil
+			}
+
+			utils.PrintValue("TXID", offlineTx.TxId)
+			return nil
+		},
+	}
+}
+
+func CommandPendingTxs() *cli.Command {
+	return &cli.Command{
+		Name:    "pending",
+		Aliases: []string{"p"},
+		Usage:   "List broadcasted transactions waiting for confirmation",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "all",
+				Usage: "Include confirmed and failed transactions",
+			},
+			&cli.BoolFlag{
+				Name:  "clear",
+				Usage: "Remove confirmed and failed transactions from the list",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			// without an open wallet the txs of every wallet are listed as last checked
+			walletId := sql.NullInt64{}
+			walletInstance := app.Context.WalletInstance
+			if walletInstance != nil && walletInstance.TxTracker() != nil {
+				walletId = sql.NullInt64{Int64: walletInstance.Id, Valid: true}
+				err := walletInstance.TxTracker().CheckAll()
+				if err != nil {
+					return err
+				}
+			}
+
+			if ctx.Bool("clear") {
+				count, err := app.Context.ClearResolvedTxs(walletId)
+				if err != nil {
+					return err
+				}
+
+				fmt.Fprintf(os.Stderr, "%d transactions removed.\n", count)
+				return nil
+			}
+
+			txs, err := app.Context.GetPendingTxs(walletId, ctx.Bool("all"))
+			if err != nil {
+				return err
+			}
+
+			app.Context.DisplayTable(len(txs), func(i int) []interface{} {
+				p := txs[i]
+				blockHeight := ""
+				if p.BlockHeight >= 0 {
+					blockHeight = fmt.Sprint(p.BlockHeight)
+				}
+
+				return []interface{}{
+					p.WalletName.String, p.TxId, p.Status, p.BroadcastHeight, blockHeight,
+					time.Unix(p.Timestamp, 0).Local().String(), p.Err,
+				}
+			}, []interface{}{"Wallet", "TXID", "Status", "Broadcast Height", "Block Height", "Sent", "Error"}, 25)
+			return nil
+		},
+	}
+}
+
+func TxCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "tx",
+		Usage:              "Build, sign and broadcast transactions offline and follow pending transactions",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandBuildTx(),
+			CommandSignTx(),
+			CommandBroadcastTx(),
+			CommandPendingTxs(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,254 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func promptNewVaultPassphrase() (string, error) {
+	passphrase, err := app.PromptPassword("Enter new vault passphrase")
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase == "" {
+		return "", errors.New("vault passphrase can't be empty")
+	}
+
+	confirm, err := app.PromptPassword("Confirm new vault passphrase")
+	if err != nil {
+		return "", err
+	}
+
+	if passphrase != confirm {
+		return "", errors.New("passphrases don't match")
+	}
+
+	return passphrase, nil
+}
+
+func promptVaultWallet(ctx *cli.Context) (*app.WalletInstance, error) {
+	name := ctx.Args().First()
+	var err error
+	if name == "" {
+		name, err = app.Prompt("Enter wallet name", "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, walletInstance := app.Context.GetWalletInstance(name)
+	if walletInstance == nil {
+		return nil, fmt.Errorf("wallet [%s] does not exists", name)
+	}
+
+	return walletInstance, nil
+}
+
+func CommandInitVault() *cli.Command {
+	return &cli.Command{
+		Name:  "init",
+		Usage: "Create the vault storing wallet rpc credentials of this env",
+		Action: func(ctx *cli.Context) error {
+			exists, err := app.Context.VaultExists()
+			if err != nil {
+				return err
+			}
+
+			if exists {
+				return app.ErrVaultExists
+			}
+
+			passphrase, err := promptNewVaultPassphrase()
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.InitVault(passphrase)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Vault created. Wallet rpc credentials can be saved when opening a wallet.")
+			return nil
+		},
+	}
+}
+
+func CommandListCredentials() *cli.Command {
+	return &cli.Command{
+		Name:    "list",
+		Aliases: []string{"l"},
+		Usage:   "List wallets with stored credentials",
+		Action: func(ctx *cli.Context) error {
+			credentials, err := app.Context.ListCredentials()
+			if err != nil {
+				return err
+			}
+
+			app.Context.DisplayTable(len(credentials), func(i int) []interface{} {
+				c := credentials[i]
+				return []interface{}{
+					c.WalletName.String, time.Unix(c.UpdatedTimestamp, 0).Local().String(),
+				}
+			}, []interface{}{"Wallet", "Updated"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandRotateCredential() *cli.Command {
+	return &cli.Command{
+		Name:    "rotate",
+		Aliases: []string{"r"},
+		Usage:   "Replace the stored credentials of a wallet",
+		Action: func(ctx *cli.Context) error {
+			walletInstance, err := promptVaultWallet(ctx)
+			if err != nil {
+				return err
+			}
+
+			if walletInstance.WalletAddress == "" {
+				return errors.New("only rpc wallets use credentials")
+			}
+
+			username, err := app.Prompt("Enter username", "")
+			if err != nil {
+				return err
+			}
+
+			password, err := app.PromptPassword("Enter password")
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.SaveCredential(walletInstance.Id, username, password)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Credentials of %s saved.\n", walletInstance.Name)
+			return nil
+		},
+	}
+}
+
+func CommandForgetCredential() *cli.Command {
+	return &cli.Command{
+		Name:    "forget",
+		Aliases: []string{"f"},
+		Usage:   "Remove the stored credentials of a wallet",
+		Action: func(ctx *cli.Context) error {
+			walletInstance, err := promptVaultWallet(ctx)
+			if err != nil {
+				return err
+			}
+
+			deleted, err := app.Context.ForgetCredential(walletInstance.Id)
+			if err != nil {
+				return err
+			}
+
+			if !deleted {
+				fmt.Printf("No credentials stored for %s.\n", walletInstance.Name)
+				return nil
+			}
+
+			fmt.Printf("Credentials of %s removed.\n", walletInstance.Name)
+			return nil
+		},
+	}
+}
+
+func CommandChangeVaultPassphrase() *cli.Command {
+	return &cli.Command{
+		Name:  "change-passphrase",
+		Usage: "Encrypt stored credentials with a new passphrase",
+		Action: func(ctx *cli.Context) error {
+			_, err := app.Context.UnlockVault()
+			if err != nil {
+				return err
+			}
+
+			passphrase, err := promptNewVaultPassphrase()
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.ChangeVaultPassphrase(passphrase)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Vault passphrase changed.")
+			return nil
+		},
+	}
+}
+
+func CommandLockVault() *cli.Command {
+	return &cli.Command{
+		Name:  "lock",
+		Usage: "Lock the vault now - the passphrase is asked on next use",
+		Action: func(ctx *cli.Context) error {
+			app.Context.LockVault()
+			fmt.Println("Vault locked.")
+			return nil
+		},
+	}
+}
+
+func CommandSetVaultUnlockWindow() *cli.Command {
+	return &cli.Command{
+		Name:  "set-unlock-window",
+		Usage: "Seconds the vault stays unlocked after entering the passphrase. Default to 300s and 0 = ask every time.",
+		Action: func(ctx *cli.Context) error {
+			windowString := ctx.Args().First()
+			var err error = nil
+			var window uint64
+
+			if windowString != "" {
+				window, err = strconv.ParseUint(windowString, 10, 64)
+				if err != nil {
+					return err
+				}
+			} else {
+				window, err = app.PromptUInt("Enter unlock window in second", 300)
+				if err != nil {
+					return err
+				}
+			}
+
+			app.Context.SetVaultUnlockWindow(window)
+			fmt.Printf("Vault unlock window set to %ds\n", window)
+			return nil
+		},
+	}
+}
+
+func VaultCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "vault",
+		Usage:              "Encrypted wallet rpc credentials",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandInitVault(),
+			CommandListCredentials(),
+			CommandRotateCredential(),
+			CommandForgetCredential(),
+			CommandChangeVaultPassphrase(),
+			CommandLockVault(),
+			CommandSetVaultUnlockWindow(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -1,26 +1,25 @@
 package cli
 
 import (
-	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path"
 	"path/filepath"
 	"regexp"
-	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/blang/semver/v4"
 	"github.com/deroproject/derohe/cryptography/crypto"
 	"github.com/deroproject/derohe/globals"
 	"github.com/deroproject/derohe/rpc"
-	"github.com/deroproject/derohe/transaction"
 	"github.com/deroproject/derohe/walletapi"
 	"github.com/g45t345rt/derosphere/app"
-	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/commit_sync"
 	"github.com/g45t345rt/derosphere/dapps"
 	"github.com/g45t345rt/derosphere/utils"
 	"github.com/urfave/cli/v2"
@@ -33,10 +32,12 @@ func CommandWalletInfo() *cli.Command {
 		Usage:   "Wallet generic information",
 		Action: func(ctx *cli.Context) error {
 			w := app.Context.WalletInstance
-			fmt.Println("Name: ", w.Name)
-			fmt.Println("Daemon: ", w.DaemonAddress)
-			fmt.Println("Wallet: ", w.GetConnectionAddress())
-			fmt.Println("Registered: ", w.IsRegistered())
+			utils.PrintFields(
+				utils.Field{Name: "Name", Value: w.Name},
+				utils.Field{Name: "Daemon", Value: w.DaemonAddress},
+				utils.Field{Name: "Wallet", Value: w.GetConnectionAddress()},
+				utils.Field{Name: "Registered", Value: w.IsRegistered()},
+			)
 			return nil
 		},
 	}
@@ -52,14 +53,15 @@ func CommandDaemonInfo() *cli.Command {
 
 			result, err := w.Daemon.GetInfo()
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			fmt.Println("Height: ", result.Height)
-			fmt.Println("Testnet: ", result.Testnet)
-			fmt.Println("Network: ", result.Network)
-			fmt.Println("Version: ", result.Version)
+			utils.PrintFields(
+				utils.Field{Name: "Height", Value: result.Height},
+				utils.Field{Name: "Testnet", Value: result.Testnet},
+				utils.Field{Name: "Network", Value: result.Network},
+				utils.Field{Name: "Version", Value: result.Version},
+			)
 			return nil
 		},
 	}
@@ -70,6 +72,7 @@ func CommandRegisterWallet() *cli.Command {
 		Name:    "register",
 		Aliases: []string{"r"},
 		Usage:   "Register wallet with blockchain (can take up to 2 hours - POW anti-spam)",
+		Before:  requireSpendKey,
 		Action: func(ctx *cli.Context) error {
 			w := app.Context.WalletInstance
 
@@ -78,49 +81,9 @@ func CommandRegisterWallet() *cli.Command {
 				return nil
 			}
 
-			if w.WalletDisk == nil {
-				fmt.Println("Can't register from rpc wallet yet. TODO")
-				return nil
-			}
-
-			wallet := w.WalletDisk
-
-			fmt.Println("Please wait while the app solves the POW to register the new wallet...")
-			fmt.Println("Can take a few hours!")
-
-			var regTx *transaction.Transaction
-			chanTx := make(chan *transaction.Transaction)
-
-			counter := 0
-			found := false // need this to cancel other parallel loop
-			maxThreads := runtime.GOMAXPROCS(0)
-			fmt.Printf("Using %d threads\n", maxThreads)
-
-			for i := 0; i < maxThreads; i++ {
-				go func() {
-					for !found {
-						tempTx := wallet.GetRegistrationTX()
-						hash := tempTx.GetHash()
-
-						if hash[0] == 0 && hash[1] == 0 && hash[2] == 0 {
-							chanTx <- tempTx
-							found = true
-							break
-						}
-
-						counter++
-						fmt.Printf("%d tries\r", counter)
-					}
-				}()
-			}
-
-			regTx = <-chanTx
-			fmt.Println("Valid registration tx found!")
-			fmt.Println("Sending transaction to blockchain...")
-			err := wallet.SendTransaction(regTx)
+			err := w.Register()
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println("Your wallet was succesfully registered.")
@@ -131,11 +94,17 @@ func CommandRegisterWallet() *cli.Command {
 
 func CommandWalletSeed() *cli.Command {
 	return &cli.Command{
-		Name:  "seed",
-		Usage: "Display wallet seed",
+		Name:   "seed",
+		Usage:  "Display wallet seed",
+		Before: requireSpendKey,
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
-			fmt.Println(walletInstance.GetSeed())
+			seed, err := walletInstance.GetSeed()
+			if err != nil {
+				return err
+			}
+
+			utils.PrintValue("Seed", seed)
 			return nil
 		},
 	}
@@ -144,7 +113,7 @@ func CommandWalletSeed() *cli.Command {
 func CommandSwitchWallet() *cli.Command {
 	return &cli.Command{
 		Name:    "switch",
-		Usage:   "Quickly change to another wallet",
+		Usage:   "Quickly change to another wallet - the current one stays open",
 		Aliases: []string{"s"},
 		Action: func(ctx *cli.Context) error {
 			return OpenWalletAction(ctx, "")
@@ -197,8 +166,8 @@ func CommandOpenDApp() *cli.Command {
 		setAppName:
 			if dappName == "" {
 				dappName, err = app.Prompt("Enter app name/index", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -214,13 +183,16 @@ func CommandOpenDApp() *cli.Command {
 			}
 
 			if dapp == nil {
-				fmt.Println("App does not exists.")
+				err = retryPrompt("App does not exists.")
+				if err != nil {
+					return err
+				}
+
 				dappName = ""
 				goto setAppName
 			}
 
-			app.Context.DAppApp = DAppApp(dapp)
-			app.Context.UseApp = "dappApp"
+			app.Context.SetUseApp("dappApp", DAppApp(dapp))
 
 			return nil
 		},
@@ -233,8 +205,7 @@ func CommandDAppBack() *cli.Command {
 		Usage:   "Back to wallet",
 		Aliases: []string{"b"},
 		Action: func(ctx *cli.Context) error {
-			app.Context.DAppApp = nil
-			app.Context.UseApp = "walletApp"
+			app.Context.SetUseApp("walletApp", nil)
 			return nil
 		},
 	}
@@ -260,11 +231,9 @@ func CommandCloseWallet() *cli.Command {
 	return &cli.Command{
 		Name:    "close",
 		Aliases: []string{"c"},
-		Usage:   "Close wallet",
+		Usage:   "Close wallet - the focus goes to the last opened session left",
 		Action: func(ctx *cli.Context) error {
-			app.Context.WalletInstance.Close()
-			app.Context.WalletInstance = nil
-			app.Context.UseApp = "rootApp"
+			app.Context.CloseSession(app.Context.WalletInstance)
 			return nil
 		},
 	}
@@ -278,11 +247,10 @@ func CommandWalletAddress() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			addr, err := app.Context.WalletInstance.GetAddress()
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			fmt.Println(addr)
+			utils.PrintValue("Address", addr)
 			return nil
 		},
 	}
@@ -297,11 +265,10 @@ func CommandWalletBalance() *cli.Command {
 			var scid crypto.Hash // default DERO scid
 			balance, err := app.Context.WalletInstance.GetBalance(scid)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			fmt.Printf("%s\n", globals.FormatMoney(balance))
+			utils.PrintValue("Balance", globals.FormatMoney(balance))
 			return nil
 		},
 	}
@@ -319,53 +286,191 @@ func CommandDisplayTransaction() *cli.Command {
 
 			if txId == "" {
 				txId, err = app.Prompt("Enter txid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
-			result, err := walletInstance.Daemon.GetTransaction(&rpc.GetTransaction_Params{
-				Tx_Hashes: []string{txId},
-			})
-
+			inspection, err := walletInstance.InspectTx(txId)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			var tx transaction.Transaction
-			tx_bin, _ := hex.DecodeString(result.Txs_as_hex[0])
-			tx.Deserialize(tx_bin)
-
-			fmt.Println(tx.Height)
-			fmt.Println(tx)
+			printTxInspection(inspection)
 			return nil
 		},
 	}
 }
 
+// requireSpendKey refuses the command early on a watch-only wallet
+func requireSpendKey(ctx *cli.Context) error {
+	walletInstance := app.Context.WalletInstance
+	if walletInstance == nil {
+		return nil
+	}
+
+	return walletInstance.CheckSpend()
+}
+
+func formatAssetAmount(scid crypto.Hash, amount uint64) string {
+	if scid.IsZero() {
+		return globals.FormatMoney(amount)
+	}
+
+	return fmt.Sprint(amount)
+}
+
+func assetName(scid crypto.Hash) string {
+	if scid.IsZero() {
+		return "DERO"
+	}
+
+	return scid.String()
+}
+
+func txStatus(inspection *app.TxInspection) string {
+	switch {
+	case inspection.ValidBlock != "":
+		return "confirmed"
+	case inspection.InPool:
+		return "in pool"
+	default:
+		return "invalid"
+	}
+}
+
+func txPayloadFields(p app.TxPayload) []utils.Field {
+	fields := []utils.Field{
+		{Name: "Index", Value: p.Index},
+		{Name: "SCID", Value: p.SCID.String()},
+		{Name: "Burn", Value: formatAssetAmount(p.SCID, p.Burn)},
+		{Name: "Ring Size", Value: p.RingSize},
+	}
+
+	if p.Entry == nil {
+		return fields
+	}
+
+	direction := "outgoing"
+	counterparty := p.Entry.Destination
+	if p.Entry.Incoming {
+		direction = "incoming"
+		counterparty = p.Entry.Sender
+	}
+
+	comment, _ := p.Entry.Payload_RPC.Value(rpc.RPC_COMMENT, rpc.DataString).(string)
+	return append(fields,
+		utils.Field{Name: "Direction", Value: direction},
+		utils.Field{Name: "Amount", Value: formatAssetAmount(p.SCID, p.Entry.Amount)},
+		utils.Field{Name: "Counterparty", Value: counterparty},
+		utils.Field{Name: "Comment", Value: comment},
+		utils.Field{Name: "Destination Port", Value: p.Entry.DestinationPort},
+	)
+}
+
+func scArgValue(arg rpc.Argument) string {
+	if arg.Name == rpc.SCCODE && utils.Output == utils.OUTPUT_TABLE {
+		return fmt.Sprintf("(%d chars of code)", len(fmt.Sprint(arg.Value)))
+	}
+
+	return fmt.Sprint(arg.Value)
+}
+
+func printTxInspection(inspection *app.TxInspection) {
+	fields := []utils.Field{
+		{Name: "TXID", Value: inspection.TxId},
+		{Name: "Type", Value: inspection.Type},
+		{Name: "Status", Value: txStatus(inspection)},
+		{Name: "Signer", Value: inspection.Signer},
+		{Name: "Block Height", Value: inspection.BlockHeight},
+		{Name: "Topoheight", Value: inspection.TopoHeight},
+		{Name: "Confirmations", Value: inspection.Confirmations},
+		{Name: "Build Height", Value: inspection.BuildHeight},
+		{Name: "Fees", Value: globals.FormatMoney(inspection.Fees)},
+	}
+
+	if len(inspection.InvalidBlocks) > 0 {
+		fields = append(fields, utils.Field{Name: "Invalid Blocks", Value: strings.Join(inspection.InvalidBlocks, ", ")})
+	}
+
+	if h := inspection.History; h != nil {
+		fields = append(fields,
+			utils.Field{Name: "Command", Value: h.Command},
+			utils.Field{Name: "DApp", Value: h.DApp},
+			utils.Field{Name: "Note", Value: h.Note},
+		)
+	}
+
+	scArgs := []utils.Record{}
+	for _, arg := range inspection.SCArgs {
+		scArgs = append(scArgs, utils.Record{
+			{Name: "Name", Value: arg.Name},
+			{Name: "Type", Value: arg.DataType.String()},
+			{Name: "Value", Value: scArgValue(arg)},
+		})
+	}
+
+	// json keeps everything in one object
+	if utils.Output == utils.OUTPUT_JSON {
+		payloads := []utils.Record{}
+		for _, p := range inspection.Payloads {
+			payload := utils.Record(txPayloadFields(p))
+			payload = append(payload, utils.Field{Name: "Ring", Value: p.Ring})
+			payloads = append(payloads, payload)
+		}
+
+		fields = append(fields,
+			utils.Field{Name: "Payloads", Value: payloads},
+			utils.Field{Name: "SC Args", Value: scArgs},
+		)
+
+		utils.PrintFields(fields...)
+		return
+	}
+
+	utils.PrintFields(fields...)
+
+	for _, p := range inspection.Payloads {
+		fmt.Println()
+		utils.PrintFields(txPayloadFields(p)...)
+
+		ring := p.Ring
+		app.Context.DisplayTable(len(ring), func(i int) []interface{} {
+			return []interface{}{i, ring[i]}
+		}, []interface{}{"", "Ring Member"}, 25)
+	}
+
+	if len(scArgs) > 0 {
+		fmt.Println()
+		app.Context.DisplayTable(len(scArgs), func(i int) []interface{} {
+			return []interface{}{scArgs[i][0].Value, scArgs[i][1].Value, scArgs[i][2].Value}
+		}, []interface{}{"Name", "Type", "Value"}, 25)
+	}
+}
+
 func CommandWalletBurn() *cli.Command {
 	return &cli.Command{
 		Name:    "burn",
 		Aliases: []string{"bu"},
 		Usage:   "Burn DERO/ASSET_TOKEN",
+		Before:  requireSpendKey,
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 			assetToken, err := app.Prompt("Enter asset token (empty for burning DERO)", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			burnAmount := uint64(0)
 			if assetToken == "" {
 				burnAmount, err = app.PromptDero("Enter burn amount (in Dero)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			} else {
 				burnAmount, err = app.PromptUInt("Enter burn amount (atomic value)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -375,8 +480,8 @@ func CommandWalletBurn() *cli.Command {
 			}
 
 			ringsize, err := app.PromptUInt("Set ringsize", 2)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			prompt := ""
@@ -387,8 +492,8 @@ func CommandWalletBurn() *cli.Command {
 			}
 
 			yes, err := app.PromptYesNo(prompt, false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if !yes {
@@ -396,8 +501,8 @@ func CommandWalletBurn() *cli.Command {
 			}
 
 			yes, err = app.PromptYesNo("The funds will literally burn like it never existed! Are your really sure?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if !yes {
@@ -412,119 +517,122 @@ func CommandWalletBurn() *cli.Command {
 			})
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txid)
+			walletInstance.TxSent(txid)
 			return nil
 		},
 	}
 }
 
-func CommandWalletTransfer() *cli.Command {
-	return &cli.Command{
-		Name:    "transfer",
-		Aliases: []string{"t"},
-		Usage:   "Transfer DERO/ASSET_TOKEN to another address",
-		Action: func(ctx *cli.Context) error {
-
-			walletInstance := app.Context.WalletInstance
+func promptTransfer(walletInstance *app.WalletInstance) (transfer rpc.Transfer, ringsize uint64, err error) {
+	assetToken, err := app.Prompt("Enter asset token (empty for sending DERO)", "")
+	if err != nil {
+		return transfer, 0, err
+	}
 
-			assetToken, err := app.Prompt("Enter asset token (empty for sending DERO)", "")
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+	resolved, err := app.PromptAddress("Enter address/name/contact")
+	if err != nil {
+		return transfer, 0, err
+	}
 
-			addressOrName, err := app.Prompt("Enter address/name", "")
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+	amount := uint64(0)
+	if assetToken == "" {
+		amount, err = app.PromptDero("Enter amount (in Dero)", 0)
+		if err != nil {
+			return transfer, 0, err
+		}
+	} else {
+		amount, err = app.PromptUInt("Enter amount (atomic value)", 0)
+		if err != nil {
+			return transfer, 0, err
+		}
+	}
 
-			_, err = globals.ParseValidateAddress(addressOrName)
-			if err != nil {
-				result, err := walletInstance.Daemon.NameToAddress(&rpc.NameToAddress_Params{
-					Name: addressOrName,
-				})
+	ringsize, err = app.PromptUInt("Set ringsize", 2)
+	if err != nil {
+		return transfer, 0, err
+	}
 
-				if err != nil {
-					fmt.Println(err)
-					return nil
-				}
+	transfer = rpc.Transfer{
+		SCID:        crypto.HashHexToHash(assetToken),
+		Destination: resolved.Address,
+		Amount:      amount,
+	}
 
-				addressOrName = result.Address
-				fmt.Printf("Address found: %s\n", addressOrName)
-			}
+	arguments := rpc.Arguments{}
 
-			amount := uint64(0)
-			if assetToken == "" {
-				amount, err = app.PromptDero("Enter amount (in Dero)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
-				}
-			} else {
-				amount, err = app.PromptUInt("Enter amount (atomic value)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
-				}
-			}
+	// a contact sets the default comment and destination port
+	defaultComment := ""
+	defaultPortNumber := ""
+	if resolved.Contact != nil {
+		defaultComment = resolved.Contact.Comment
+		if resolved.Contact.DestinationPort.Valid {
+			defaultPortNumber = fmt.Sprint(resolved.Contact.DestinationPort.Int64)
+		}
+	}
 
-			ringsize, err := app.PromptUInt("Set ringsize", 2)
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+	comment, err := app.Prompt("Comment", defaultComment)
+	if err != nil {
+		return transfer, 0, err
+	}
 
-			transfer := rpc.Transfer{
-				SCID:        crypto.HashHexToHash(assetToken),
-				Destination: addressOrName,
-				Amount:      amount,
-			}
+	if comment != "" {
+		arguments = append(arguments, rpc.Argument{
+			Name:     rpc.RPC_COMMENT,
+			DataType: rpc.DataString,
+			Value:    comment,
+		})
+	}
 
-			arguments := rpc.Arguments{}
+	sPortNumber, err := app.Prompt("Destination port number", defaultPortNumber)
+	if err != nil {
+		return transfer, 0, err
+	}
 
-			comment, err := app.Prompt("Comment", "")
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+	if sPortNumber != "" {
+		portNumber, err := strconv.ParseUint(sPortNumber, 10, 64)
+		if err != nil {
+			return transfer, 0, err
+		}
+
+		arguments = append(arguments, rpc.Argument{
+			Name:     rpc.RPC_DESTINATION_PORT,
+			DataType: rpc.DataUint64,
+			Value:    portNumber,
+		})
+	}
 
-			if comment != "" {
-				arguments = append(arguments, rpc.Argument{
-					Name:     rpc.RPC_COMMENT,
-					DataType: rpc.DataString,
-					Value:    comment,
-				})
-			}
+	transfer.Payload_RPC = arguments
+	return transfer, ringsize, nil
+}
 
-			sPortNumber, err := app.Prompt("Destination port number", "")
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+func CommandWalletTransfer() *cli.Command {
+	return &cli.Command{
+		Name:    "transfer",
+		Aliases: []string{"t"},
+		Usage:   "Transfer DERO/ASSET_TOKEN to another address",
+		Before:  requireSpendKey,
+		Action: func(ctx *cli.Context) error {
 
-			if sPortNumber != "" {
-				portNumber, err := strconv.ParseUint(sPortNumber, 10, 64)
-				if err != nil {
-					fmt.Println(err)
-				}
+			walletInstance := app.Context.WalletInstance
 
-				arguments = append(arguments, rpc.Argument{
-					Name:     rpc.RPC_DESTINATION_PORT,
-					DataType: rpc.DataUint64,
-					Value:    portNumber,
-				})
+			transfer, ringsize, err := promptTransfer(walletInstance)
+			if err != nil {
+				return err
 			}
 
-			transfer.Payload_RPC = arguments
-
 			prompt := ""
-			if assetToken == "" {
-				prompt = fmt.Sprintf("Are you sure you want to send %s DERO to %s", globals.FormatMoney(transfer.Amount), addressOrName)
+			if transfer.SCID.IsZero() {
+				prompt = fmt.Sprintf("Are you sure you want to send %s DERO to %s", globals.FormatMoney(transfer.Amount), transfer.Destination)
 			} else {
-				prompt = fmt.Sprintf("Are you sure you want to send %d TOKEN to %s", transfer.Amount, addressOrName)
+				prompt = fmt.Sprintf("Are you sure you want to send %d TOKEN to %s", transfer.Amount, transfer.Destination)
 			}
 
 			yes, err := app.PromptYesNo(prompt, false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if !yes {
@@ -539,11 +647,10 @@ func CommandWalletTransfer() *cli.Command {
 			})
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txid)
+			walletInstance.TxSent(txid)
 			return nil
 		},
 	}
@@ -554,25 +661,24 @@ func CommandWalletTransferFromFile() *cli.Command {
 		Name:    "transfer-from-file",
 		Aliases: []string{"tff"},
 		Usage:   "Transfer from json file",
+		Before:  requireSpendKey,
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 
 			transferFilePath, err := app.Prompt("Filepath", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			content, err := ioutil.ReadFile(transferFilePath)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			var transfer *rpc.Transfer_Params
 			err = json.Unmarshal(content, &transfer)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			sums := make(map[string]uint64)
@@ -583,20 +689,17 @@ func CommandWalletTransferFromFile() *cli.Command {
 				addr := t.Destination
 
 				valid := false
-				if !strings.HasPrefix(addr, "dero") {
-					result, err := walletInstance.Daemon.NameToAddress(&rpc.NameToAddress_Params{
-						Name: addr,
+				resolved, err := app.Context.ResolveAddress(walletInstance.Daemon, addr)
+				if err != nil {
+					fmt.Println(ti, addr, err)
+					invalidAddrs = append(invalidAddrs, map[string]interface{}{
+						"addr": addr,
+						"err":  err,
 					})
-					if err != nil {
-						fmt.Println(ti, addr, err)
-						invalidAddrs = append(invalidAddrs, map[string]interface{}{
-							"addr": addr,
-							"err":  err,
-						})
-					} else {
-						valid = true
-						fmt.Println(ti, addr, "->", result.Address)
-					}
+				} else if resolved.Source != app.ADDRESS_SOURCE_RAW {
+					valid = true
+					t.Destination = resolved.Address
+					fmt.Println(ti, addr, "->", resolved.Address, fmt.Sprintf("(%s)", resolved.Source))
 				} else {
 					_, err = walletInstance.Daemon.GetEncrypedBalance(&rpc.GetEncryptedBalance_Params{
 						Address:    t.Destination,
@@ -638,27 +741,23 @@ func CommandWalletTransferFromFile() *cli.Command {
 			if invalidAddrs != nil {
 				invalidAddrBytes, err := json.MarshalIndent(invalidAddrs, "", "\t")
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				err = ioutil.WriteFile(path.Join(folder, fmt.Sprintf("invalid-%s", name)), invalidAddrBytes, os.ModePerm)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			}
 
 			validTransferBytes, err := json.MarshalIndent(transfer, "", "\t")
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			err = ioutil.WriteFile(path.Join(folder, fmt.Sprintf("valid-%s", name)), validTransferBytes, os.ModePerm)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(len(invalidAddrs), "invalid addrs")
@@ -671,11 +770,10 @@ func CommandWalletTransferFromFile() *cli.Command {
 				txid, err := walletInstance.Transfer(transfer)
 
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
-				walletInstance.RunTxChecker(txid)
+				walletInstance.TxSent(txid)
 			}
 
 			return nil
@@ -688,6 +786,28 @@ func CommandWalletTransactions() *cli.Command {
 		Name:    "transactions",
 		Aliases: []string{"txs"},
 		Usage:   "Show transaction history",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "dapp",
+				Usage: "Only txs created by this dapp",
+			},
+			&cli.StringFlag{
+				Name:  "entrypoint",
+				Usage: "Only smart contract calls of this function",
+			},
+			&cli.TimestampFlag{
+				Name:     "from",
+				Usage:    "Only txs since this date (2006-01-02)",
+				Layout:   "2006-01-02",
+				Timezone: time.Local,
+			},
+			&cli.TimestampFlag{
+				Name:     "to",
+				Usage:    "Only txs until this date included (2006-01-02)",
+				Layout:   "2006-01-02",
+				Timezone: time.Local,
+			},
+		},
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 
@@ -704,17 +824,89 @@ func CommandWalletTransactions() *cli.Command {
 			})
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
+			}
+
+			history, err := app.Context.GetTxHistory(walletInstance.Id)
+			if err != nil {
+				return err
+			}
+
+			dapp := ctx.String("dapp")
+			entrypoint := ctx.String("entrypoint")
+			from := ctx.Timestamp("from")
+			to := ctx.Timestamp("to")
+			if to != nil {
+				end := to.AddDate(0, 0, 1)
+				to = &end
+			}
+
+			var filtered []rpc.Entry
+			for _, tx := range entries {
+				h := history[tx.TXID]
+				if h == nil {
+					h = &app.TxHistory{}
+				}
+
+				if (dapp != "" && h.DApp != dapp) || (entrypoint != "" && h.Entrypoint != entrypoint) {
+					continue
+				}
+
+				if (from != nil && tx.Time.Before(*from)) || (to != nil && !tx.Time.Before(*to)) {
+					continue
+				}
+
+				filtered = append(filtered, tx)
 			}
 
-			app.Context.DisplayTable(len(entries), func(i int) []interface{} {
-				tx := entries[i]
+			app.Context.DisplayTable(len(filtered), func(i int) []interface{} {
+				tx := filtered[i]
+				h := history[tx.TXID]
+				if h == nil {
+					h = &app.TxHistory{}
+				}
+
 				return []interface{}{
 					i, globals.FormatMoney(tx.Amount), globals.FormatMoney(tx.Burn), globals.FormatMoney(tx.Fees), tx.Time,
-					tx.Height, tx.Destination, tx.Coinbase, tx.TXID,
+					tx.Height, tx.Destination, tx.Coinbase, tx.TXID, h.DApp, h.Entrypoint, h.Note,
+				}
+			}, []interface{}{"", "Amount", "Burn", "Fees", "Time", "Height", "Destination", "Coinbase", "TXID", "DApp", "Entrypoint", "Note"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandTxNote() *cli.Command {
+	return &cli.Command{
+		Name:    "tx-note",
+		Aliases: []string{"txn"},
+		Usage:   "Add or replace the note of a transaction",
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
+			txId := ctx.Args().Get(0)
+			note := strings.Join(ctx.Args().Tail(), " ")
+			var err error
+
+			if txId == "" {
+				txId, err = app.Prompt("Enter txid", "")
+				if err != nil {
+					return err
 				}
-			}, []interface{}{"", "Amount", "Burn", "Fees", "Time", "Height", "Destination", "Coinbase", "TXID"}, 25)
+			}
+
+			if note == "" {
+				note, err = app.Prompt("Enter note", "")
+				if err != nil {
+					return err
+				}
+			}
+
+			err = app.Context.SetTxNote(walletInstance.Id, txId, note)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println("Transaction note saved.")
 			return nil
 		},
 	}
@@ -725,26 +917,25 @@ func CommandInstallSC() *cli.Command {
 		Name:    "install",
 		Aliases: []string{"i"},
 		Usage:   "Install smart contract",
+		Before:  requireSpendKey,
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 			codeFilePath, err := app.Prompt("Enter code filepath", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			code, err := ioutil.ReadFile(codeFilePath)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			txId, err := walletInstance.InstallSmartContract(code, 2, []rpc.Argument{}, true)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -755,23 +946,23 @@ func CommandUpdateSC() *cli.Command {
 		Name:    "update",
 		Aliases: []string{"u"},
 		Usage:   "Update smart contract",
+		Before:  requireSpendKey,
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			codeFilePath, err := app.Prompt("Enter new code filepath", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			code, err := ioutil.ReadFile(codeFilePath)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			codeString := string(code)
@@ -780,11 +971,10 @@ func CommandUpdateSC() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -800,166 +990,169 @@ type SCFuncArg struct {
 	Type string
 }
 
-func CommandCallSC() *cli.Command {
-	return &cli.Command{
-		Name:    "call",
-		Aliases: []string{"c"},
-		Usage:   "Call smart contract function",
-		Action: func(ctx *cli.Context) error {
-			walletInstance := app.Context.WalletInstance
+func promptSCCall(walletInstance *app.WalletInstance) (scid string, entrypoint string, args []rpc.Argument, transfers []rpc.Transfer, ringsize uint64, err error) {
+	scid, err = app.Prompt("Enter scid", "")
+	if err != nil {
+		return "", "", nil, nil, 0, err
+	}
 
-			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+	result, err := walletInstance.Daemon.GetSC(&rpc.GetSC_Params{
+		SCID:      scid,
+		Code:      true,
+		Variables: false,
+	})
 
-			result, err := walletInstance.Daemon.GetSC(&rpc.GetSC_Params{
-				SCID:      scid,
-				Code:      true,
-				Variables: false,
-			})
+	if err != nil {
+		return "", "", nil, nil, 0, err
+	}
 
-			if err != nil {
-				fmt.Println(err)
-				return nil
-			}
+	matchFunctions, err := regexp.Compile(`Function ([A-Z]\w+)\(?(.+)\)`)
+	if err != nil {
+		return "", "", nil, nil, 0, err
+	}
 
-			matchFunctions, err := regexp.Compile(`Function ([A-Z]\w+)\(?(.+)\)`)
-			if err != nil {
-				fmt.Println(err)
-				return nil
+	funcs := make(map[string]SCFunc)
+	var funcNames []string
+
+	values := matchFunctions.FindAllStringSubmatch(result.Code, -1)
+	for _, value := range values {
+		funcName := value[1]
+		/*if funcName == "Initialize" || funcName == "PrivateInitialize" || funcName == "UpdateCode" {
+			continue
+		}*/
+
+		scFunc := SCFunc{
+			Name: funcName,
+		}
+
+		sArgs := value[2]
+		if sArgs != "(" {
+			args := strings.Split(sArgs, ",")
+
+			for _, arg := range args {
+				def := strings.Split(strings.Trim(arg, " "), " ")
+				scFunc.Args = append(scFunc.Args, SCFuncArg{
+					Name: def[0],
+					Type: def[1],
+				})
 			}
+		}
 
-			funcs := make(map[string]SCFunc)
-			var funcNames []string
+		funcs[funcName] = scFunc
+		funcNames = append(funcNames, funcName)
+	}
 
-			values := matchFunctions.FindAllStringSubmatch(result.Code, -1)
-			for _, value := range values {
-				funcName := value[1]
-				/*if funcName == "Initialize" || funcName == "PrivateInitialize" || funcName == "UpdateCode" {
-					continue
-				}*/
+	funcName, err := app.PromptChoose("Function to excute", funcNames, "")
+	//funcName, err := app.Prompt("Function to excute", "")
+	if err != nil {
+		return "", "", nil, nil, 0, err
+	}
 
-				scFunc := SCFunc{
-					Name: funcName,
-				}
+	function := funcs[funcName]
 
-				sArgs := value[2]
-				if sArgs != "(" {
-					args := strings.Split(sArgs, ",")
+	args = []rpc.Argument{}
+	for _, arg := range function.Args {
+		switch arg.Type {
+		case "Uint64":
+			valueUInt, err := app.PromptUInt(arg.Name, 0)
+			if err != nil {
+				return "", "", nil, nil, 0, err
+			}
 
-					for _, arg := range args {
-						def := strings.Split(strings.Trim(arg, " "), " ")
-						scFunc.Args = append(scFunc.Args, SCFuncArg{
-							Name: def[0],
-							Type: def[1],
-						})
-					}
-				}
+			args = append(args, rpc.Argument{
+				Name:     arg.Name,
+				DataType: rpc.DataUint64,
+				Value:    valueUInt,
+			})
+		case "String":
+			sArg := rpc.Argument{}
 
-				funcs[funcName] = scFunc
-				funcNames = append(funcNames, funcName)
+			valueString, err := app.Prompt(arg.Name, "")
+			if err != nil {
+				return "", "", nil, nil, 0, err
 			}
 
-			funcName, err := app.PromptChoose("Function to excute", funcNames, "")
-			//funcName, err := app.Prompt("Function to excute", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			isHashString, err := app.PromptYesNo(fmt.Sprintf("Is arg %s hash string?", arg.Name), false)
+			if err != nil {
+				return "", "", nil, nil, 0, err
 			}
 
-			function := funcs[funcName]
-
-			args := []rpc.Argument{}
-			for _, arg := range function.Args {
-				switch arg.Type {
-				case "Uint64":
-					valueUInt, err := app.PromptUInt(arg.Name, 0)
-					if app.HandlePromptErr(err) {
-						return nil
-					}
-
-					args = append(args, rpc.Argument{
-						Name:     arg.Name,
-						DataType: rpc.DataUint64,
-						Value:    valueUInt,
-					})
-				case "String":
-					sArg := rpc.Argument{}
-
-					valueString, err := app.Prompt(arg.Name, "")
-					if app.HandlePromptErr(err) {
-						return nil
-					}
-
-					isHashString, err := app.PromptYesNo(fmt.Sprintf("Is arg %s hash string?", arg.Name), false)
-					if app.HandlePromptErr(err) {
-						return nil
-					}
-
-					if isHashString {
-						sArg = rpc.Argument{
-							Name:     arg.Name,
-							DataType: rpc.DataHash,
-							Value:    crypto.HashHexToHash(valueString),
-						}
-					} else {
-						sArg = rpc.Argument{
-							Name:     arg.Name,
-							DataType: rpc.DataString,
-							Value:    valueString,
-						}
-					}
-
-					args = append(args, sArg)
-				default:
-					fmt.Println("Unknown arg type")
-					return nil
+			if isHashString {
+				sArg = rpc.Argument{
+					Name:     arg.Name,
+					DataType: rpc.DataHash,
+					Value:    crypto.HashHexToHash(valueString),
+				}
+			} else {
+				sArg = rpc.Argument{
+					Name:     arg.Name,
+					DataType: rpc.DataString,
+					Value:    valueString,
 				}
 			}
 
-			ringSize, err := app.PromptUInt("Ringsize", 2)
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+			args = append(args, sArg)
+		default:
+			return "", "", nil, nil, 0, fmt.Errorf("unknown arg type %s", arg.Type)
+		}
+	}
 
-			burnAssetOrDero, err := app.PromptYesNo("Burn asset or dero?", false)
-			if app.HandlePromptErr(err) {
-				return nil
-			}
+	ringsize, err = app.PromptUInt("Ringsize", 2)
+	if err != nil {
+		return "", "", nil, nil, 0, err
+	}
 
-			var transfer []rpc.Transfer
+	burnAssetOrDero, err := app.PromptYesNo("Burn asset or dero?", false)
+	if err != nil {
+		return "", "", nil, nil, 0, err
+	}
 
-			if burnAssetOrDero {
-				assetToken, err := app.Prompt("Enter asset token (empty for sending DERO)", "")
-				if app.HandlePromptErr(err) {
-					return nil
-				}
+	if burnAssetOrDero {
+		assetToken, err := app.Prompt("Enter asset token (empty for sending DERO)", "")
+		if err != nil {
+			return "", "", nil, nil, 0, err
+		}
+
+		amount, err := app.PromptUInt("Enter amount (atomic value)", 0)
+		if err != nil {
+			return "", "", nil, nil, 0, err
+		}
+
+		randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
+		if err != nil {
+			return "", "", nil, nil, 0, err
+		}
+
+		transfers = append(transfers, rpc.Transfer{
+			SCID:        crypto.HashHexToHash(assetToken),
+			Burn:        amount,
+			Destination: randomAddresses.Address[0],
+		})
+	}
 
-				amount, err := app.PromptUInt("Enter amount (atomic value)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
-				}
+	return scid, function.Name, args, transfers, ringsize, nil
+}
 
-				randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
-				if err != nil {
-					fmt.Println(err)
-					return nil
-				}
+func CommandCallSC() *cli.Command {
+	return &cli.Command{
+		Name:    "call",
+		Aliases: []string{"c"},
+		Usage:   "Call smart contract function",
+		Before:  requireSpendKey,
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
 
-				transfer = append(transfer, rpc.Transfer{
-					SCID:        crypto.HashHexToHash(assetToken),
-					Burn:        amount,
-					Destination: randomAddresses.Address[0],
-				})
+			scid, entrypoint, args, transfers, ringsize, err := promptSCCall(walletInstance)
+			if err != nil {
+				return err
 			}
 
-			txId, err := walletInstance.CallSmartContract(ringSize, scid, function.Name, args, transfer, true)
+			txId, err := walletInstance.CallSmartContract(ringsize, scid, entrypoint, args, transfers, true)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -995,19 +1188,18 @@ func CommandAssetBalance() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter asset token", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			hash := crypto.HashHexToHash(scid)
 			balance, err := walletInstance.GetBalance(hash)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			fmt.Println(balance)
+			utils.PrintValue("Balance", balance)
 			return nil
 		},
 	}
@@ -1017,22 +1209,15 @@ func CommandClearCommitCount() *cli.Command {
 	return &cli.Command{
 		Name:    "clear-commit",
 		Aliases: []string{"cc"},
-		Usage:   "Clear commit count to resync values",
+		Usage:   "Clear sync checkpoints to resync values",
 		Action: func(ctx *cli.Context) error {
 			name := app.Context.DAppApp.Name
-			count := utils.Count{Filename: config.GetCountFilename(app.Context.Config.Env)}
-			err := count.Load()
-			if err != nil {
-				return err
-			}
-
-			count.Set(name, 0)
-			err = count.Save()
+			err := commit_sync.ClearCheckpoints(app.Context.DB, name)
 			if err != nil {
 				return err
 			}
 
-			fmt.Printf("dapps [%s] count reset\n", name)
+			fmt.Printf("dapps [%s] sync checkpoints cleared\n", name)
 			return nil
 		},
 	}
@@ -1045,19 +1230,18 @@ func CommandAccountExists() *cli.Command {
 		Aliases: []string{"we"},
 		Action: func(ctx *cli.Context) error {
 
-			addr, err := app.Prompt("Enter wallet address", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			addr, err := app.PromptAddress("Enter wallet address")
+			if err != nil {
+				return err
 			}
 
 			result, err := app.Context.WalletInstance.Daemon.GetEncrypedBalance(&rpc.GetEncryptedBalance_Params{
-				Address:    addr,
+				Address:    addr.Address,
 				TopoHeight: -1,
 			})
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(result)
@@ -1090,8 +1274,7 @@ func DAppApp(dapp *cli.App) *cli.App {
 			CommandExit(),
 		),
 		Action: func(ctx *cli.Context) error {
-			fmt.Println("Command not found. Type 'help' for a list of commands.")
-			return nil
+			return errors.New("Command not found. Type 'help' for a list of commands.")
 		},
 	}
 }
@@ -1107,9 +1290,12 @@ func DAppWalletCommands() *cli.Command {
 			CommandWalletTransfer(),
 			CommandWalletBalance(),
 			CommandAssetBalance(),
+			CommandWalletAssets(),
 			CommandWalletAddress(),
 			CommandWalletTransactions(),
+			CommandWalletPayout(),
 			CommandSwitchWallet(),
+			TxCommands(),
 		},
 		Action: func(ctx *cli.Context) error {
 			ctx.App.Run([]string{"cmd", "help"})
@@ -1126,25 +1312,24 @@ func CommandGetEncrypedBalance() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 
-			walletAddress, err := app.Prompt("Wallet Address", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			walletAddress, err := app.PromptAddress("Wallet Address")
+			if err != nil {
+				return err
 			}
 
 			scid, err := app.Prompt("Token ID (SCID)", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			result, err := walletInstance.Daemon.GetEncrypedBalance(&rpc.GetEncryptedBalance_Params{
-				Address:    walletAddress,
+				Address:    walletAddress.Address,
 				SCID:       crypto.HashHexToHash(scid),
 				TopoHeight: -1,
 			})
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(result)
@@ -1154,7 +1339,7 @@ func CommandGetEncrypedBalance() *cli.Command {
 }
 
 func WalletApp() *cli.App {
-	fmt.Println(`Initializing lookup table...`)
+	fmt.Fprintln(os.Stderr, `Initializing lookup table...`)
 	walletapi.Initialize_LookupTable(1, 1<<19)
 
 	return &cli.App{
@@ -1174,24 +1359,35 @@ func WalletApp() *cli.App {
 			CommandDApp(),
 			CommandWalletTransfer(),
 			CommandWalletTransferFromFile(),
+			CommandWalletPayout(),
 			CommandWalletBurn(),
 			CommandWalletBalance(),
 			CommandAssetBalance(),
+			CommandWalletAssets(),
+			CommandPortfolio(),
 			CommandWalletAddress(),
 			CommandDisplayTransaction(),
 			CommandWalletTransactions(),
+			CommandTxNote(),
 			CommandWalletSeed(),
 			CommandRegisterWallet(),
 			CommandSwitchWallet(),
+			SessionCommands(),
 			CommandAccountExists(),
 			CommandGetEncrypedBalance(),
+			DAppWalletCommands(),
+			ContactCommands(),
+			ScheduleCommands(),
+			PolicyCommands(),
+			WatchCommands(),
+			NodeCommands(),
 			SCCommands(),
+			CommandServe(),
 			CommandCloseWallet(),
 			CommandExit(),
 		},
 		Action: func(ctx *cli.Context) error {
-			fmt.Println("Command not found. Type 'help' for a list of commands.")
-			return nil
+			return errors.New("Command not found. Type 'help' for a list of commands.")
 		},
 	}
 }
@@ -1209,6 +1405,7 @@ func WalletCommands() *cli.Command {
 			CommandDetachWallet(),
 			CommandCreateWallet(),
 			CommandListWallets(),
+			TxCommands(),
 		},
 		Action: func(ctx *cli.Context) error {
 			ctx.App.Run([]string{"cmd", "help"})
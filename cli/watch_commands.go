@@ -0,0 +1,220 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/utils"
+	"github.com/urfave/cli/v2"
+)
+
+func requireWatchOnly(ctx *cli.Context) error {
+	if !app.Context.WalletInstance.IsWatchOnly() {
+		return errors.New("wallet is not watch-only - attach the address with the watch connection")
+	}
+
+	return nil
+}
+
+func CommandWatchStatus() *cli.Command {
+	return &cli.Command{
+		Name:    "status",
+		Aliases: []string{"s"},
+		Usage:   "Check the watched assets on chain and show the balances of the imported views",
+		Before:  requireWatchOnly,
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
+
+			assets, err := walletInstance.RefreshWatchAssets()
+			if err != nil {
+				return err
+			}
+
+			views, err := app.Context.GetWalletViews(walletInstance.Id)
+			if err != nil {
+				return err
+			}
+
+			viewOf := make(map[crypto.Hash]*app.WalletViewAsset)
+			for _, view := range views {
+				viewOf[view.SCID] = view
+			}
+
+			app.Context.DisplayTable(len(assets), func(i int) []interface{} {
+				a := assets[i]
+				balance, topoheight, incoming := "", "", 0
+				status := "no view"
+
+				if view := viewOf[a.SCID]; view != nil {
+					balance = formatAssetAmount(a.SCID, view.Balance)
+					topoheight = fmt.Sprint(view.Topoheight)
+					incoming = len(view.Entries)
+					status = "current"
+					if view.Data != a.Data {
+						status = "changed since view"
+					}
+				}
+
+				lastChange := ""
+				if a.ChangedTopoheight > 0 {
+					lastChange = fmt.Sprint(a.ChangedTopoheight)
+				}
+
+				return []interface{}{
+					assetName(a.SCID), balance, topoheight, incoming, status, a.Changes, lastChange,
+				}
+			}, []interface{}{"Asset", "View Balance", "View Topoheight", "Incoming", "Status", "Changes", "Last Change"}, 25)
+			return nil
+		},
+	}
+}
+
+func CommandWatchAsset() *cli.Command {
+	return &cli.Command{
+		Name:      "add",
+		Aliases:   []string{"a"},
+		Usage:     "Watch the encrypted balance of an asset - DERO is always watched",
+		ArgsUsage: "[asset token]",
+		Before:    requireWatchOnly,
+		Action: func(ctx *cli.Context) error {
+			scid, err := promptAssetArg(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.WatchAsset(app.Context.WalletInstance.Id, scid)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Asset [%s] watched.\n", assetName(scid))
+			return nil
+		},
+	}
+}
+
+func CommandUnwatchAsset() *cli.Command {
+	return &cli.Command{
+		Name:      "remove",
+		Aliases:   []string{"r"},
+		Usage:     "Stop watching an asset and remove its imported view",
+		ArgsUsage: "[asset token]",
+		Before:    requireWatchOnly,
+		Action: func(ctx *cli.Context) error {
+			scid, err := promptAssetArg(ctx)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.UnwatchAsset(app.Context.WalletInstance.Id, scid)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Asset [%s] removed.\n", assetName(scid))
+			return nil
+		},
+	}
+}
+
+func CommandImportView() *cli.Command {
+	return &cli.Command{
+		Name:      "import-view",
+		Usage:     "Import the balances and incoming transfers exported by the wallet with the spend key",
+		ArgsUsage: "[file]",
+		Before:    requireWatchOnly,
+		Action: func(ctx *cli.Context) error {
+			walletInstance := app.Context.WalletInstance
+
+			filename := ctx.Args().First()
+			if filename == "" {
+				var err error
+				filename, err = app.Prompt("Enter view filepath", "wallet-view.json")
+				if err != nil {
+					return err
+				}
+			}
+
+			view, err := app.LoadWalletView(filename)
+			if err != nil {
+				return err
+			}
+
+			err = app.Context.ImportWalletView(walletInstance.Id, walletInstance.WatchAddress, view)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("View of %d asset(s) imported - use watch status to check it is still current.\n", len(view.Assets))
+			return nil
+		},
+	}
+}
+
+func CommandExportView() *cli.Command {
+	return &cli.Command{
+		Name:      "export-view",
+		Usage:     "Export the balances and incoming transfers for a watch-only wallet of this address",
+		ArgsUsage: "[file]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "scid",
+				Usage: "Asset to export with DERO - repeat for more assets",
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			var scids []crypto.Hash
+			scids = append(scids, crypto.Hash{})
+			for _, assetToken := range ctx.StringSlice("scid") {
+				if len(assetToken) != 64 {
+					return fmt.Errorf("invalid asset token [%s]", assetToken)
+				}
+
+				scids = append(scids, crypto.HashHexToHash(assetToken))
+			}
+
+			view, err := app.Context.WalletInstance.ExportView(scids)
+			if err != nil {
+				return err
+			}
+
+			filename := ctx.Args().First()
+			if filename == "" {
+				filename, err = app.Prompt("Output filepath", "wallet-view.json")
+				if err != nil {
+					return err
+				}
+			}
+
+			err = view.Save(filename)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(os.Stderr, "View of %d asset(s) saved to %s - it reveals the balances and incoming transfers but not the spend key.\n", len(view.Assets), filename)
+			return nil
+		},
+	}
+}
+
+func WatchCommands() *cli.Command {
+	return &cli.Command{
+		Name:               "watch",
+		Usage:              "Watch-only wallets - follow an address without the spend key",
+		CustomHelpTemplate: utils.AppTemplate,
+		Subcommands: []*cli.Command{
+			CommandWatchStatus(),
+			CommandWatchAsset(),
+			CommandUnwatchAsset(),
+			CommandImportView(),
+			CommandExportView(),
+		},
+		Action: func(ctx *cli.Context) error {
+			ctx.App.Run([]string{"cmd", "help"})
+			return nil
+		},
+	}
+}
@@ -0,0 +1,324 @@
+package commit_sync
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// KEEP_CHECKPOINTS is the number of checkpoints kept per engine - a reorg deeper than this resyncs from the first commit
+var KEEP_CHECKPOINTS = 20
+
+// Checkpoint is the commit index stored by an engine and the block the commits were read from
+type Checkpoint struct {
+	Name       string
+	CommitAt   uint64
+	Topoheight int64
+	BlockHash  string
+}
+
+// InitTables creates the checkpoint and undo tables in the env db - app migration
+func InitTables(tx *sql.Tx) error {
+	sql := `
+		create table if not exists app_sync_checkpoints (
+			name varchar,
+			commit_at bigint,
+			topoheight bigint,
+			block_hash varchar,
+			primary key(name, commit_at)
+		);
+
+		create table if not exists app_sync_undo (
+			id integer primary key,
+			name varchar,
+			commit_at bigint,
+			table_name varchar,
+			keys varchar,
+			row varchar
+		);
+	`
+
+	_, err := tx.Exec(sql)
+	return err
+}
+
+// Checkpoints returns the checkpoints of an engine - latest first
+func Checkpoints(db *sql.DB, name string) ([]Checkpoint, error) {
+	query := `
+		select name, commit_at, topoheight, block_hash
+		from app_sync_checkpoints
+		where name = ?
+		order by commit_at desc
+	`
+
+	rows, err := db.Query(query, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []Checkpoint
+	for rows.Next() {
+		var checkpoint Checkpoint
+		err = rows.Scan(&checkpoint.Name, &checkpoint.CommitAt, &checkpoint.Topoheight, &checkpoint.BlockHash)
+		if err != nil {
+			return nil, err
+		}
+
+		checkpoints = append(checkpoints, checkpoint)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+// ClearCheckpoints removes the checkpoints and undo log of every engine starting with prefix so they resync from the first commit
+func ClearCheckpoints(db *sql.DB, prefix string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"app_sync_checkpoints", "app_sync_undo"} {
+		_, err = tx.Exec(fmt.Sprintf("delete from %s where name = ? or name like ?", table), prefix, prefix+"-%")
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func saveCheckpoint(tx *sql.Tx, checkpoint Checkpoint) error {
+	query := `
+		insert or replace into app_sync_checkpoints (name, commit_at, topoheight, block_hash)
+		values (?, ?, ?, ?)
+	`
+
+	_, err := tx.Exec(query, checkpoint.Name, checkpoint.CommitAt, checkpoint.Topoheight, checkpoint.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	// only the undo entries newer than the oldest kept checkpoint are needed to roll back
+	query = `
+		select commit_at from app_sync_checkpoints
+		where name = ?
+		order by commit_at desc
+		limit 1 offset ?
+	`
+
+	var oldest uint64
+	err = tx.QueryRow(query, checkpoint.Name, KEEP_CHECKPOINTS-1).Scan(&oldest)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`delete from app_sync_checkpoints where name = ? and commit_at < ?`, checkpoint.Name, oldest)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`delete from app_sync_undo where name = ? and commit_at <= ?`, checkpoint.Name, oldest)
+	return err
+}
+
+// undoLog records the rows of a chunk before they are changed - only the first change of a row is needed
+type undoLog struct {
+	name     string
+	commitAt uint64 // checkpoint of the chunk
+	saved    map[string]bool
+}
+
+func whereKeys(keys map[string]interface{}) (string, []interface{}) {
+	var columns []string
+	for column := range keys {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	var where []string
+	var args []interface{}
+	for _, column := range columns {
+		where = append(where, fmt.Sprintf("%s = ?", column))
+		args = append(args, keys[column])
+	}
+
+	return strings.Join(where, " and "), args
+}
+
+func (u *undoLog) save(tx *sql.Tx, table string, keys map[string]interface{}) error {
+	keysValue, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+
+	id := table + string(keysValue)
+	if u.saved[id] {
+		return nil
+	}
+
+	where, args := whereKeys(keys)
+	rows, err := tx.Query(fmt.Sprintf("select * from %s where %s", table, where), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var row sql.NullString
+	if rows.Next() {
+		columns, err := rows.Columns()
+		if err != nil {
+			return err
+		}
+
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+
+		err = rows.Scan(pointers...)
+		if err != nil {
+			return err
+		}
+
+		data := make(map[string]interface{})
+		for i, column := range columns {
+			value := values[i]
+			if b, ok := value.([]byte); ok {
+				value = string(b)
+			}
+
+			data[column] = value
+		}
+
+		rowValue, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+
+		row = sql.NullString{String: string(rowValue), Valid: true}
+	}
+
+	err = rows.Close()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		insert into app_sync_undo (name, commit_at, table_name, keys, row)
+		values (?, ?, ?, ?, ?)
+	`
+
+	_, err = tx.Exec(query, u.name, u.commitAt, table, string(keysValue), row)
+	if err != nil {
+		return err
+	}
+
+	u.saved[id] = true
+	return nil
+}
+
+func decodeJSON(value string, out interface{}) error {
+	decoder := json.NewDecoder(strings.NewReader(value))
+	decoder.UseNumber() // keep big integers as is
+	return decoder.Decode(out)
+}
+
+// rollback restores the rows changed after commitAt from the undo log and removes the newer checkpoints
+func rollback(db *sql.DB, name string, commitAt uint64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		select table_name, keys, row
+		from app_sync_undo
+		where name = ? and commit_at > ?
+		order by id desc
+	`
+
+	rows, err := tx.Query(query, name, commitAt)
+	if err != nil {
+		return err
+	}
+
+	type undo struct {
+		table string
+		keys  string
+		row   sql.NullString
+	}
+
+	var undos []undo
+	for rows.Next() {
+		var u undo
+		err = rows.Scan(&u.table, &u.keys, &u.row)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		undos = append(undos, u)
+	}
+
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+
+	for _, u := range undos {
+		var keys map[string]interface{}
+		err = decodeJSON(u.keys, &keys)
+		if err != nil {
+			return err
+		}
+
+		where, args := whereKeys(keys)
+		_, err = tx.Exec(fmt.Sprintf("delete from %s where %s", u.table, where), args...)
+		if err != nil {
+			return err
+		}
+
+		if !u.row.Valid {
+			continue
+		}
+
+		var data map[string]interface{}
+		err = decodeJSON(u.row.String, &data)
+		if err != nil {
+			return err
+		}
+
+		var columns []string
+		var values []interface{}
+		for column, value := range data {
+			columns = append(columns, column)
+			values = append(values, value)
+		}
+
+		query := fmt.Sprintf("insert into %s (%s) values (%s)", u.table, strings.Join(columns, ", "), strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+		_, err = tx.Exec(query, values...)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(`delete from app_sync_undo where name = ? and commit_at > ?`, name, commitAt)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`delete from app_sync_checkpoints where name = ? and commit_at > ?`, name, commitAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
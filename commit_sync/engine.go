@@ -0,0 +1,358 @@
+package commit_sync
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/rpc_client"
+)
+
+type Format int
+
+const (
+	FORMAT_V1 Format = iota // commit_count and commit_N = hex(Action::Key::Value)
+	FORMAT_V2               // commit_ctr and commit_N = hex(json changes) - a value of -1 is a deleted key
+)
+
+type DeleteAction int
+
+const (
+	DELETE_IGNORE DeleteAction = iota
+	DELETE_ROW
+	DELETE_COLUMN // set column to null
+)
+
+var CHUNK_SIZE = uint64(1000)
+
+var columnName = regexp.MustCompile(`^[a-zA-Z0-9_]+$`)
+
+// Mapping stores the commit keys matching Pattern in Table.
+// The first submatches are the values of Keys and the last submatch is the column name unless Column is set.
+type Mapping struct {
+	Pattern  *regexp.Regexp
+	Table    string
+	Keys     []string
+	Column   string
+	Skip     []string // column prefixes that are not stored
+	OnDelete DeleteAction
+}
+
+// Engine syncs the commit log of a smart contract to sqlite tables
+type Engine struct {
+	Name       string // key of the checkpoints
+	SCID       string
+	Format     Format
+	Mappings   []Mapping                                  // first matching mapping is used
+	AfterChunk func(tx *sql.Tx) error                     // runs in the chunk transaction after commits are stored - must only change rows of the chunk so a rollback restores them
+	OnProgress func(name string, at uint64, count uint64) // called before and after each chunk - default prints to stderr if there is more than one chunk
+}
+
+type change struct {
+	key     string
+	value   interface{}
+	deleted bool
+}
+
+func (e *Engine) commitCount(daemon *rpc_client.Daemon, topoheight int64) (uint64, error) {
+	if e.Format == FORMAT_V2 {
+		return daemon.GetSCCommitCountV2(e.SCID, topoheight)
+	}
+
+	return daemon.GetSCCommitCount(e.SCID, topoheight)
+}
+
+func (e *Engine) changes(daemon *rpc_client.Daemon, start uint64, end uint64, topoheight int64) ([]change, error) {
+	var changes []change
+	if e.Format == FORMAT_V2 {
+		commits, err := daemon.GetSCCommitsV2(e.SCID, start, end, topoheight)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, commit := range commits {
+			for key, value := range commit {
+				number, ok := value.(float64)
+				changes = append(changes, change{key: key, value: value, deleted: ok && number == -1})
+			}
+		}
+
+		return changes, nil
+	}
+
+	commits, err := daemon.GetSCCommits(e.SCID, start, end, topoheight)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, commit := range commits {
+		changes = append(changes, change{key: commit.Key, value: commit.Value, deleted: commit.Action == "D"})
+	}
+
+	return changes, nil
+}
+
+// Tables returns the tables filled by the engine
+func (e *Engine) Tables() []string {
+	var tables []string
+	exists := make(map[string]bool)
+	for _, m := range e.Mappings {
+		if !exists[m.Table] {
+			exists[m.Table] = true
+			tables = append(tables, m.Table)
+		}
+	}
+
+	return tables
+}
+
+func (e *Engine) clear(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, table := range e.Tables() {
+		_, err = tx.Exec(fmt.Sprintf("delete from %s", table))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, table := range []string{"app_sync_checkpoints", "app_sync_undo"} {
+		_, err = tx.Exec(fmt.Sprintf("delete from %s where name = ?", table), e.Name)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func blockHash(daemon *rpc_client.Daemon, topoheight int64) (string, error) {
+	result, err := daemon.GetBlockHeaderByTopoHeight(&rpc.GetBlockHeaderByTopoHeight_Params{
+		TopoHeight: uint64(topoheight),
+	})
+
+	if err != nil {
+		return "", err
+	}
+
+	return result.Block_Header.Hash, nil
+}
+
+// verify returns the commit index to resume from. If the latest checkpoint block is not in the chain anymore (reorg or node on another fork)
+// the engine is rolled back to the last checkpoint still in the chain - or cleared if there is none.
+func (e *Engine) verify(daemon *rpc_client.Daemon, db *sql.DB, topoheight int64, commitCount uint64) (uint64, error) {
+	checkpoints, err := Checkpoints(db, e.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	for i, checkpoint := range checkpoints {
+		if checkpoint.Topoheight > topoheight || checkpoint.CommitAt > commitCount {
+			continue
+		}
+
+		hash, err := blockHash(daemon, checkpoint.Topoheight)
+		if err != nil {
+			return 0, err
+		}
+
+		if hash != checkpoint.BlockHash {
+			continue
+		}
+
+		if i > 0 {
+			fmt.Fprintf(os.Stderr, "Chain reorganized - rolling back %s to commit %d (topoheight %d)\n", e.Name, checkpoint.CommitAt, checkpoint.Topoheight)
+			err = rollback(db, e.Name, checkpoint.CommitAt)
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		return checkpoint.CommitAt, nil
+	}
+
+	if len(checkpoints) > 0 {
+		fmt.Fprintf(os.Stderr, "Chain reorganized - no matching checkpoint for %s, resyncing from the first commit\n", e.Name)
+	}
+
+	return 0, nil
+}
+
+// Sync stores new commits chunk by chunk. Commits are read at the current topoheight and each chunk is one transaction
+// with its checkpoint (commit index, topoheight and block hash) so a failed sync resumes from the last stored chunk.
+// Checkpoints are verified against the chain first and tables are cleared when syncing from the first commit.
+func (e *Engine) Sync(daemon *rpc_client.Daemon, db *sql.DB) error {
+	info, err := daemon.GetInfo()
+	if err != nil {
+		return err
+	}
+
+	topoheight := info.TopoHeight
+	hash, err := blockHash(daemon, topoheight)
+	if err != nil {
+		return err
+	}
+
+	commitCount, err := e.commitCount(daemon, topoheight)
+	if err != nil {
+		return err
+	}
+
+	commitAt, err := e.verify(daemon, db, topoheight, commitCount)
+	if err != nil {
+		return err
+	}
+
+	if commitAt == 0 {
+		err = e.clear(db)
+		if err != nil {
+			return err
+		}
+	}
+
+	onProgress := e.OnProgress
+	if onProgress == nil && commitCount-commitAt > CHUNK_SIZE {
+		onProgress = printProgress
+	} else if onProgress != nil {
+		onProgress(e.Name, commitAt, commitCount)
+	}
+
+	for i := commitAt; i < commitCount; i += CHUNK_SIZE {
+		end := i + CHUNK_SIZE
+		if end > commitCount {
+			end = commitCount
+		}
+
+		changes, err := e.changes(daemon, i, end, topoheight)
+		if err != nil {
+			return err
+		}
+
+		err = e.store(db, changes, Checkpoint{
+			Name:       e.Name,
+			CommitAt:   end,
+			Topoheight: topoheight,
+			BlockHash:  hash,
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if onProgress != nil {
+			onProgress(e.Name, end, commitCount)
+		}
+	}
+
+	return nil
+}
+
+func (e *Engine) store(db *sql.DB, changes []change, checkpoint Checkpoint) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	undo := &undoLog{name: e.Name, commitAt: checkpoint.CommitAt, saved: make(map[string]bool)}
+	for _, c := range changes {
+		err = e.storeChange(tx, undo, c)
+		if err != nil {
+			return err
+		}
+	}
+
+	if e.AfterChunk != nil {
+		err = e.AfterChunk(tx)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = saveCheckpoint(tx, checkpoint)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (e *Engine) storeChange(tx *sql.Tx, undo *undoLog, c change) error {
+	for _, m := range e.Mappings {
+		matches := m.Pattern.FindStringSubmatch(c.key)
+		if matches == nil {
+			continue
+		}
+
+		values := matches[1:]
+		column := m.Column
+		if column == "" {
+			column = values[len(values)-1]
+			values = values[:len(values)-1]
+		}
+
+		if len(values) != len(m.Keys) || !columnName.MatchString(column) {
+			return nil
+		}
+
+		for _, prefix := range m.Skip {
+			if strings.HasPrefix(column, prefix) {
+				return nil
+			}
+		}
+
+		var args []interface{}
+		var where []string
+		keyValues := make(map[string]interface{})
+		for i, key := range m.Keys {
+			args = append(args, values[i])
+			where = append(where, fmt.Sprintf("%s = ?", key))
+			keyValues[key] = values[i]
+		}
+
+		if !c.deleted || m.OnDelete != DELETE_IGNORE {
+			err := undo.save(tx, m.Table, keyValues)
+			if err != nil {
+				return err
+			}
+		}
+
+		if c.deleted {
+			switch m.OnDelete {
+			case DELETE_ROW:
+				_, err := tx.Exec(fmt.Sprintf("delete from %s where %s", m.Table, strings.Join(where, " and ")), args...)
+				return err
+			case DELETE_COLUMN:
+				_, err := tx.Exec(fmt.Sprintf("update %s set %s = null where %s", m.Table, column, strings.Join(where, " and ")), args...)
+				return err
+			}
+
+			return nil
+		}
+
+		keys := strings.Join(m.Keys, ", ")
+		query := fmt.Sprintf(`
+			insert into %s (%s, %s)
+			values (%s?)
+			on conflict(%s) do update
+			set %s = ?
+		`, m.Table, keys, column, strings.Repeat("?, ", len(m.Keys)), keys, column)
+
+		args = append(args, c.value, c.value)
+		_, err := tx.Exec(query, args...)
+		return err
+	}
+
+	return nil
+}
+
+func printProgress(name string, at uint64, count uint64) {
+	fmt.Fprintf(os.Stderr, "Syncing %s... %d/%d commits\n", name, at, count)
+}
@@ -0,0 +1,351 @@
+package commit_sync
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/rpc_client"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// fakeChain is a daemon serving the V1 commits of a smart contract at its current topoheight
+type fakeChain struct {
+	lock       sync.Mutex
+	topoheight int64
+	hashes     map[int64]string
+	commits    []string // Action::Key::Value
+}
+
+func (c *fakeChain) result(method string, params json.RawMessage) (interface{}, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	switch method {
+	case "DERO.GetInfo":
+		return rpc.GetInfo_Result{TopoHeight: c.topoheight}, nil
+	case "DERO.GetBlockHeaderByTopoHeight":
+		var p rpc.GetBlockHeaderByTopoHeight_Params
+		err := json.Unmarshal(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		return rpc.GetBlockHeaderByHeight_Result{Block_Header: rpc.BlockHeader_Print{Hash: c.hashes[int64(p.TopoHeight)]}}, nil
+	case "DERO.GetSC":
+		var p rpc.GetSC_Params
+		err := json.Unmarshal(params, &p)
+		if err != nil {
+			return nil, err
+		}
+
+		var values []string
+		for _, key := range p.KeysString {
+			value := "NOT AVAILABLE"
+			var i int
+			if key == "commit_count" {
+				value = fmt.Sprint(len(c.commits))
+			} else if _, err := fmt.Sscanf(key, "commit_%d", &i); err == nil && i < len(c.commits) {
+				value = hex.EncodeToString([]byte(c.commits[i]))
+			}
+
+			values = append(values, value)
+		}
+
+		return rpc.GetSC_Result{ValuesString: values}, nil
+	}
+
+	return nil, fmt.Errorf("method [%s] not found", method)
+}
+
+func (c *fakeChain) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Id     interface{}     `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res := map[string]interface{}{"jsonrpc": "2.0", "id": req.Id}
+	result, err := c.result(req.Method, req.Params)
+	if err != nil {
+		res["error"] = map[string]interface{}{"code": -32000, "message": err.Error()}
+	} else {
+		res["result"] = result
+	}
+
+	json.NewEncoder(w).Encode(res)
+}
+
+// mine moves the chain to a new topoheight with these commits
+func (c *fakeChain) mine(topoheight int64, hash string, commits []string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.topoheight = topoheight
+	c.hashes[topoheight] = hash
+	c.commits = commits
+}
+
+func newTestEngine(t *testing.T) (*Engine, *fakeChain, *rpc_client.Daemon, *sql.DB) {
+	t.Helper()
+
+	chain := &fakeChain{hashes: make(map[int64]string)}
+	server := httptest.NewServer(chain)
+	t.Cleanup(server.Close)
+
+	daemon := new(rpc_client.Daemon)
+	daemon.SetClient(server.URL)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	tx, err := db.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = InitTables(tx)
+	if err == nil {
+		_, err = tx.Exec(`create table tokens (id varchar primary key, owner varchar)`)
+	}
+
+	if err == nil {
+		err = tx.Commit()
+	}
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	engine := &Engine{
+		Name: "tokens",
+		SCID: "0000000000000000000000000000000000000000000000000000000000000001",
+		Mappings: []Mapping{
+			{Pattern: regexp.MustCompile(`^token_(\w+)$`), Table: "tokens", Keys: []string{"id"}, Column: "owner", OnDelete: DELETE_ROW},
+		},
+	}
+
+	return engine, chain, daemon, db
+}
+
+func checkOwners(t *testing.T, db *sql.DB, expected map[string]string) {
+	t.Helper()
+
+	rows, err := db.Query(`select id, owner from tokens`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	owners := make(map[string]string)
+	for rows.Next() {
+		var id, owner string
+		err = rows.Scan(&id, &owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		owners[id] = owner
+	}
+
+	if len(owners) != len(expected) {
+		t.Fatalf("expected tokens %v got %v", expected, owners)
+	}
+
+	for id, owner := range expected {
+		if owners[id] != owner {
+			t.Fatalf("expected tokens %v got %v", expected, owners)
+		}
+	}
+}
+
+func checkCheckpoints(t *testing.T, db *sql.DB, name string, expected ...uint64) {
+	t.Helper()
+
+	checkpoints, err := Checkpoints(db, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var commits []uint64
+	for _, checkpoint := range checkpoints {
+		commits = append(commits, checkpoint.CommitAt)
+	}
+
+	if len(commits) != len(expected) {
+		t.Fatalf("expected checkpoints %v got %v", expected, commits)
+	}
+
+	for i := range expected {
+		if commits[i] != expected[i] {
+			t.Fatalf("expected checkpoints %v got %v", expected, commits)
+		}
+	}
+}
+
+func TestSyncCommits(t *testing.T) {
+	engine, chain, daemon, db := newTestEngine(t)
+
+	commits := []string{"S::token_a::alice", "S::token_b::bob", "S::other::value"}
+	chain.mine(10, "h10", commits)
+	err := engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "alice", "b": "bob"})
+
+	chain.mine(11, "h11", append(commits, "S::token_a::carol", "D::token_b::"))
+	err = engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "carol"})
+	checkCheckpoints(t, db, engine.Name, 5, 3)
+}
+
+func TestSyncChunks(t *testing.T) {
+	chunkSize := CHUNK_SIZE
+	CHUNK_SIZE = 2
+	defer func() { CHUNK_SIZE = chunkSize }()
+
+	engine, chain, daemon, db := newTestEngine(t)
+	engine.OnProgress = func(name string, at uint64, count uint64) {}
+
+	chain.mine(10, "h10", []string{"S::token_a::alice", "S::token_b::bob", "S::token_c::carol", "S::token_a::dave", "S::token_d::erin"})
+	err := engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "dave", "b": "bob", "c": "carol", "d": "erin"})
+
+	// each chunk is stored with its checkpoint
+	checkCheckpoints(t, db, engine.Name, 5, 4, 2)
+}
+
+func TestSyncFromFirstCommit(t *testing.T) {
+	engine, chain, daemon, db := newTestEngine(t)
+
+	// rows left by a previous sync are cleared when there is no checkpoint
+	_, err := db.Exec(`insert into tokens (id, owner) values ('z', 'zoe')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chain.mine(10, "h10", []string{"S::token_a::alice"})
+	err = engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "alice"})
+}
+
+func TestSyncReorgUndo(t *testing.T) {
+	engine, chain, daemon, db := newTestEngine(t)
+
+	commits := []string{"S::token_a::alice", "S::token_b::bob"}
+	chain.mine(10, "h10", commits)
+	err := engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "alice", "b": "bob"})
+
+	chain.mine(11, "h11", append(commits, "S::token_a::carol", "D::token_b::"))
+	err = engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "carol"})
+
+	// block 11 is replaced by a block with other commits - the changes of commits 2 and 3 are undone
+	chain.mine(11, "h11b", append(commits, "S::token_c::dave"))
+	err = engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "alice", "b": "bob", "c": "dave"})
+
+	checkCheckpoints(t, db, engine.Name, 3, 2)
+
+	var undos int
+	err = db.QueryRow(`select count(*) from app_sync_undo where commit_at > 3`).Scan(&undos)
+	if err != nil || undos != 0 {
+		t.Fatalf("undo log of the removed commits was kept: %d %v", undos, err)
+	}
+}
+
+func TestSyncReorgChunks(t *testing.T) {
+	chunkSize := CHUNK_SIZE
+	CHUNK_SIZE = 1
+	defer func() { CHUNK_SIZE = chunkSize }()
+
+	engine, chain, daemon, db := newTestEngine(t)
+	engine.OnProgress = func(name string, at uint64, count uint64) {}
+
+	commits := []string{"S::token_a::alice"}
+	chain.mine(10, "h10", commits)
+	err := engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every change of a row in the reorganized chunks is undone to its value before the first one
+	chain.mine(11, "h11", append(commits, "S::token_a::bob", "S::token_a::carol", "S::token_d::dave"))
+	err = engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "carol", "d": "dave"})
+
+	chain.mine(11, "h11b", commits)
+	err = engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"a": "alice"})
+}
+
+func TestSyncReorgNoCheckpoint(t *testing.T) {
+	engine, chain, daemon, db := newTestEngine(t)
+
+	chain.mine(10, "h10", []string{"S::token_a::alice", "S::token_b::bob"})
+	err := engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// no checkpoint block is still in the chain - the tables are synced again from the first commit
+	chain.mine(10, "h10b", []string{"S::token_c::carol"})
+	err = engine.Sync(daemon, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	checkOwners(t, db, map[string]string{"c": "carol"})
+}
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const DEFAULT_PROFILE = "default"
+
+// LEGACY_DATA_FOLDER is used when it exists in the working directory - data of the versions before profiles
+const LEGACY_DATA_FOLDER = "./data"
+
+// HOME_FOLDER holds the default profile and the profiles folder
+var HOME_FOLDER = LEGACY_DATA_FOLDER
+var PROFILE = DEFAULT_PROFILE
+
+var profileName = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// DefaultHome returns $DEROSPHERE_HOME, ./data if it exists or $XDG_DATA_HOME/derosphere (~/.local/share/derosphere)
+func DefaultHome() (string, error) {
+	home := os.Getenv("DEROSPHERE_HOME")
+	if home != "" {
+		return home, nil
+	}
+
+	info, err := os.Stat(LEGACY_DATA_FOLDER)
+	if err == nil && info.IsDir() {
+		return LEGACY_DATA_FOLDER, nil
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		userHome, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+
+		dataHome = filepath.Join(userHome, ".local", "share")
+	}
+
+	return filepath.Join(dataHome, "derosphere"), nil
+}
+
+func ValidateProfileName(name string) error {
+	if !profileName.MatchString(name) {
+		return fmt.Errorf("invalid profile name [%s] - use letters, digits, - and _", name)
+	}
+
+	return nil
+}
+
+// ProfileFolder returns the folder of a profile - the default profile is the home folder itself
+func ProfileFolder(name string) string {
+	if name == DEFAULT_PROFILE {
+		return HOME_FOLDER
+	}
+
+	return filepath.Join(HOME_FOLDER, "profiles", name)
+}
+
+// SetDataFolder points the config, env dbs, backups and wallet files to the profile of the home folder
+func SetDataFolder(home string, profile string) error {
+	err := ValidateProfileName(profile)
+	if err != nil {
+		return err
+	}
+
+	// wallet paths are stored relative to the profile folder
+	home, err = filepath.Abs(home)
+	if err != nil {
+		return err
+	}
+
+	HOME_FOLDER = home
+	PROFILE = profile
+	DATA_FOLDER = ProfileFolder(profile)
+	WALLET_FOLDER_PATH = fmt.Sprintf("%s/wallets", DATA_FOLDER)
+
+	if profile != DEFAULT_PROFILE {
+		_, err = os.Stat(DATA_FOLDER)
+		if os.IsNotExist(err) {
+			return fmt.Errorf("profile [%s] does not exist - create it with profile create %s", profile, profile)
+		}
+	}
+
+	return err
+}
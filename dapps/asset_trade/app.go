@@ -14,7 +14,7 @@ import (
 	"github.com/deroproject/derohe/globals"
 	"github.com/deroproject/derohe/rpc"
 	"github.com/g45t345rt/derosphere/app"
-	"github.com/g45t345rt/derosphere/config"
+	"github.com/g45t345rt/derosphere/commit_sync"
 	"github.com/g45t345rt/derosphere/utils"
 	"github.com/urfave/cli/v2"
 )
@@ -110,284 +110,164 @@ type Bid struct {
 	Timestamp    sql.NullString
 }
 
-func initData() {
-	sqlQuery := `
-		create table if not exists dapps_asset_trade_orders (
-			id bigint primary key,
-			type varchar,
-			assetAmount bigint,
-			assetBalance bigint,
-			assetId varchar,
-			priceAssetId varchar,
-			unitPrice bigint,
-			creator varchar,
-			timestamp bigint,
-			close boolean,
-			oneTxOnly boolean,
-			priceAmount bigint,
-			priceBalance bigint,
-			expireTimestamp bigint
-		);
-
-		create table if not exists dapps_asset_trade_auctions (
-			id bigint primary key,
-			sellAssetId varchar,
-			sellAmount bigint,
-			startAmount bigint,
-			startTimestamp bigint,
-			duration bigint,
-			seller varchar,
-			bidAssetId varchar,
-			minBidAmount bigint,
-			bidSum bigint,
-			bidCount bigint,
-			timestamp bigint,
-			close boolean,
-			lastBidder varchar
-		);
-
-		create table if not exists dapps_asset_trade_auctions_bids (
-			auId bigint,
-			bidder varchar,
-			lockedAmount bigint,
-			timestamp bigint,
-			primary key (bidder, auId)
-		);
-
-		create table if not exists dapps_asset_trade_orders_txs (
-			odId bigint,
-			id bigint,
-			sender varchar,
-			assetSent bigint,
-			assetReceived bigint,
-			amountSent bigint,
-			amountReceived bigint,
-			timestamp bigint,
-			txId varchar,
-			fee bigint,
-			primary key (odId, id)
-		);
-	`
-
-	db := app.Context.DB
-
-	_, err := db.Exec(sqlQuery)
-	if err != nil {
-		log.Fatal(err)
+// Migrations of the dapp tables - applied when the dapp is opened
+func Migrations() app.MigrationModule {
+	return app.MigrationModule{
+		Name: DAPP_NAME,
+		Migrations: []app.Migration{
+			{Version: 1, Name: "create tables", Up: app.MigrateExec(`
+				create table if not exists dapps_asset_trade_orders (
+					id bigint primary key,
+					type varchar,
+					assetAmount bigint,
+					assetBalance bigint,
+					assetId varchar,
+					priceAssetId varchar,
+					unitPrice bigint,
+					creator varchar,
+					timestamp bigint,
+					close boolean,
+					oneTxOnly boolean,
+					priceAmount bigint,
+					priceBalance bigint,
+					expireTimestamp bigint
+				);
+
+				create table if not exists dapps_asset_trade_auctions (
+					id bigint primary key,
+					sellAssetId varchar,
+					sellAmount bigint,
+					startAmount bigint,
+					startTimestamp bigint,
+					duration bigint,
+					seller varchar,
+					bidAssetId varchar,
+					minBidAmount bigint,
+					bidSum bigint,
+					bidCount bigint,
+					timestamp bigint,
+					close boolean,
+					lastBidder varchar
+				);
+
+				create table if not exists dapps_asset_trade_auctions_bids (
+					auId bigint,
+					bidder varchar,
+					lockedAmount bigint,
+					timestamp bigint,
+					primary key (bidder, auId)
+				);
+
+				create table if not exists dapps_asset_trade_orders_txs (
+					odId bigint,
+					id bigint,
+					sender varchar,
+					assetSent bigint,
+					assetReceived bigint,
+					amountSent bigint,
+					amountReceived bigint,
+					timestamp bigint,
+					txId varchar,
+					fee bigint,
+					primary key (odId, id)
+				);
+			`)},
+		},
 	}
 }
 
-func clearData() {
-	query := `
-		delete from dapps_asset_trade_orders;
-		delete from dapps_asset_trade_orders_txs;
-		delete from dapps_asset_trade_auctions;
-		delete from dapps_asset_trade_auctions_bids;
-	`
-
-	db := app.Context.DB
-
-	_, err := db.Exec(query)
+func initData() {
+	_, err := app.Context.Migrate(Migrations())
 	if err != nil {
 		log.Fatal(err)
 	}
 }
 
-func syncAuction() {
-	daemon := app.Context.WalletInstance.Daemon
-	scid := getAuctionSCID()
-	commitCount, err := daemon.GetSCCommitCountV2(scid)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	count := utils.Count{Filename: config.GetCountFilename(app.Context.Config.Env)}
-	err = count.Load()
-	if err != nil {
-		log.Fatal(err)
+var auKey = regexp.MustCompile(`^au_(\d+)_(.+)`)
+var bidKey = regexp.MustCompile(`^au_(\d+)_bid_(.+)_(.+)`)
+var odKey = regexp.MustCompile(`^od_(\d+)_(.+)`)
+var txKey = regexp.MustCompile(`^od_(\d+)_tx_(.+)_(.+)`)
+
+func auctionSyncEngine() *commit_sync.Engine {
+	return &commit_sync.Engine{
+		Name:   DAPP_NAME + "-auction",
+		SCID:   getAuctionSCID(),
+		Format: commit_sync.FORMAT_V2,
+		Mappings: []commit_sync.Mapping{
+			{Pattern: bidKey, Table: "dapps_asset_trade_auctions_bids", Keys: []string{"auId", "bidder"}},
+			{Pattern: auKey, Table: "dapps_asset_trade_auctions", Keys: []string{"id"}},
+		},
 	}
+}
 
-	name := DAPP_NAME + "-auction"
-	commitAt := count.Get(name)
-
-	if commitAt == 0 {
-		clearData()
+func exchangeSyncEngine() *commit_sync.Engine {
+	return &commit_sync.Engine{
+		Name:   DAPP_NAME + "-exchange",
+		SCID:   getExchangeSCID(),
+		Format: commit_sync.FORMAT_V2,
+		Mappings: []commit_sync.Mapping{
+			{Pattern: txKey, Table: "dapps_asset_trade_orders_txs", Keys: []string{"odId", "id"}},
+			{Pattern: odKey, Table: "dapps_asset_trade_orders", Keys: []string{"id"}, Skip: []string{"txCtr"}},
+		},
 	}
+}
 
-	chunk := uint64(1000)
-	db := app.Context.DB
-	auKey, _ := regexp.Compile(`au_(\d+)_(.+)`)
-	bidKey, _ := regexp.Compile(`au_(\d+)_bid_(.+)_(.+)`)
-
-	tx, err := db.Begin()
-	if err != nil {
-		log.Fatal(err)
-	}
+func syncAuction() error {
+	return app.SyncCommits(auctionSyncEngine())
+}
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			log.Fatal(err)
-		} else {
-			tx.Commit()
+func syncExchange() error {
+	return app.SyncCommits(exchangeSyncEngine())
+}
 
-			count.Set(name, commitAt)
-			err = count.Save()
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-	}()
-
-	for i := commitAt; i < commitCount; i += chunk {
-		var commits []map[string]interface{}
-		end := i + chunk
-		if end > commitCount {
-			commitAt = commitCount
-			commits, err = daemon.GetSCCommitsV2(scid, i, commitCount)
-			if err != nil {
-				log.Fatal(err)
-			}
-		} else {
-			commitAt = end
-			commits, err = daemon.GetSCCommitsV2(scid, i, commitAt)
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
+// SyncEngines creates the tables and returns the engines to sync in the background
+func SyncEngines() []*commit_sync.Engine {
+	initData()
 
-		for _, changes := range commits {
-			for key, value := range changes {
-				if bidKey.Match([]byte(key)) {
-					auId := bidKey.ReplaceAllString(key, "$1")
-					signer := bidKey.ReplaceAllString(key, "$2")
-					columnName := bidKey.ReplaceAllString(key, "$3")
-
-					deleted := value == -1
-					if !deleted {
-						query := fmt.Sprintf(`insert into dapps_asset_trade_auctions_bids (auId, bidder, %s)
-						values (?, ?, ?)
-						on conflict(auId, bidder) do update
-						set %s = ?`, columnName, columnName)
-
-						_, err = tx.Exec(query, auId, signer, value, value)
-					}
-				} else if auKey.Match([]byte(key)) {
-					auId := auKey.ReplaceAllString(key, "$1")
-					columnName := auKey.ReplaceAllString(key, "$2")
-
-					deleted := value == -1
-					if !deleted {
-						query := fmt.Sprintf(`insert into dapps_asset_trade_auctions (id, %s)
-							values (?, ?)
-							on conflict(id) do update
-							set %s = ?`, columnName, columnName)
-
-						_, err = tx.Exec(query, auId, value, value)
-					}
-				}
-			}
-		}
+	var engines []*commit_sync.Engine
+	if getAuctionSCID() != "" {
+		engines = append(engines, auctionSyncEngine())
 	}
-}
 
-func syncExchange() {
-	daemon := app.Context.WalletInstance.Daemon
-	scid := getExchangeSCID()
-	commitCount, err := daemon.GetSCCommitCountV2(scid)
-	if err != nil {
-		log.Fatal(err)
+	if getExchangeSCID() != "" {
+		engines = append(engines, exchangeSyncEngine())
 	}
 
-	count := utils.Count{Filename: config.GetCountFilename(app.Context.Config.Env)}
-	err = count.Load()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	name := DAPP_NAME + "-exchange"
-	commitAt := count.Get(name)
+	return engines
+}
 
-	if commitAt == 0 {
-		clearData()
-	}
+// AssetSCIDs returns the assets traded in the synced orders and auctions - nothing is synced here
+func AssetSCIDs() ([]string, error) {
+	initData()
 
-	chunk := uint64(1000)
-	db := app.Context.DB
-	odKey, _ := regexp.Compile(`od_(\d+)_(.+)`)
-	txKey, _ := regexp.Compile(`od_(\d+)_tx_(.+)_(.+)`)
+	query := `
+		select assetId from dapps_asset_trade_orders
+		union select priceAssetId from dapps_asset_trade_orders
+		union select sellAssetId from dapps_asset_trade_auctions
+		union select bidAssetId from dapps_asset_trade_auctions
+	`
 
-	tx, err := db.Begin()
+	rows, err := app.Context.DB.Query(query)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
-	defer func() {
-		if err != nil {
-			tx.Rollback()
-			log.Fatal(err)
-		} else {
-			tx.Commit()
+	defer rows.Close()
 
-			count.Set(name, commitAt)
-			err = count.Save()
-			if err != nil {
-				log.Fatal(err)
-			}
-		}
-	}()
-
-	for i := commitAt; i < commitCount; i += chunk {
-		var commits []map[string]interface{}
-		end := i + chunk
-		if end > commitCount {
-			commitAt = commitCount
-			commits, err = daemon.GetSCCommitsV2(scid, i, commitCount)
-			if err != nil {
-				log.Fatal(err)
-			}
-		} else {
-			commitAt = end
-			commits, err = daemon.GetSCCommitsV2(scid, i, commitAt)
-			if err != nil {
-				log.Fatal(err)
-			}
+	var scids []string
+	for rows.Next() {
+		var scid sql.NullString
+		err = rows.Scan(&scid)
+		if err != nil {
+			return nil, err
 		}
 
-		for _, changes := range commits {
-			for key, value := range changes {
-				if txKey.Match([]byte(key)) {
-					odId := txKey.ReplaceAllString(key, "$1")
-					id := txKey.ReplaceAllString(key, "$2")
-					columnName := txKey.ReplaceAllString(key, "$3")
-
-					deleted := value == -1
-					if !deleted {
-						_, err = tx.Exec(fmt.Sprintf(`insert into dapps_asset_trade_orders_txs (odId,id,%s) values (?,?,?) on conflict(odId,id) do update set %s = ?;`, columnName, columnName),
-							odId, id, value, value)
-					}
-				} else if odKey.Match([]byte(key)) {
-					odId := odKey.ReplaceAllString(key, "$1")
-					columnName := odKey.ReplaceAllString(key, "$2")
-					if columnName == "txCtr" {
-						continue
-					}
-
-					deleted := value == -1
-					if !deleted {
-						query := fmt.Sprintf(`
-							insert into dapps_asset_trade_orders (id, %s)
-							values (?, ?)
-							on conflict(id) do update 
-							set %s = ?
-						`, columnName, columnName)
-
-						_, err = tx.Exec(query, odId, value, value)
-					}
-				}
-			}
+		if scid.Valid {
+			scids = append(scids, scid.String)
 		}
 	}
+
+	return scids, rows.Err()
 }
 
 func CommandListAuction() *cli.Command {
@@ -396,7 +276,10 @@ func CommandListAuction() *cli.Command {
 		Aliases: []string{"la"},
 		Usage:   "Assets in auction",
 		Action: func(c *cli.Context) error {
-			syncAuction()
+			err := syncAuction()
+			if err != nil {
+				return err
+			}
 
 			db := app.Context.DB
 
@@ -448,19 +331,19 @@ func CommandCreateAuction() *cli.Command {
 
 			if sellAssetId == "" {
 				sellAssetId, err = app.Prompt("Enter asset id to sell", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			amount, err := app.PromptUInt("Enter asset amount", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			bidAssetId, err := app.Prompt("Enter asset id you want to auction for (empty for DERO)", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			startAmount := uint64(0)
@@ -468,34 +351,34 @@ func CommandCreateAuction() *cli.Command {
 			if bidAssetId == "" {
 				bidAssetId = crypto.ZEROHASH.String() //"0000000000000000000000000000000000000000000000000000000000000000"
 				startAmount, err = app.PromptDero("Enter start amount (in Dero)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				minBidAmount, err = app.PromptDero("Enter min bid amount (in Dero)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			} else {
 				startAmount, err = app.PromptUInt("Enter start amount of the asset", 1)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				minBidAmount, err = app.PromptUInt("Enter min bid amount of the asset", 1)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			startTimestamp, err := app.PromptUInt("Start timestamp (unix)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			duration, err := app.PromptUInt("Duration (in seconds)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -503,8 +386,7 @@ func CommandCreateAuction() *cli.Command {
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{
@@ -525,11 +407,10 @@ func CommandCreateAuction() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -546,8 +427,8 @@ func CommandCloseAuction() *cli.Command {
 
 			if sAuId == "" {
 				sAuId, err = app.Prompt("Enter auction id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -556,8 +437,7 @@ func CommandCloseAuction() *cli.Command {
 
 			auId, err := strconv.ParseUint(sAuId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			txId, err := walletInstance.CallSmartContract(2, scid, "CloseAuction", []rpc.Argument{
@@ -565,11 +445,10 @@ func CommandCloseAuction() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -581,24 +460,25 @@ func CommandBidAuction() *cli.Command {
 		Aliases: []string{"bi"},
 		Usage:   "Bid on the auction",
 		Action: func(ctx *cli.Context) error {
-			syncAuction()
+			err := syncAuction()
+			if err != nil {
+				return err
+			}
+
 			sAuId := ctx.Args().First()
 			walletInstance := app.Context.WalletInstance
 			db := app.Context.DB
 
-			var err error
-
 			if sAuId == "" {
 				sAuId, err = app.Prompt("Enter auction id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			auId, err := strconv.ParseUint(sAuId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			scid := getAuctionSCID()
@@ -618,27 +498,25 @@ func CommandBidAuction() *cli.Command {
 			)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			bidAmount := uint64(0)
 			if auction.BidAssetId.String == crypto.ZEROHASH.String() {
 				bidAmount, err = app.PromptDero("Bid amount (in Dero)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			} else {
 				bidAmount, err = app.PromptUInt("Bid amount", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{
@@ -654,11 +532,10 @@ func CommandBidAuction() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -670,23 +547,24 @@ func CommandListAuctionBids() *cli.Command {
 		Aliases: []string{"lab"},
 		Usage:   "List auction bids",
 		Action: func(ctx *cli.Context) error {
-			syncAuction()
+			err := syncAuction()
+			if err != nil {
+				return err
+			}
+
 			sAuId := ctx.Args().First()
 			db := app.Context.DB
 
-			var err error
-
 			if sAuId == "" {
 				sAuId, err = app.Prompt("Enter auction id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			auId, err := strconv.ParseUint(sAuId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			query := `
@@ -736,20 +614,19 @@ func CommandSetMinBidAuction() *cli.Command {
 
 			if sAuId == "" {
 				sAuId, err = app.Prompt("Enter auction id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			auId, err := strconv.ParseUint(sAuId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			amount, err := app.PromptUInt("Enter minimum bid amount", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			scid := getAuctionSCID()
@@ -759,11 +636,10 @@ func CommandSetMinBidAuction() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -782,15 +658,14 @@ func CommandCheckoutAuction() *cli.Command {
 
 			if sAuId == "" {
 				sAuId, err = app.Prompt("Enter auction id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			auId, err := strconv.ParseUint(sAuId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			scid := getAuctionSCID()
@@ -799,11 +674,10 @@ func CommandCheckoutAuction() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -822,15 +696,14 @@ func CommandRetrieveLockedFundsAuction() *cli.Command {
 
 			if sAuId == "" {
 				sAuId, err = app.Prompt("Enter auction id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			auId, err := strconv.ParseUint(sAuId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			scid := getAuctionSCID()
@@ -839,48 +712,62 @@ func CommandRetrieveLockedFundsAuction() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
 }
 
+// ListOrders syncs the exchange and returns open orders
+func ListOrders() ([]Order, error) {
+	err := syncExchange()
+	if err != nil {
+		return nil, err
+	}
+
+	db := app.Context.DB
+
+	query := `
+		select id, assetAmount, assetId, priceAssetId, unitPrice, creator, timestamp, close
+		from dapps_asset_trade_orders
+		where close = false
+	`
+
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var orders []Order
+	for rows.Next() {
+		var order Order
+		err = rows.Scan(&order.Id, &order.AssetAmount, &order.AssetId, &order.PriceAssetId,
+			&order.UnitPrice, &order.Creator, &order.Timestamp, &order.Close)
+
+		if err != nil {
+			return nil, err
+		}
+
+		orders = append(orders, order)
+	}
+
+	return orders, rows.Err()
+}
+
 func CommandListOrders() *cli.Command {
 	return &cli.Command{
 		Name:    "list-orders",
 		Aliases: []string{"lo"},
 		Usage:   "Assets/NFTs you can buy or sell",
 		Action: func(c *cli.Context) error {
-			syncExchange()
-
-			db := app.Context.DB
-
-			query := `
-				select id, assetAmount, assetId, priceAssetId, unitPrice, creator, timestamp, close
-				from dapps_asset_trade_orders
-				where close = false
-			`
-
-			rows, err := db.Query(query)
+			orders, err := ListOrders()
 			if err != nil {
-				log.Fatal(err)
-			}
-
-			var orders []Order
-			for rows.Next() {
-				var order Order
-				err = rows.Scan(&order.Id, &order.AssetAmount, &order.AssetId, &order.PriceAssetId,
-					&order.UnitPrice, &order.Creator, &order.Timestamp, &order.Close)
-
-				if err != nil {
-					log.Fatal(err)
-				}
-
-				orders = append(orders, order)
+				return err
 			}
 
 			app.Context.DisplayTable(len(orders), func(i int) []interface{} {
@@ -903,48 +790,48 @@ func CommandCreateOrder() *cli.Command {
 		Usage:   "Create order",
 		Action: func(ctx *cli.Context) error {
 			odType, err := app.PromptChoose("Sell or buy?", []string{"sell", "buy"}, "sell")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			assetId, err := app.Prompt("Enter asset id", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			assetAmount, err := app.PromptUInt("Enter amount (atomic value)", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			priceAssetId, err := app.Prompt("Enter price asset id (empty for DERO)", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			unitPrice := uint64(0)
 			if priceAssetId == "" {
 				priceAssetId = crypto.ZEROHASH.String() //"0000000000000000000000000000000000000000000000000000000000000000"
 				unitPrice, err = app.PromptDero("Enter unit price (in Dero)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			} else {
 				unitPrice, err = app.PromptUInt("Enter unit price (atomic value)", 1)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			expireTimestamp, err := app.PromptUInt("Expire timestamp (unix)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uOneTx := uint64(0)
 			oneTx, err := app.PromptYesNo("One transaction only?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if oneTx {
@@ -956,8 +843,7 @@ func CommandCreateOrder() *cli.Command {
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{}
@@ -989,11 +875,10 @@ func CommandCreateOrder() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1010,8 +895,8 @@ func CommandCloseOrder() *cli.Command {
 
 			if sOdId == "" {
 				sOdId, err = app.Prompt("Enter order id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -1020,8 +905,7 @@ func CommandCloseOrder() *cli.Command {
 
 			odId, err := strconv.ParseUint(sOdId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			txId, err := walletInstance.CallSmartContract(2, scid, "CloseOrder", []rpc.Argument{
@@ -1029,11 +913,10 @@ func CommandCloseOrder() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1046,38 +929,38 @@ func CommandCreateSellOrdersFromFile() *cli.Command {
 		Usage:   "Create sell orders from file",
 		Action: func(ctx *cli.Context) error {
 			lRateAmount, err := app.PromptUInt("Enter amount (atomic value)", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			rAssetId, err := app.Prompt("Enter price asset id (empty for DERO)", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			rRateAmount := uint64(0)
 			if rAssetId == "" {
 				rAssetId = crypto.ZEROHASH.String() //"0000000000000000000000000000000000000000000000000000000000000000"
 				rRateAmount, err = app.PromptDero("Enter unit price (in Dero)", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			} else {
 				rRateAmount, err = app.PromptUInt("Enter unit price (atomic value)", 1)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			expireTimestamp, err := app.PromptUInt("Expire timestamp (unix)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uOneTx := uint64(0)
 			oneTx, err := app.PromptYesNo("One transaction only?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if oneTx {
@@ -1090,8 +973,8 @@ func CommandCreateSellOrdersFromFile() *cli.Command {
 			transfer := rpc.Transfer{}
 
 			scidFilePath, err := app.Prompt("Enter scids json file", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			content, err := ioutil.ReadFile(scidFilePath)
@@ -1108,8 +991,7 @@ func CommandCreateSellOrdersFromFile() *cli.Command {
 			for _, assetId := range assets {
 				randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				transfer = rpc.Transfer{
@@ -1118,7 +1000,8 @@ func CommandCreateSellOrdersFromFile() *cli.Command {
 					Destination: randomAddresses.Address[0],
 				}
 
-			retry:
+				// the orders wait for each tx - the next tx spends the balance changed by this one.
+				// A failed tx is not sent again because it may still be on chain.
 				txId, err := walletInstance.CallSmartContract(2, scid, "CreateOrder", []rpc.Argument{
 					{Name: "odType", DataType: rpc.DataString, Value: "sell"},
 					{Name: "lAssetId", DataType: rpc.DataString, Value: assetId},
@@ -1131,15 +1014,12 @@ func CommandCreateSellOrdersFromFile() *cli.Command {
 					transfer,
 				}, false)
 
-				if err != nil {
-					fmt.Println(err)
-					continue
+				if err == nil {
+					err = walletInstance.WaitTransaction(txId)
 				}
 
-				err = walletInstance.WaitTransaction(txId)
 				if err != nil {
-					fmt.Println(err)
-					goto retry
+					return fmt.Errorf("sell order of asset [%s] failed: %s - check the wallet transactions before creating the orders left", assetId, err)
 				}
 			}
 
@@ -1154,22 +1034,23 @@ func CommandBuyOrSell() *cli.Command {
 		Aliases: []string{"bso"},
 		Usage:   "Buy or sell asset order",
 		Action: func(ctx *cli.Context) error {
-			syncExchange()
+			err := syncExchange()
+			if err != nil {
+				return err
+			}
 
 			sOdId := ctx.Args().First()
-			var err error
 
 			if sOdId == "" {
 				sOdId, err = app.Prompt("Enter order id", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			odId, err := strconv.ParseUint(sOdId, 10, 64)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -1190,14 +1071,12 @@ func CommandBuyOrSell() *cli.Command {
 				&order.Creator, &order.Timestamp, &order.Close, &order.UnitPrice)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer = rpc.Transfer{
@@ -1207,8 +1086,8 @@ func CommandBuyOrSell() *cli.Command {
 			switch order.Type.String {
 			case "sell":
 				qty, err := app.PromptUInt("Enter quantity", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				amount := qty * uint64(order.UnitPrice.Int64)
@@ -1222,8 +1101,8 @@ func CommandBuyOrSell() *cli.Command {
 				transfer.Burn = amount
 			case "buy":
 				qty, err := app.PromptUInt("Enter quantity", 0)
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
 				totalAmount := qty * uint64(order.UnitPrice.Int64)
@@ -1242,11 +1121,10 @@ func CommandBuyOrSell() *cli.Command {
 			}, []rpc.Transfer{transfer}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1264,8 +1142,8 @@ func CommandViewAsset() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -1276,14 +1154,12 @@ func CommandViewAsset() *cli.Command {
 				Variables: true,
 			})
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			err = asset.Parse(scid, result)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			asset.Print()
@@ -1,6 +1,8 @@
 package dapps
 
 import (
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/commit_sync"
 	"github.com/g45t345rt/derosphere/dapps/asset_trade"
 	"github.com/g45t345rt/derosphere/dapps/dnd"
 	"github.com/g45t345rt/derosphere/dapps/g45_sc"
@@ -22,6 +24,30 @@ func Find(name string) *cli.App {
 	return nil
 }
 
+// SyncEngines returns the commit sync engines of every dapp for the current env
+func SyncEngines() []*commit_sync.Engine {
+	var engines []*commit_sync.Engine
+	engines = append(engines, username.SyncEngines()...)
+	engines = append(engines, lotto.SyncEngines()...)
+	engines = append(engines, asset_trade.SyncEngines()...)
+	return engines
+}
+
+// AssetSCIDs returns the assets found in the synced tables of the dapps
+func AssetSCIDs() ([]string, error) {
+	return asset_trade.AssetSCIDs()
+}
+
+// Migrations returns the schema migrations of every dapp with tables
+func Migrations() []app.MigrationModule {
+	return []app.MigrationModule{
+		username.Migrations(),
+		lotto.Migrations(),
+		seals.Migrations(),
+		asset_trade.Migrations(),
+	}
+}
+
 func List() []*cli.App {
 	return []*cli.App{
 		nameservice.App(),
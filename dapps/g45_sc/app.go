@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"time"
 
 	"github.com/deroproject/derohe/cryptography/crypto"
 	"github.com/deroproject/derohe/rpc"
@@ -23,8 +22,8 @@ func Command_G45_AT_View() *cli.Command {
 		Usage:   "Display G45-AT metadata and more",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -36,14 +35,12 @@ func Command_G45_AT_View() *cli.Command {
 				Variables: true,
 			})
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			err = asset.Parse(scid, result)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			asset.Print()
@@ -60,8 +57,8 @@ func Command_G45_AT_Deploy() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 			assetType, err := app.PromptChoose("Asset token type", []string{"public", "private"}, "private")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			code := utils.G45_AT_PRIVATE_CODE
@@ -70,33 +67,33 @@ func Command_G45_AT_Deploy() *cli.Command {
 			}
 
 			collectionSCID, err := app.Prompt("Enter collection scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			decimals, err := app.PromptUInt("Enter token decimals", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			startSupply, err := app.PromptUInt("Enter amount to mint", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadataFormat, err := app.Prompt("Enter metadata format", "json")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadata, err := app.Prompt("Enter metadata", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			freezeMetadata, err := app.PromptYesNo("Freeze metadata?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeMetadata := uint64(0)
@@ -105,8 +102,8 @@ func Command_G45_AT_Deploy() *cli.Command {
 			}
 
 			freezeMint, err := app.PromptYesNo("Freeze minting?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeMint := uint64(0)
@@ -115,8 +112,8 @@ func Command_G45_AT_Deploy() *cli.Command {
 			}
 
 			freezeCollection, err := app.PromptYesNo("Freeze collection?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeCollection := uint64(0)
@@ -136,11 +133,10 @@ func Command_G45_AT_Deploy() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -157,14 +153,14 @@ func Command_G45_AT_Mint() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			supply, err := app.PromptUInt("Enter amount", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -173,11 +169,10 @@ func Command_G45_AT_Mint() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -194,14 +189,14 @@ func Command_G45_AT_SetCollection() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			collectionSCID, err := app.Prompt("Enter collection SCID", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -210,11 +205,10 @@ func Command_G45_AT_SetCollection() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -227,14 +221,14 @@ func Command_G45_AT_Freeze() *cli.Command {
 		Usage:   "Freeze G45-AT (mint, metadata or collection)",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeMint := uint64(0)
 			freezeMint, err := app.PromptYesNo("Freeze minting?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if freezeMint {
@@ -243,8 +237,8 @@ func Command_G45_AT_Freeze() *cli.Command {
 
 			uFreezeMetadata := uint64(0)
 			freezeMetadata, err := app.PromptYesNo("Freeze metadata?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if freezeMetadata {
@@ -253,8 +247,8 @@ func Command_G45_AT_Freeze() *cli.Command {
 
 			uFreezeCollection := uint64(0)
 			freezeCollection, err := app.PromptYesNo("Freeze collection?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if freezeCollection {
@@ -269,11 +263,10 @@ func Command_G45_AT_Freeze() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -290,19 +283,19 @@ func Command_G45_AT_SetMetadata() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			format, err := app.Prompt("Metadata format?", "json")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadata, err := app.Prompt("Set new metadata", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -312,11 +305,10 @@ func Command_G45_AT_SetMetadata() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -334,20 +326,19 @@ func Command_G45_AT_Burn() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			burnAmount, err := app.PromptUInt("Burn", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{
@@ -361,11 +352,10 @@ func Command_G45_AT_Burn() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -383,20 +373,19 @@ func Command_G45_AT_DisplayToken() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			amount, err := app.PromptUInt("Amount", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{
@@ -410,11 +399,10 @@ func Command_G45_AT_DisplayToken() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -432,14 +420,14 @@ func Command_G45_AT_RetrieveToken() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			amount, err := app.PromptUInt("Amount", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			txId, err := walletInstance.CallSmartContract(2, scid, "RetrieveToken", []rpc.Argument{
@@ -447,11 +435,10 @@ func Command_G45_AT_RetrieveToken() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -464,26 +451,25 @@ func Command_G45_AT_TransferMinter() *cli.Command {
 		Usage:   "Initiate transfer minter",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
-			addr, err := app.Prompt("New owner address", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			addr, err := app.PromptAddress("New owner address")
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
 			txId, err := walletInstance.CallSmartContract(2, scid, "TransferMinter", []rpc.Argument{
-				{Name: "newMinter", DataType: rpc.DataString, Value: addr},
+				{Name: "newMinter", DataType: rpc.DataString, Value: addr.Address},
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -496,19 +482,18 @@ func Command_G45_AT_CancelTransferMinter() *cli.Command {
 		Usage:   "Cancel ongoing transfer minter",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
 			txId, err := walletInstance.CallSmartContract(2, scid, "CancelTransferMinter", []rpc.Argument{}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -521,19 +506,18 @@ func Command_G45_AT_ClaimMinter() *cli.Command {
 		Usage:   "Claim minter pending transfer",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
 			txId, err := walletInstance.CallSmartContract(2, scid, "ClaimMinter", []rpc.Argument{}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -548,8 +532,8 @@ func Command_G45_FAT_View() *cli.Command {
 		Usage:   "Display G45-FAT metadata and more",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -561,14 +545,12 @@ func Command_G45_FAT_View() *cli.Command {
 				Variables: true,
 			})
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			err = asset.Parse(scid, result)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			asset.Print()
@@ -585,8 +567,8 @@ func Command_G45_FAT_Deploy() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 			assetType, err := app.PromptChoose("Asset token type", []string{"public", "private"}, "private")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			code := utils.G45_FAT_PRIVATE_CODE
@@ -595,33 +577,33 @@ func Command_G45_FAT_Deploy() *cli.Command {
 			}
 
 			collectionSCID, err := app.Prompt("Enter collection scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			decimals, err := app.PromptUInt("Enter token decimals", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			startSupply, err := app.PromptUInt("Enter max supply", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadataFormat, err := app.Prompt("Enter metadata format", "json")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadata, err := app.Prompt("Enter metadata", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			freezeMetadata, err := app.PromptYesNo("Freeze metadata?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeMetadata := uint64(0)
@@ -630,8 +612,8 @@ func Command_G45_FAT_Deploy() *cli.Command {
 			}
 
 			freezeCollection, err := app.PromptYesNo("Freeze collection?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeCollection := uint64(0)
@@ -650,11 +632,10 @@ func Command_G45_FAT_Deploy() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -671,19 +652,19 @@ func Command_G45_FAT_SetMetadata() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			format, err := app.Prompt("Metadata format?", "json")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadata, err := app.Prompt("Set new metadata", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -693,11 +674,10 @@ func Command_G45_FAT_SetMetadata() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -714,14 +694,14 @@ func Command_G45_FAT_SetCollection() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			collectionSCID, err := app.Prompt("Enter collection SCID", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -730,11 +710,10 @@ func Command_G45_FAT_SetCollection() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -752,20 +731,19 @@ func Command_G45_FAT_Burn() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			burnAmount, err := app.PromptUInt("Burn", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{
@@ -779,11 +757,10 @@ func Command_G45_FAT_Burn() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -796,14 +773,14 @@ func Command_G45_FAT_Freeze() *cli.Command {
 		Usage:   "Freeze G45-FAT (metadata or collection)",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeMetadata := uint64(0)
 			freezeMetadata, err := app.PromptYesNo("Freeze metadata?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if freezeMetadata {
@@ -812,8 +789,8 @@ func Command_G45_FAT_Freeze() *cli.Command {
 
 			uFreezeCollection := uint64(0)
 			freezeCollection, err := app.PromptYesNo("Freeze collection?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if freezeCollection {
@@ -827,11 +804,10 @@ func Command_G45_FAT_Freeze() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -849,20 +825,19 @@ func Command_G45_FAT_DisplayToken() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			amount, err := app.PromptUInt("Amount", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{
@@ -876,11 +851,10 @@ func Command_G45_FAT_DisplayToken() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -898,14 +872,14 @@ func Command_G45_FAT_RetrieveToken() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			amount, err := app.PromptUInt("Amount", 1)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			txId, err := walletInstance.CallSmartContract(2, scid, "RetrieveToken", []rpc.Argument{
@@ -913,11 +887,10 @@ func Command_G45_FAT_RetrieveToken() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -932,8 +905,8 @@ func Command_G45_NFT_View() *cli.Command {
 		Usage:   "Display G45-NFT metadata and more",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -945,14 +918,12 @@ func Command_G45_NFT_View() *cli.Command {
 				Variables: true,
 			})
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			err = asset.Parse(scid, result)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			asset.Print()
@@ -969,8 +940,8 @@ func Command_G45_NFT_Deploy() *cli.Command {
 		Action: func(ctx *cli.Context) error {
 			walletInstance := app.Context.WalletInstance
 			assetType, err := app.PromptChoose("Privacy type", []string{"public", "private"}, "private")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			code := utils.G45_NFT_PRIVATE_CODE
@@ -979,18 +950,18 @@ func Command_G45_NFT_Deploy() *cli.Command {
 			}
 
 			collectionSCID, err := app.Prompt("Enter collection scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadataFormat, err := app.Prompt("Enter metadata format", "json")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadata, err := app.Prompt("Enter metadata", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			txId, err := walletInstance.InstallSmartContract([]byte(code), 2, []rpc.Argument{
@@ -1000,11 +971,10 @@ func Command_G45_NFT_Deploy() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1022,15 +992,14 @@ func Command_G45_NFT_DisplayNFT() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			transfer := rpc.Transfer{
@@ -1043,11 +1012,10 @@ func Command_G45_NFT_DisplayNFT() *cli.Command {
 				transfer,
 			}, true)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1065,19 +1033,18 @@ func Command_G45_NFT_RetrieveNFT() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			txId, err := walletInstance.CallSmartContract(2, scid, "RetrieveNFT", []rpc.Argument{}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1092,8 +1059,8 @@ func Command_G45_C_View() *cli.Command {
 		Usage:   "Display G45-C metadata and more",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -1104,14 +1071,12 @@ func Command_G45_C_View() *cli.Command {
 				Variables: true,
 			})
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			err = collection.Parse(scid, result)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			collection.Print()
@@ -1161,11 +1126,11 @@ func Command_G45_C_Deploy() *cli.Command {
 			walletInstance := app.Context.WalletInstance
 
 			txId, err := G45_C_Deploy()
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1178,14 +1143,14 @@ func Command_G45_C_Freeze() *cli.Command {
 		Usage:   "Freeze G45-C (assets/metadata)",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uFreezeAssets := uint64(0)
 			freezeAssets, err := app.PromptYesNo("Freeze assets/nfts?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if freezeAssets {
@@ -1194,8 +1159,8 @@ func Command_G45_C_Freeze() *cli.Command {
 
 			uFreezeMetadata := uint64(0)
 			freezeMetadata, err := app.PromptYesNo("Freeze metadata?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if freezeMetadata {
@@ -1209,11 +1174,10 @@ func Command_G45_C_Freeze() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1221,13 +1185,13 @@ func Command_G45_C_Freeze() *cli.Command {
 
 func G45_C_SetAssets(scId string, assets map[string]uint64, promptFees bool) error {
 	startAt, err := app.PromptUInt("Start at index", 0)
-	if app.HandlePromptErr(err) {
-		return nil
+	if err != nil {
+		return err
 	}
 
 	maxAssetsPerEntry, err := app.PromptUInt("Assets per TX", 100)
-	if app.HandlePromptErr(err) {
-		return nil
+	if err != nil {
+		return err
 	}
 
 	var entries []map[string]uint64
@@ -1254,27 +1218,22 @@ func G45_C_SetAssets(scId string, assets map[string]uint64, promptFees bool) err
 		if i >= int(startAt) {
 			data, err := json.Marshal(entry)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-		set_assets:
+			// unlike other dapp commands the batch waits for each tx - the next tx spends the balance changed by this one.
+			// A failed tx is not sent again because it may still be on chain.
 			fmt.Printf("Set Assets %d - %d assets\n", i, len(entry))
 			txId, err := walletInstance.CallSmartContract(2, scId, "SetAssets", []rpc.Argument{
 				{Name: "index", DataType: rpc.DataUint64, Value: uint64(i)},
 				{Name: "assets", DataType: rpc.DataString, Value: string(data)},
 			}, []rpc.Transfer{}, promptFees)
-			if err != nil {
-				fmt.Println(err)
-				time.Sleep(2 * time.Second)
-				goto set_assets
+			if err == nil {
+				err = walletInstance.WaitTransaction(txId)
 			}
 
-			err = walletInstance.WaitTransaction(txId)
 			if err != nil {
-				fmt.Println(err)
-				time.Sleep(2 * time.Second)
-				goto set_assets
+				return fmt.Errorf("set assets %d failed: %s - check the wallet transactions and start at the index not set", i, err)
 			}
 		}
 	}
@@ -1289,32 +1248,29 @@ func Command_G45_C_SetAssets() *cli.Command {
 		Usage:   "Set assets to G45-C",
 		Action: func(ctx *cli.Context) error {
 			collectionSCID, err := app.Prompt("Enter collection scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadataPath, err := app.Prompt("Enter assets metadata file path", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			content, err := ioutil.ReadFile(metadataPath)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			var assets map[string]uint64
 			err = json.Unmarshal(content, &assets)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			err = G45_C_SetAssets(collectionSCID, assets, false)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			return nil
@@ -1329,13 +1285,13 @@ func Command_G45_C_DelAssets() *cli.Command {
 		Usage:   "Del assets from G45-C",
 		Action: func(ctx *cli.Context) error {
 			collectionSCID, err := app.Prompt("Enter collection scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			index, err := app.PromptUInt("Enter index to delete?", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -1344,11 +1300,10 @@ func Command_G45_C_DelAssets() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1365,19 +1320,19 @@ func Command_G45_C_SetMetadata() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			format, err := app.Prompt("Metadata format?", "json")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadata, err := app.Prompt("Set new metadata", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
@@ -1387,11 +1342,10 @@ func Command_G45_C_SetMetadata() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1404,26 +1358,25 @@ func Command_G45_C_TransferOwnership() *cli.Command {
 		Usage:   "Initiate collection transfer ownership",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
-			addr, err := app.Prompt("New owner address", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			addr, err := app.PromptAddress("New owner address")
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
 			txId, err := walletInstance.CallSmartContract(2, scid, "TransferOwnership", []rpc.Argument{
-				{Name: "newOwner", DataType: rpc.DataString, Value: addr},
+				{Name: "newOwner", DataType: rpc.DataString, Value: addr.Address},
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1436,19 +1389,18 @@ func Command_G45_C_ClaimOwnership() *cli.Command {
 		Usage:   "Claim collection ownership",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
 			txId, err := walletInstance.CallSmartContract(2, scid, "ClaimOwnership", []rpc.Argument{}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1461,19 +1413,18 @@ func Command_G45_C_CancelTransferOwnership() *cli.Command {
 		Usage:   "Cancel transfer collection ownership",
 		Action: func(ctx *cli.Context) error {
 			scid, err := app.Prompt("Enter scid", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			walletInstance := app.Context.WalletInstance
 			txId, err := walletInstance.CallSmartContract(2, scid, "CancelTransferOwnership", []rpc.Argument{}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txId)
+			walletInstance.TxSent(txId)
 			return nil
 		},
 	}
@@ -1487,46 +1438,44 @@ func Command_G45_C_DeployNFTs() *cli.Command {
 			walletInstance := app.Context.WalletInstance
 
 			installCollection, err := app.PromptYesNo("Install G45-C?", true)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			var collectionSCID string
 			if installCollection {
 				collectionSCID, err = G45_C_Deploy()
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 
-				walletInstance.RunTxChecker(collectionSCID)
+				walletInstance.TxSent(collectionSCID)
 			} else {
 				collectionSCID, err = app.Prompt("G45-C Smart Contract?", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			metadataPath, err := app.Prompt("Enter nfts metadata file path", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			metadataContent, err := ioutil.ReadFile(metadataPath)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			var metadataList []interface{}
 			err = json.Unmarshal(metadataContent, &metadataList)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			scType, err := app.PromptChoose("G45-NFT type", []string{"public", "private"}, "private")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			scCode := utils.G45_NFT_PUBLIC_CODE
@@ -1535,38 +1484,36 @@ func Command_G45_C_DeployNFTs() *cli.Command {
 			}
 
 			startIndex, err := app.PromptUInt("NFT start index", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			endIndex, err := app.PromptUInt("NFT end index", 99)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			nfts := make(map[string]uint64)
 
 			nftsOutputPath, err := app.Prompt("Enter nft list output file path", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			loadNFTs, err := app.PromptYesNo("Load nft list from output file?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if loadNFTs {
 				nftsContent, err := ioutil.ReadFile(nftsOutputPath)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				err = json.Unmarshal(nftsContent, &nfts)
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 			}
 
@@ -1582,7 +1529,7 @@ func Command_G45_C_DeployNFTs() *cli.Command {
 
 				sMetadata := string(bMetadata)
 
-			install_asset:
+				// the installs wait for each tx like G45_C_SetAssets and stop at the first failure instead of installing twice
 				fmt.Printf("Install NFT - %d\n", index)
 				assetSCID, err := walletInstance.InstallSmartContract([]byte(scCode), 2, []rpc.Argument{
 					{Name: "collection", DataType: rpc.DataString, Value: collectionSCID},
@@ -1590,17 +1537,12 @@ func Command_G45_C_DeployNFTs() *cli.Command {
 					{Name: "metadata", DataType: rpc.DataString, Value: sMetadata},
 				}, false)
 
-				if err != nil {
-					fmt.Println(err)
-					time.Sleep(2 * time.Second)
-					goto install_asset
+				if err == nil {
+					err = walletInstance.WaitTransaction(assetSCID)
 				}
 
-				err = walletInstance.WaitTransaction(assetSCID)
 				if err != nil {
-					fmt.Println(err)
-					time.Sleep(2 * time.Second)
-					goto install_asset
+					return fmt.Errorf("install nft %d failed: %s - check the wallet transactions and load the nft list to start at the next index", index, err)
 				}
 
 				nfts[assetSCID] = index
@@ -1617,8 +1559,8 @@ func Command_G45_C_DeployNFTs() *cli.Command {
 			}
 
 			setCollectionAssets, err := app.PromptYesNo("Set all nfts in collection?", true)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if setCollectionAssets {
@@ -1648,8 +1590,8 @@ func CommandValidSC() *cli.Command {
 
 			if scid == "" {
 				scid, err = app.Prompt("Enter scid", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -1660,8 +1602,7 @@ func CommandValidSC() *cli.Command {
 			})
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			//checksum := fmt.Sprintf("%x", sha256.Sum256([]byte(result.Code)))
@@ -1689,6 +1630,37 @@ func CommandValidSC() *cli.Command {
 	}
 }
 
+// View detects the G45 standard from the smart contract code and returns the parsed data
+func View(scid string) (string, interface{}, error) {
+	walletInstance := app.Context.WalletInstance
+	result, err := walletInstance.Daemon.GetSC(&rpc.GetSC_Params{
+		SCID:      scid,
+		Code:      true,
+		Variables: true,
+	})
+
+	if err != nil {
+		return "", nil, err
+	}
+
+	switch result.Code {
+	case utils.G45_AT_PUBLIC_CODE, utils.G45_AT_PRIVATE_CODE:
+		asset := &utils.G45_AT{}
+		return "G45-AT", asset, asset.Parse(scid, result)
+	case utils.G45_FAT_PUBLIC_CODE, utils.G45_FAT_PRIVATE_CODE:
+		asset := &utils.G45_FAT{}
+		return "G45-FAT", asset, asset.Parse(scid, result)
+	case utils.G45_NFT_PUBLIC_CODE, utils.G45_NFT_PRIVATE_CODE:
+		asset := &utils.G45_NFT{}
+		return "G45-NFT", asset, asset.Parse(scid, result)
+	case utils.G45_C_CODE:
+		collection := &utils.G45_C{}
+		return "G45-C", collection, collection.Parse(scid, result)
+	}
+
+	return "", nil, fmt.Errorf("not a valid G45 smart contract")
+}
+
 func App() *cli.App {
 	return &cli.App{
 		Name:        "g45-sc",
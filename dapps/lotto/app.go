@@ -13,8 +13,7 @@ import (
 	"github.com/deroproject/derohe/globals"
 	"github.com/deroproject/derohe/rpc"
 	"github.com/g45t345rt/derosphere/app"
-	"github.com/g45t345rt/derosphere/config"
-	"github.com/g45t345rt/derosphere/rpc_client"
+	"github.com/g45t345rt/derosphere/commit_sync"
 	"github.com/g45t345rt/derosphere/utils"
 	"github.com/urfave/cli/v2"
 
@@ -108,15 +107,17 @@ func (l *Lotto) DisplayWinner() string {
 }
 
 func (l *Lotto) Print() {
-	fmt.Println("Creator:", l.DisplayCreator())
-	fmt.Println("Tickets:", l.DisplayTickets())
-	fmt.Println("Ticket price:", globals.FormatMoney(uint64(l.TicketPrice.Int64)))
-	fmt.Println("Winner reward:", l.DisplayWinnerReward())
-	fmt.Println("Base reward:", globals.FormatMoney(uint64(l.BaseReward.Int64)))
-	fmt.Println("Start timestamp:", l.DisplayStartTimestamp())
-	fmt.Println("Draw timestamp:", l.DisplayDrawTimestamp())
-	fmt.Println("One ticket per wallet:", l.UniqueWallet.Bool)
-	fmt.Println("Password lock:", l.PasswordHash.Valid)
+	utils.PrintFields(
+		utils.Field{Name: "Creator", Value: l.DisplayCreator()},
+		utils.Field{Name: "Tickets", Value: l.DisplayTickets()},
+		utils.Field{Name: "Ticket price", Value: globals.FormatMoney(uint64(l.TicketPrice.Int64))},
+		utils.Field{Name: "Winner reward", Value: l.DisplayWinnerReward()},
+		utils.Field{Name: "Base reward", Value: globals.FormatMoney(uint64(l.BaseReward.Int64))},
+		utils.Field{Name: "Start timestamp", Value: l.DisplayStartTimestamp()},
+		utils.Field{Name: "Draw timestamp", Value: l.DisplayDrawTimestamp()},
+		utils.Field{Name: "One ticket per wallet", Value: l.UniqueWallet.Bool},
+		utils.Field{Name: "Password lock", Value: l.PasswordHash.Valid},
+	)
 }
 
 type LottoTicket struct {
@@ -136,156 +137,84 @@ func (lt *LottoTicket) DisplayOwner() string {
 	return lt.Owner.String
 }
 
-func initData() {
-	sqlQuery := `
-		create table if not exists dapps_lotto (
-			tx_id varchar primary key,
-			max_tickets bigint,
-			ticket_price bigint,
-			ticket_count bigint,
-			base_reward bigint,
-			duration bigint,
-			unique_wallet boolean,
-			password_hash varchar,
-			draw_timestamp bigint,
-			claim_tx_id varchar,
-			claim_timestamp bigint,
-			start_timestamp bigint,
-			winner varchar,
-			winning_ticket bigint,
-			winner_comment varchar,
-			owner varchar,
-			anti_spam_fee bigint
-		);
-
-		create table if not exists dapps_lotto_tickets (
-			lotto_tx_id varchar,
-			ticket_number bigint,
-			owner varchar,
-			timestamp bigint,
-			play_tx_id varchar,
-			primary key(lotto_tx_id, ticket_number)
-		);
-	`
-
-	db := app.Context.DB
-
-	_, err := db.Exec(sqlQuery)
-	if err != nil {
-		log.Fatal(err)
+// Migrations of the dapp tables - applied when the dapp is opened
+func Migrations() app.MigrationModule {
+	return app.MigrationModule{
+		Name: DAPP_NAME,
+		Migrations: []app.Migration{
+			{Version: 1, Name: "create tables", Up: app.MigrateExec(`
+				create table if not exists dapps_lotto (
+					tx_id varchar primary key,
+					max_tickets bigint,
+					ticket_price bigint,
+					ticket_count bigint,
+					base_reward bigint,
+					duration bigint,
+					unique_wallet boolean,
+					password_hash varchar,
+					draw_timestamp bigint,
+					claim_tx_id varchar,
+					claim_timestamp bigint,
+					start_timestamp bigint,
+					winner varchar,
+					winning_ticket bigint,
+					winner_comment varchar,
+					owner varchar
+				);
+
+				create table if not exists dapps_lotto_tickets (
+					lotto_tx_id varchar,
+					ticket_number bigint,
+					owner varchar,
+					timestamp bigint,
+					play_tx_id varchar,
+					primary key(lotto_tx_id, ticket_number)
+				);
+			`)},
+			{Version: 2, Name: "add dapps_lotto.anti_spam_fee", Up: app.MigrateAddColumn("dapps_lotto", "anti_spam_fee", "bigint")},
+		},
 	}
 }
 
-func sync() {
-	daemon := app.Context.WalletInstance.Daemon
-	scid := getSCID()
-	commitCount := daemon.GetSCCommitCount(scid)
-	count := utils.Count{Filename: config.GetCountFilename(app.Context.Config.Env)}
-	err := count.Load()
+func initData() {
+	_, err := app.Context.Migrate(Migrations())
 	if err != nil {
 		log.Fatal(err)
 	}
+}
 
-	commitAt := count.Get(DAPP_NAME)
-	chunk := uint64(1000)
-	db := app.Context.DB
-
-	isLotto, _ := regexp.Compile(`state_lotto_`)
-	lottoKey, _ := regexp.Compile(`state_lotto_([a-zA-Z0-9-]+)_(.+)`)
-
-	isTicket, _ := regexp.Compile(`state_lotto_[a-zA-Z0-9-]+_ticket_\d+`)
-	ticketKey, _ := regexp.Compile(`state_lotto_([a-zA-Z0-9-]+)_ticket_(\d+)_(.+)`)
-
-	tx, err := db.Begin()
-	if err != nil {
-		log.Fatal(err)
+var ticketKey = regexp.MustCompile(`^state_lotto_([a-zA-Z0-9-]+)_ticket_(\d+)_(.+)`)
+var lottoKey = regexp.MustCompile(`^state_lotto_([a-zA-Z0-9-]+)_(.+)`)
+
+func syncEngine() *commit_sync.Engine {
+	return &commit_sync.Engine{
+		Name:   DAPP_NAME,
+		SCID:   getSCID(),
+		Format: commit_sync.FORMAT_V1,
+		Mappings: []commit_sync.Mapping{
+			{Pattern: ticketKey, Table: "dapps_lotto_tickets", Keys: []string{"lotto_tx_id", "ticket_number"}},
+			{Pattern: lottoKey, Table: "dapps_lotto", Keys: []string{"tx_id"}, Skip: []string{"unique_ticket_"}, OnDelete: commit_sync.DELETE_COLUMN},
+		},
+		AfterChunk: func(tx *sql.Tx) error {
+			// we if ticket price null means a lotto has been cancelled and deleted from sc
+			_, err := tx.Exec("delete from dapps_lotto where ticket_price is null")
+			return err
+		},
 	}
-	defer tx.Rollback()
-
-	for i := commitAt; i < commitCount; i += chunk {
-		var commits []rpc_client.Commit
-		end := i + chunk
-		if end > commitCount {
-			commitAt = commitCount
-			commits = daemon.GetSCCommits(scid, i, commitCount)
-		} else {
-			commitAt = end
-			commits = daemon.GetSCCommits(scid, i, commitAt)
-		}
-
-		for _, commit := range commits {
-			key := commit.Key
-
-			if isTicket.Match([]byte(key)) {
-				txId := ticketKey.ReplaceAllString(key, "$1")
-				ticketNumber := ticketKey.ReplaceAllString(key, "$2")
-				columnName := ticketKey.ReplaceAllString(key, "$3")
-
-				if commit.Action == "S" {
-					query := fmt.Sprintf(`
-						insert into lotto_tickets (lotto_tx_id, ticket_number, %s)
-						values (?, ?, ?)
-						on conflict(lotto_tx_id, ticket_number) do update 
-						set %s = ?
-					`, columnName, columnName)
-
-					_, err := tx.Exec(query, txId, ticketNumber, commit.Value, commit.Value)
-					if err != nil {
-						log.Fatal(err)
-					}
-				}
-			} else if isLotto.Match([]byte(key)) {
-				txId := lottoKey.ReplaceAllString(key, "$1")
-				columnName := lottoKey.ReplaceAllString(key, "$2")
-
-				if strings.HasPrefix(columnName, "unique_ticket_") {
-					continue
-				}
-
-				if commit.Action == "S" {
-					query := fmt.Sprintf(`
-						insert into lotto (tx_id, %s)
-						values (?, ?)
-						on conflict(tx_id) do update 
-						set %s = ?
-					`, columnName, columnName)
-
-					_, err := tx.Exec(query, txId, commit.Value, commit.Value)
-					if err != nil {
-						log.Fatal(err)
-					}
-				} else if commit.Action == "D" {
-					query := fmt.Sprintf(`
-					  update lotto
-						set %s = null
-						where tx_id = ?
-					`, columnName)
-
-					_, err := tx.Exec(query, txId)
-					if err != nil {
-						log.Fatal(err)
-					}
-				}
-			}
-		}
-
-		// we if ticket price null means a lotto has been cancelled and deleted from sc
-		_, err := tx.Exec("delete from dapps_lotto where ticket_price is null")
-		if err != nil {
-			log.Fatal(err)
-		}
+}
 
-		err = tx.Commit()
-		if err != nil {
-			log.Fatal(err)
-		}
+func sync() error {
+	return app.SyncCommits(syncEngine())
+}
 
-		count.Set(DAPP_NAME, commitAt)
-		err = count.Save()
-		if err != nil {
-			log.Fatal()
-		}
+// SyncEngines creates the tables and returns the engines to sync in the background
+func SyncEngines() []*commit_sync.Engine {
+	if getSCID() == "" {
+		return nil
 	}
+
+	initData()
+	return []*commit_sync.Engine{syncEngine()}
 }
 
 func promptTxId(c *cli.Context) (string, error) {
@@ -338,7 +267,10 @@ func CommandViewResult() *cli.Command {
 		Aliases: []string{"r"},
 		Usage:   "View lottery draws / result",
 		Action: func(c *cli.Context) error {
-			sync()
+			err := sync()
+			if err != nil {
+				return err
+			}
 
 			db := app.Context.DB
 
@@ -384,81 +316,99 @@ func CommandViewResult() *cli.Command {
 	}
 }
 
+// BuyTicket buys one ticket of the lotto and returns the txid
+func BuyTicket(txId string, password string, promptFees bool) (string, error) {
+	walletInstance := app.Context.WalletInstance
+	scid := getSCID()
+	db := app.Context.DB
+
+	lotto, err := getLotto(db, txId)
+	if err != nil {
+		return "", err
+	}
+
+	ticketPrice := lotto.TicketPrice.Int64
+
+	userPasswordHash := ""
+
+	if lotto.PasswordHash.Valid && lotto.PasswordHash.String != "" && password != "" {
+		walletAddress, err := walletInstance.GetAddress()
+		if err != nil {
+			return "", err
+		}
+
+		owner := "" // TODO - get owner lotto address
+		hasher := crypto.SHA3_256.New()
+
+		// first hash
+		hasher.Write([]byte(strings.Join([]string{owner, fmt.Sprintf("%d", ticketPrice), password}, ".")))
+		userPasswordHash = hex.EncodeToString(hasher.Sum(nil))
+		hasher.Reset()
+
+		// second hash
+		hasher.Write([]byte(strings.Join([]string{txId, userPasswordHash}, ".")))
+		userPasswordHash = hex.EncodeToString(hasher.Sum(nil))
+		hasher.Reset()
+
+		// third hash
+		hasher.Write([]byte(strings.Join([]string{walletAddress, userPasswordHash}, ".")))
+		userPasswordHash = hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
+	if err != nil {
+		return "", err
+	}
+
+	return walletInstance.CallSmartContract(2, scid, "Play", []rpc.Argument{
+		{Name: "txId", DataType: rpc.DataString, Value: txId},
+		{Name: "userPasswordHash", DataType: rpc.DataString, Value: userPasswordHash},
+	}, []rpc.Transfer{
+		{
+			Burn:        uint64(ticketPrice),
+			Destination: randomAddresses.Address[0],
+		},
+	}, promptFees)
+}
+
+// GetLotto syncs commits and returns the lotto created by txId
+func GetLotto(txId string) (*Lotto, error) {
+	err := sync()
+	if err != nil {
+		return nil, err
+	}
+
+	return getLotto(app.Context.DB, txId)
+}
+
 func CommandBuyTicket() *cli.Command {
 	return &cli.Command{
 		Name:  "buy",
 		Usage: "Buy ticket",
 		Action: func(c *cli.Context) error {
-			walletInstance := app.Context.WalletInstance
-			scid := getSCID()
 			db := app.Context.DB
 
 			txId, err := promptTxId(c)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			lotto, err := getLotto(db, txId)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			ticketPrice := lotto.TicketPrice.Int64
-
-			userPasswordHash := ""
-
+			password := ""
 			if lotto.PasswordHash.Valid && lotto.PasswordHash.String != "" {
-				password, err := app.PromptPassword("Password")
-				if app.HandlePromptErr(err) {
-					return nil
-				}
-
-				if password != "" {
-					walletAddress, err := walletInstance.GetAddress()
-					if err != nil {
-						fmt.Println(err)
-						return nil
-					}
-
-					owner := "" // TODO - get owner lotto address
-					hasher := crypto.SHA3_256.New()
-
-					// first hash
-					hasher.Write([]byte(strings.Join([]string{owner, fmt.Sprintf("%d", ticketPrice), password}, ".")))
-					userPasswordHash = hex.EncodeToString(hasher.Sum(nil))
-					hasher.Reset()
-
-					// second hash
-					hasher.Write([]byte(strings.Join([]string{txId, userPasswordHash}, ".")))
-					userPasswordHash = hex.EncodeToString(hasher.Sum(nil))
-					hasher.Reset()
-
-					// third hash
-					hasher.Write([]byte(strings.Join([]string{walletAddress, userPasswordHash}, ".")))
-					userPasswordHash = hex.EncodeToString(hasher.Sum(nil))
+				password, err = app.PromptPassword("Password")
+				if err != nil {
+					return err
 				}
 			}
 
-			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
+			txid, err := BuyTicket(txId, password, true)
 			if err != nil {
-				fmt.Println(err)
-				return nil
-			}
-
-			txid, err := walletInstance.CallSmartContract(2, scid, "Play", []rpc.Argument{
-				{Name: "txId", DataType: rpc.DataString, Value: txId},
-				{Name: "userPasswordHash", DataType: rpc.DataString, Value: userPasswordHash},
-			}, []rpc.Transfer{
-				{
-					Burn:        uint64(ticketPrice),
-					Destination: randomAddresses.Address[0],
-				},
-			}, true)
-
-			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(txid)
@@ -477,23 +427,23 @@ func CommandCreateLotto() *cli.Command {
 			scid := getSCID()
 
 			maxTickets, err := app.PromptUInt("Max tickets", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			ticketPrice, err := app.PromptDero("Ticket price (in Dero)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			duration, err := app.PromptUInt("Duration (in seconds)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uniqueWalletBool, err := app.PromptYesNo("One ticker per wallet ?", true)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			uniqueWallet := 0
@@ -502,26 +452,25 @@ func CommandCreateLotto() *cli.Command {
 			}
 
 			password, err := app.PromptPassword("Password")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			startTimestamp, err := app.PromptUInt("Start timestamp (unix)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			baseReward, err := app.PromptDero("Base reward (in Dero)", 0)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			passwordHash := ""
 			if password != "" {
 				walletAddress, err := walletInstance.GetAddress()
 				if err != nil {
-					fmt.Println(err)
-					return nil
+					return err
 				}
 
 				hasher := crypto.SHA3_256.New()
@@ -533,8 +482,7 @@ func CommandCreateLotto() *cli.Command {
 
 			randomAddresses, err := walletInstance.Daemon.GetRandomAddresses(nil)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			txid, err := walletInstance.CallSmartContract(2, scid, "Create", []rpc.Argument{
@@ -552,8 +500,7 @@ func CommandCreateLotto() *cli.Command {
 			}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(txid)
@@ -574,14 +521,13 @@ func CommandCancelLotto() *cli.Command {
 			db := app.Context.DB
 
 			txId, err := promptTxId(c)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			_, err = getLotto(db, txId)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			txid, err := walletInstance.CallSmartContract(2, scid, "Cancel", []rpc.Argument{
@@ -589,8 +535,7 @@ func CommandCancelLotto() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(txid)
@@ -610,14 +555,13 @@ func CommandDrawLotto() *cli.Command {
 			db := app.Context.DB
 
 			txId, err := promptTxId(c)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			lotto, err := getLotto(db, txId)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			if time.Now().Unix() < lotto.DrawTimestamp.Int64 {
@@ -630,8 +574,7 @@ func CommandDrawLotto() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(txid)
@@ -651,28 +594,27 @@ func CommandClaimReward() *cli.Command {
 			db := app.Context.DB
 
 			txId, err := promptTxId(c)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			lotto, err := getLotto(db, txId)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			password := ""
 
 			if lotto.PasswordHash.Valid && lotto.PasswordHash.String != "" {
 				password, err = app.PromptPassword("Enter password")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
 			comment, err := app.Prompt("Enter comment (optional)", "")
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			txid, err := walletInstance.CallSmartContract(2, scid, "ClaimReward", []rpc.Argument{
@@ -682,8 +624,7 @@ func CommandClaimReward() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(txid)
@@ -698,20 +639,14 @@ func CommandViewLotto() *cli.Command {
 		Aliases: []string{"v"},
 		Usage:   "View lottery specifications",
 		Action: func(c *cli.Context) error {
-			sync()
-
-			db := app.Context.DB
-
 			txId, err := promptTxId(c)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
-			lotto, err := getLotto(db, txId)
-
+			lotto, err := GetLotto(txId)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			lotto.Print()
@@ -726,7 +661,10 @@ func CommandLiveLotto() *cli.Command {
 		Aliases: []string{"l"},
 		Usage:   "Available lottery that you can participate",
 		Action: func(c *cli.Context) error {
-			sync()
+			err := sync()
+			if err != nil {
+				return err
+			}
 
 			db := app.Context.DB
 
@@ -778,14 +716,16 @@ func CommandLottoTickets() *cli.Command {
 		Aliases: []string{"t"},
 		Usage:   "View lotto tickets",
 		Action: func(c *cli.Context) error {
-			sync()
+			err := sync()
+			if err != nil {
+				return err
+			}
 
 			db := app.Context.DB
 
 			txId, err := promptTxId(c)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			query := `
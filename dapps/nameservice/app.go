@@ -26,8 +26,8 @@ func CommandRegister() *cli.Command {
 			var err error
 			if username == "" {
 				username, err = app.Prompt("Enter name", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -37,11 +37,10 @@ func CommandRegister() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txid)
+			walletInstance.TxSent(txid)
 			return nil
 		},
 	}
@@ -62,8 +61,7 @@ func CommandNames() *cli.Command {
 			})
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			var names []Name
@@ -72,29 +72,35 @@ func (sn *SealNFT) Traits() string {
 	return strings.Join(traits, ", ")
 }
 
-func initData() {
-	query := `
-		create table if not exists dapps_seals_collection (
-			scid varchar primary key,
-			frozen_metadata boolean,
-			frozen_supply boolean,
-			supply bigint,
-			metadata string,
-			id integer,
-			rarity real,
-			trait_background varchar,
-			trait_base varchar,
-			trait_eyes varchar,
-			trait_hairAndHats varchar,
-			trait_shirts varchar,
-			trait_tattoo varchar,
-			trait_facialHair varchar
-		);
-	`
-
-	db := app.Context.DB
+// Migrations of the dapp tables - applied when the dapp is opened
+func Migrations() app.MigrationModule {
+	return app.MigrationModule{
+		Name: DAPP_NAME,
+		Migrations: []app.Migration{
+			{Version: 1, Name: "create tables", Up: app.MigrateExec(`
+				create table if not exists dapps_seals_collection (
+					scid varchar primary key,
+					frozen_metadata boolean,
+					frozen_supply boolean,
+					supply bigint,
+					metadata string,
+					id integer,
+					rarity real,
+					trait_background varchar,
+					trait_base varchar,
+					trait_eyes varchar,
+					trait_hairAndHats varchar,
+					trait_shirts varchar,
+					trait_tattoo varchar,
+					trait_facialHair varchar
+				);
+			`)},
+		},
+	}
+}
 
-	_, err := db.Exec(query)
+func initData() {
+	_, err := app.Context.Migrate(Migrations())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -123,14 +129,12 @@ func update() error {
 		Variables: true,
 	})
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return err
 	}
 
 	err = collection.Parse(collectionSCID, result)
 	if err != nil {
-		fmt.Println(err)
-		return nil
+		return err
 	}
 
 	for assetSCID := range collection.Assets {
@@ -260,8 +264,7 @@ func CommandCount() *cli.Command {
 			var count int
 			err := row.Scan(&count)
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			fmt.Println(count)
@@ -281,8 +284,8 @@ func CommandViewNFT() *cli.Command {
 
 			if nft == "" {
 				nft, err = app.Prompt("Enter nft", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -292,8 +295,7 @@ func CommandViewNFT() *cli.Command {
 			row := db.QueryRow(query, nft)
 			err = row.Err()
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			var id string
@@ -317,8 +319,8 @@ func CommandViewImage() *cli.Command {
 
 			if id == "" {
 				id, err = app.Prompt("Enter ID", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -4,15 +4,12 @@ import (
 	"fmt"
 	"log"
 	"regexp"
-	"strings"
 
 	"database/sql"
 
 	"github.com/deroproject/derohe/rpc"
 	"github.com/g45t345rt/derosphere/app"
-	"github.com/g45t345rt/derosphere/config"
-	"github.com/g45t345rt/derosphere/rpc_client"
-	"github.com/g45t345rt/derosphere/utils"
+	"github.com/g45t345rt/derosphere/commit_sync"
 	"github.com/urfave/cli/v2"
 )
 
@@ -33,134 +30,53 @@ func getSCID() string {
 	return SC_ID[app.Context.Config.Env]
 }
 
-func initData() {
-	sqlQuery := `
-		create table if not exists dapps_username (
-			wallet_address varchar primary key,
-			name varchar
-		);
-	`
-
-	db := app.Context.DB
-	_, err := db.Exec(sqlQuery)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	count := utils.Count{Filename: config.GetCountFilename(app.Context.Config.Env)}
-	err = count.Load()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	commitAt := count.Get(DAPP_NAME)
-	if commitAt == 0 {
-		sqlQuery = `
-			delete from dapps_username
-		`
-
-		_, err = db.Exec(sqlQuery)
-		if err != nil {
-			log.Fatal(err)
-		}
+// Migrations of the dapp tables - applied when the dapp is opened
+func Migrations() app.MigrationModule {
+	return app.MigrationModule{
+		Name: DAPP_NAME,
+		Migrations: []app.Migration{
+			{Version: 1, Name: "create tables", Up: app.MigrateExec(`
+				create table if not exists dapps_username (
+					wallet_address varchar primary key,
+					name varchar
+				);
+			`)},
+		},
 	}
 }
 
-func sync() {
-	daemon := app.Context.WalletInstance.Daemon
-	scid := getSCID()
-	commitCount := daemon.GetSCCommitCount(scid)
-	count := utils.Count{Filename: config.GetCountFilename(app.Context.Config.Env)}
-	err := count.Load()
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	commitAt := count.Get(DAPP_NAME)
-	chunk := uint64(1000)
-	db := app.Context.DB
-	nameKey, err := regexp.Compile(`state_name_(.+)`)
-	if err != nil {
-		log.Fatal(err)
-	}
-
-	tx, err := db.Begin()
+func initData() {
+	_, err := app.Context.Migrate(Migrations())
 	if err != nil {
 		log.Fatal(err)
 	}
-	defer tx.Rollback()
+}
 
-	sqlQuery := `
-		insert into dapps_username (wallet_address, name)
-		values (?,?)
-		on conflict(wallet_address) do update set name = ?
-	`
+var nameKey = regexp.MustCompile(`^state_name_(.+)`)
 
-	setTx, err := tx.Prepare(sqlQuery)
-	if err != nil {
-		log.Fatal(err)
+func syncEngine() *commit_sync.Engine {
+	return &commit_sync.Engine{
+		Name:   DAPP_NAME,
+		SCID:   getSCID(),
+		Format: commit_sync.FORMAT_V1,
+		Mappings: []commit_sync.Mapping{
+			{Pattern: nameKey, Table: "dapps_username", Keys: []string{"wallet_address"}, Column: "name", OnDelete: commit_sync.DELETE_ROW},
+		},
 	}
+}
 
-	defer setTx.Close()
-
-	sqlQuery = `
-		delete from dapps_username where wallet_address == ?
-	`
+func sync() error {
+	return app.SyncCommits(syncEngine())
+}
 
-	delTx, err := tx.Prepare(sqlQuery)
-	if err != nil {
-		log.Fatal(err)
+// SyncEngines creates the tables and returns the engines to sync in the background
+func SyncEngines() []*commit_sync.Engine {
+	if getSCID() == "" {
+		return nil
 	}
 
-	defer setTx.Close()
-
-	for i := commitAt; i < commitCount; i += chunk {
-		var commits []rpc_client.Commit
-		end := i + chunk
-		if end > commitCount {
-			commitAt = commitCount
-			commits = daemon.GetSCCommits(scid, i, commitCount)
-		} else {
-			commitAt = end
-			commits = daemon.GetSCCommits(scid, i, commitAt)
-		}
-
-		for _, commit := range commits {
-			key := commit.Key
-
-			if strings.HasPrefix(commit.Key, "state_name_") {
-				walletAddress := nameKey.ReplaceAllString(key, "$1")
-				if commit.Action == "S" {
-					_, err := setTx.Exec(walletAddress, commit.Value, commit.Value)
-					if err != nil {
-						log.Fatal(err)
-					}
-
-					continue
-				}
-
-				if commit.Action == "D" {
-					_, err := delTx.Exec(walletAddress)
-					if err != nil {
-						log.Fatal(err)
-					}
-
-					continue
-				}
-			}
-		}
-
-		err := tx.Commit()
-		if err != nil {
-			log.Fatal(err)
-		}
-
-		count.Set(DAPP_NAME, commitAt)
-		err = count.Save()
-		if err != nil {
-			log.Fatal(err)
-		}
-	}
+	initData()
+	return []*commit_sync.Engine{syncEngine()}
 }
 
 func CommandRegister() *cli.Command {
@@ -174,8 +90,8 @@ func CommandRegister() *cli.Command {
 
 			if username == "" {
 				username, err = app.Prompt("Enter username", "")
-				if app.HandlePromptErr(err) {
-					return nil
+				if err != nil {
+					return err
 				}
 			}
 
@@ -187,11 +103,10 @@ func CommandRegister() *cli.Command {
 			}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txid)
+			walletInstance.TxSent(txid)
 			return nil
 		},
 	}
@@ -206,8 +121,8 @@ func CommandUnRegister() *cli.Command {
 			walletInstance := app.Context.WalletInstance
 
 			yes, err := app.PromptYesNo("Are you sure?", false)
-			if app.HandlePromptErr(err) {
-				return nil
+			if err != nil {
+				return err
 			}
 
 			if !yes {
@@ -218,11 +133,10 @@ func CommandUnRegister() *cli.Command {
 			txid, err := walletInstance.CallSmartContract(2, scid, "Unregister", []rpc.Argument{}, []rpc.Transfer{}, true)
 
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
-			walletInstance.RunTxChecker(txid)
+			walletInstance.TxSent(txid)
 			return nil
 		},
 	}
@@ -234,7 +148,10 @@ func CommandListNames() *cli.Command {
 		Aliases: []string{"l"},
 		Usage:   "List of registered names",
 		Action: func(c *cli.Context) error {
-			sync()
+			err := sync()
+			if err != nil {
+				return err
+			}
 
 			db := app.Context.DB
 
@@ -275,13 +192,15 @@ func CommandName() *cli.Command {
 		Usage:   "What is my username?",
 		Aliases: []string{"n"},
 		Action: func(c *cli.Context) error {
-			sync()
+			err := sync()
+			if err != nil {
+				return err
+			}
 
 			db := app.Context.DB
 			walletAddress, err := app.Context.WalletInstance.GetAddress()
 			if err != nil {
-				fmt.Println(err)
-				return nil
+				return err
 			}
 
 			sqlQuery := `select name from dapps_username where wallet_address == ?`
@@ -2,14 +2,11 @@ package rpc_client
 
 import (
 	"fmt"
-	"log"
 	"strconv"
 	"strings"
 
 	"encoding/hex"
 	"encoding/json"
-
-	"github.com/deroproject/derohe/rpc"
 )
 
 type Commit struct {
@@ -18,51 +15,44 @@ type Commit struct {
 	Value  string
 }
 
-func (d *Daemon) GetSCCommitCount(scid string) uint64 {
-	result, err := d.GetSC(&rpc.GetSC_Params{
-		SCID:       scid,
-		Variables:  false,
-		Code:       false,
-		KeysString: []string{"commit_count"},
-	})
-
+// Commits are read at topoheight so a sync can be tied to a block - 0 is the current topoheight
+func (d *Daemon) GetSCCommitCount(scid string, topoheight int64) (uint64, error) {
+	values, err := d.getSCValuesAt(scid, []string{"commit_count"}, topoheight)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
-	commitCount, err := strconv.ParseUint(result.ValuesString[0], 10, 64)
+	commitCount, err := strconv.ParseUint(values[0], 10, 64)
 	if err != nil {
-		log.Fatal(err)
+		return 0, fmt.Errorf("%w: %s", ErrDecode, err)
 	}
 
-	return commitCount
+	return commitCount, nil
 }
 
-func (d *Daemon) GetSCCommits(scid string, start uint64, end uint64) []Commit {
+func (d *Daemon) GetSCCommits(scid string, start uint64, end uint64, topoheight int64) ([]Commit, error) {
 	commitKeys := []string{}
 	for i := start; i < end; i++ {
 		commitKeys = append(commitKeys, fmt.Sprintf("commit_%d", i))
 	}
 
-	result, err := d.GetSC(&rpc.GetSC_Params{
-		SCID:       scid,
-		Variables:  false,
-		Code:       false,
-		KeysString: commitKeys,
-	})
-
+	values, err := d.getSCValuesAt(scid, commitKeys, topoheight)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	commits := []Commit{}
-	for _, value := range result.ValuesString {
+	for _, value := range values {
 		valuestring, err := hex.DecodeString(value)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
 		}
 
 		values := strings.Split(string(valuestring), "::")
+		if len(values) < 3 {
+			return nil, fmt.Errorf("%w: invalid commit [%s]", ErrDecode, valuestring)
+		}
+
 		commits = append(commits, Commit{
 			Action: values[0],
 			Key:    values[1],
@@ -70,57 +60,45 @@ func (d *Daemon) GetSCCommits(scid string, start uint64, end uint64) []Commit {
 		})
 	}
 
-	return commits
+	return commits, nil
 }
 
-func (d *Daemon) GetSCCommitCountV2(scid string) (uint64, error) {
-	result, err := d.GetSC(&rpc.GetSC_Params{
-		SCID:       scid,
-		Variables:  false,
-		Code:       false,
-		KeysString: []string{"commit_ctr"},
-	})
-
+func (d *Daemon) GetSCCommitCountV2(scid string, topoheight int64) (uint64, error) {
+	values, err := d.getSCValuesAt(scid, []string{"commit_ctr"}, topoheight)
 	if err != nil {
 		return 0, err
 	}
 
-	commitCounter, err := strconv.ParseUint(result.ValuesString[0], 10, 64)
+	commitCounter, err := strconv.ParseUint(values[0], 10, 64)
 	if err != nil {
-		return 0, err
+		return 0, fmt.Errorf("%w: %s", ErrDecode, err)
 	}
 
 	return commitCounter, nil
 }
 
-func (d *Daemon) GetSCCommitsV2(scid string, start uint64, end uint64) ([]map[string]interface{}, error) {
+func (d *Daemon) GetSCCommitsV2(scid string, start uint64, end uint64, topoheight int64) ([]map[string]interface{}, error) {
 	commitKeys := []string{}
 	for i := start; i < end; i++ {
 		commitKeys = append(commitKeys, fmt.Sprintf("commit_%d", i))
 	}
 
-	result, err := d.GetSC(&rpc.GetSC_Params{
-		SCID:       scid,
-		Variables:  false,
-		Code:       false,
-		KeysString: commitKeys,
-	})
-
+	values, err := d.getSCValuesAt(scid, commitKeys, topoheight)
 	if err != nil {
 		return nil, err
 	}
 
 	var commits []map[string]interface{}
-	for _, hexValue := range result.ValuesString {
+	for _, hexValue := range values {
 		value, err := hex.DecodeString(hexValue)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
 		}
 
 		var commit map[string]interface{}
 		err = json.Unmarshal(value, &commit)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
 		}
 
 		commits = append(commits, commit)
@@ -1,39 +1,73 @@
 package rpc_client
 
 import (
+	"errors"
 	"fmt"
+	"sync"
 
 	"github.com/deroproject/derohe/rpc"
 	"github.com/ybbus/jsonrpc/v2"
 )
 
 type Daemon struct {
-	Address  string
-	Endpoint string
-	Client   jsonrpc.RPCClient
+	Address      string
+	Endpoint     string
+	Client       jsonrpc.RPCClient
+	Testnet      bool                 // network expected from nodes - mainnet nodes are skipped if true
+	MaxHeightLag int64                // skip nodes behind the highest node by more than this
+	OnSwitch     func(address string) // called when the pool switched to another node
+	nodes        []string             // ordered node addresses - the first healthy node is used
+	lock         sync.RWMutex
+	stop         chan bool
 }
 
 func (d *Daemon) SetClient(address string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
 	d.Address = address
 	d.Endpoint = fmt.Sprintf("%s/json_rpc", d.Address)
 	d.Client = jsonrpc.NewClient(d.Endpoint)
 }
 
+// call runs the rpc method on the current node and retries on the next healthy node if the current one is unreachable
+func (d *Daemon) call(out interface{}, method string, params ...interface{}) error {
+	d.lock.RLock()
+	client := d.Client
+	d.lock.RUnlock()
+
+	err := wrapCallErr(client.CallFor(out, method, params...))
+	if !errors.Is(err, ErrNodeUnavailable) || len(d.Nodes()) < 2 {
+		return err
+	}
+
+	switched, checkErr := d.CheckNodes()
+	if checkErr != nil || !switched {
+		return err
+	}
+
+	d.lock.RLock()
+	client = d.Client
+	d.lock.RUnlock()
+
+	return wrapCallErr(client.CallFor(out, method, params...))
+}
+
 func (d *Daemon) Ping() (string, error) {
 	var result string
-	err := d.Client.CallFor(&result, "DERO.Ping")
+	err := d.call(&result, "DERO.Ping")
 	return result, err
 }
 
 func (d *Daemon) GetInfo() (*rpc.GetInfo_Result, error) {
 	var result *rpc.GetInfo_Result
-	err := d.Client.CallFor(&result, "DERO.GetInfo")
+	err := d.call(&result, "DERO.GetInfo")
 	return result, err
 }
 
 func (d *Daemon) GetSC(params *rpc.GetSC_Params) (*rpc.GetSC_Result, error) {
 	var result *rpc.GetSC_Result
-	err := d.Client.CallFor(&result, "DERO.GetSC", params)
+	err := d.call(&result, "DERO.GetSC", params)
 
 	if err != nil {
 		return nil, err
@@ -44,7 +78,7 @@ func (d *Daemon) GetSC(params *rpc.GetSC_Params) (*rpc.GetSC_Result, error) {
 
 func (d *Daemon) GetGasEstimate(params *rpc.GasEstimate_Params) (*rpc.GasEstimate_Result, error) {
 	var result *rpc.GasEstimate_Result
-	err := d.Client.CallFor(&result, "DERO.GetGasEstimate", params)
+	err := d.call(&result, "DERO.GetGasEstimate", params)
 	if err != nil {
 		return nil, err
 	}
@@ -54,7 +88,7 @@ func (d *Daemon) GetGasEstimate(params *rpc.GasEstimate_Params) (*rpc.GasEstimat
 
 func (d *Daemon) GetRandomAddresses(params *rpc.GetRandomAddress_Params) (*rpc.GetRandomAddress_Result, error) {
 	var result *rpc.GetRandomAddress_Result
-	err := d.Client.CallFor(&result, "DERO.GetRandomAddress", params)
+	err := d.call(&result, "DERO.GetRandomAddress", params)
 	if err != nil {
 		return nil, err
 	}
@@ -64,7 +98,7 @@ func (d *Daemon) GetRandomAddresses(params *rpc.GetRandomAddress_Params) (*rpc.G
 
 func (d *Daemon) GetHeight() (*rpc.Daemon_GetHeight_Result, error) {
 	var result *rpc.Daemon_GetHeight_Result
-	err := d.Client.CallFor(&result, "DERO.GetHeight")
+	err := d.call(&result, "DERO.GetHeight")
 	if err != nil {
 		return nil, err
 	}
@@ -74,7 +108,7 @@ func (d *Daemon) GetHeight() (*rpc.Daemon_GetHeight_Result, error) {
 
 func (d *Daemon) GetBlock(params *rpc.GetBlock_Params) (*rpc.GetBlock_Result, error) {
 	var result *rpc.GetBlock_Result
-	err := d.Client.CallFor(&result, "DERO.GetBlock", params)
+	err := d.call(&result, "DERO.GetBlock", params)
 	if err != nil {
 		return nil, err
 	}
@@ -84,7 +118,7 @@ func (d *Daemon) GetBlock(params *rpc.GetBlock_Params) (*rpc.GetBlock_Result, er
 
 func (d *Daemon) GetBlockHeaderByTopoHeight(params *rpc.GetBlockHeaderByTopoHeight_Params) (*rpc.GetBlockHeaderByHeight_Result, error) {
 	var result *rpc.GetBlockHeaderByHeight_Result
-	err := d.Client.CallFor(&result, "DERO.GetBlockHeaderByTopoHeight", params)
+	err := d.call(&result, "DERO.GetBlockHeaderByTopoHeight", params)
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +128,7 @@ func (d *Daemon) GetBlockHeaderByTopoHeight(params *rpc.GetBlockHeaderByTopoHeig
 
 func (d *Daemon) GetTransaction(params *rpc.GetTransaction_Params) (*rpc.GetTransaction_Result, error) {
 	var result *rpc.GetTransaction_Result
-	err := d.Client.CallFor(&result, "DERO.GetTransaction", params)
+	err := d.call(&result, "DERO.GetTransaction", params)
 	if err != nil {
 		return nil, err
 	}
@@ -104,7 +138,7 @@ func (d *Daemon) GetTransaction(params *rpc.GetTransaction_Params) (*rpc.GetTran
 
 func (d *Daemon) NameToAddress(params *rpc.NameToAddress_Params) (*rpc.NameToAddress_Result, error) {
 	var result *rpc.NameToAddress_Result
-	err := d.Client.CallFor(&result, "DERO.NameToAddress", params)
+	err := d.call(&result, "DERO.NameToAddress", params)
 	if err != nil {
 		return nil, err
 	}
@@ -114,7 +148,17 @@ func (d *Daemon) NameToAddress(params *rpc.NameToAddress_Params) (*rpc.NameToAdd
 
 func (d *Daemon) GetEncrypedBalance(params *rpc.GetEncryptedBalance_Params) (*rpc.GetEncryptedBalance_Result, error) {
 	var result *rpc.GetEncryptedBalance_Result
-	err := d.Client.CallFor(&result, "DERO.GetEncryptedBalance", params)
+	err := d.call(&result, "DERO.GetEncryptedBalance", params)
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+func (d *Daemon) SendRawTransaction(params *rpc.SendRawTransaction_Params) (*rpc.SendRawTransaction_Result, error) {
+	var result *rpc.SendRawTransaction_Result
+	err := d.call(&result, "DERO.SendRawTransaction", params)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,64 @@
+package rpc_client
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/deroproject/derohe/rpc"
+	"github.com/ybbus/jsonrpc/v2"
+)
+
+var (
+	ErrSCNotFound      = errors.New("smart contract not found")
+	ErrKeyMissing      = errors.New("key missing")
+	ErrNodeUnavailable = errors.New("node unavailable")
+	ErrDecode          = errors.New("can't decode value")
+)
+
+// wrapCallErr marks transport errors as ErrNodeUnavailable - errors returned by the daemon itself are kept as is
+func wrapCallErr(err error) error {
+	if err == nil || errors.Is(err, ErrNodeUnavailable) {
+		return err
+	}
+
+	var rpcErr *jsonrpc.RPCError
+	if errors.As(err, &rpcErr) {
+		return err
+	}
+
+	return fmt.Errorf("%w: %s", ErrNodeUnavailable, err)
+}
+
+// getSCValues returns the string values of keys and fails if the smart contract or one of the keys does not exist
+func (d *Daemon) getSCValues(scid string, keys []string) ([]string, error) {
+	return d.getSCValuesAt(scid, keys, 0)
+}
+
+// getSCValuesAt is getSCValues at a specific topoheight - 0 is the current topoheight
+func (d *Daemon) getSCValuesAt(scid string, keys []string, topoheight int64) ([]string, error) {
+	result, err := d.GetSC(&rpc.GetSC_Params{
+		SCID:       scid,
+		Variables:  false,
+		Code:       false,
+		TopoHeight: topoheight,
+		KeysString: keys,
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the daemon returns no values at all if the smart contract tree does not exist
+	if len(result.ValuesString) != len(keys) {
+		return nil, fmt.Errorf("%w: %s", ErrSCNotFound, scid)
+	}
+
+	for i, value := range result.ValuesString {
+		if strings.HasPrefix(value, "NOT AVAILABLE") {
+			return nil, fmt.Errorf("%w: %s in %s", ErrKeyMissing, keys[i], scid)
+		}
+	}
+
+	return result.ValuesString, nil
+}
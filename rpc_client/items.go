@@ -2,35 +2,26 @@ package rpc_client
 
 import (
 	"fmt"
-	"log"
 	"strconv"
 
 	"encoding/hex"
-
-	"github.com/deroproject/derohe/rpc"
 )
 
-func (d *Daemon) GetSCItemCount(scid string, key string) uint64 {
-	result, err := d.GetSC(&rpc.GetSC_Params{
-		SCID:       scid,
-		Variables:  false,
-		Code:       false,
-		KeysString: []string{key},
-	})
-
+func (d *Daemon) GetSCItemCount(scid string, key string) (uint64, error) {
+	values, err := d.getSCValues(scid, []string{key})
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 
-	count, err := strconv.ParseUint(result.ValuesString[0], 10, 64)
+	count, err := strconv.ParseUint(values[0], 10, 64)
 	if err != nil {
-		log.Fatal(err)
+		return 0, fmt.Errorf("%w: %s", ErrDecode, err)
 	}
 
-	return count
+	return count, nil
 }
 
-func (d *Daemon) GetSCKeyValues(scid string, prefixKey string, start uint64, end uint64, columns []string) map[string]string {
+func (d *Daemon) GetSCKeyValues(scid string, prefixKey string, start uint64, end uint64, columns []string) (map[string]string, error) {
 	keys := []string{}
 	for i := start; i < end; i++ {
 		if len(columns) == 0 {
@@ -42,27 +33,21 @@ func (d *Daemon) GetSCKeyValues(scid string, prefixKey string, start uint64, end
 		}
 	}
 
-	result, err := d.GetSC(&rpc.GetSC_Params{
-		SCID:       scid,
-		Variables:  false,
-		Code:       false,
-		KeysString: keys,
-	})
-
+	values, err := d.getSCValues(scid, keys)
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
 
 	keyValues := make(map[string]string)
-	for index, value := range result.ValuesString {
+	for index, value := range values {
 		key := keys[index]
 		valuestring, err := hex.DecodeString(value)
 		if err != nil {
-			log.Fatal(err)
+			return nil, fmt.Errorf("%w: %s", ErrDecode, err)
 		}
 
 		keyValues[key] = string(valuestring)
 	}
 
-	return keyValues
+	return keyValues, nil
 }
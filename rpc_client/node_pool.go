@@ -0,0 +1,139 @@
+package rpc_client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/deroproject/derohe/rpc"
+	"github.com/ybbus/jsonrpc/v2"
+)
+
+var NODE_CHECK_TIMEOUT = 5 * time.Second
+
+type NodeStatus struct {
+	Address string
+	Height  int64
+	Testnet bool
+	Err     error
+	Healthy bool
+}
+
+// GetNodeStatus queries a node with a short timeout - does not use the pool
+func GetNodeStatus(address string) NodeStatus {
+	status := NodeStatus{Address: address}
+	client := jsonrpc.NewClientWithOpts(fmt.Sprintf("%s/json_rpc", address), &jsonrpc.RPCClientOpts{
+		HTTPClient: &http.Client{Timeout: NODE_CHECK_TIMEOUT},
+	})
+
+	var result *rpc.GetInfo_Result
+	err := client.CallFor(&result, "DERO.GetInfo")
+	if err != nil {
+		status.Err = wrapCallErr(err)
+		return status
+	}
+
+	status.Height = result.Height
+	status.Testnet = result.Testnet
+	return status
+}
+
+func (d *Daemon) SetNodes(addresses []string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.nodes = addresses
+}
+
+func (d *Daemon) Nodes() []string {
+	d.lock.RLock()
+	defer d.lock.RUnlock()
+	return d.nodes
+}
+
+// NodesStatus checks every node of the pool and marks the ones on the right network and close to the highest height as healthy
+func (d *Daemon) NodesStatus() []NodeStatus {
+	var statuses []NodeStatus
+	maxHeight := int64(0)
+	for _, address := range d.Nodes() {
+		status := GetNodeStatus(address)
+		if status.Err == nil && status.Testnet == d.Testnet && status.Height > maxHeight {
+			maxHeight = status.Height
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	for i := range statuses {
+		status := &statuses[i]
+		if status.Err != nil {
+			continue
+		}
+
+		if status.Testnet != d.Testnet {
+			status.Err = fmt.Errorf("wrong network (testnet: %t)", status.Testnet)
+			continue
+		}
+
+		if status.Height < maxHeight-d.MaxHeightLag {
+			status.Err = fmt.Errorf("behind by %d blocks", maxHeight-status.Height)
+			continue
+		}
+
+		status.Healthy = true
+	}
+
+	return statuses
+}
+
+// CheckNodes switches to the first healthy node of the pool
+func (d *Daemon) CheckNodes() (switched bool, err error) {
+	for _, status := range d.NodesStatus() {
+		if !status.Healthy {
+			continue
+		}
+
+		d.lock.RLock()
+		current := d.Address
+		d.lock.RUnlock()
+
+		if status.Address == current {
+			return false, nil
+		}
+
+		d.SetClient(status.Address)
+		if d.OnSwitch != nil {
+			d.OnSwitch(status.Address)
+		}
+
+		return true, nil
+	}
+
+	return false, fmt.Errorf("%w: no healthy node in pool", ErrNodeUnavailable)
+}
+
+func (d *Daemon) StartHealthCheck(interval time.Duration) {
+	d.StopHealthCheck()
+
+	stop := make(chan bool)
+	d.stop = stop
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				d.CheckNodes()
+			}
+		}
+	}()
+}
+
+func (d *Daemon) StopHealthCheck() {
+	if d.stop != nil {
+		close(d.stop)
+		d.stop = nil
+	}
+}
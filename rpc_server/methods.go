@@ -0,0 +1,251 @@
+package rpc_server
+
+import (
+	"context"
+
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/deroproject/derohe/cryptography/crypto"
+	"github.com/deroproject/derohe/rpc"
+	"github.com/g45t345rt/derosphere/app"
+	"github.com/g45t345rt/derosphere/dapps/asset_trade"
+	"github.com/g45t345rt/derosphere/dapps/g45_sc"
+	"github.com/g45t345rt/derosphere/dapps/lotto"
+)
+
+type method struct {
+	handler    handler.Func
+	movesFunds bool // confirm policy is applied before the call
+}
+
+var methods = map[string]method{
+	"Wallet.Address":        {handler: handler.New(WalletAddress)},
+	"Wallet.Balance":        {handler: handler.New(WalletBalance)},
+	"Wallet.Height":         {handler: handler.New(WalletHeight)},
+	"Wallet.Transfer":       {handler: handler.New(WalletTransfer), movesFunds: true},
+	"AssetTrade.ListOrders": {handler: handler.New(AssetTradeListOrders)},
+	"Lotto.View":            {handler: handler.New(LottoView)},
+	"Lotto.BuyTicket":       {handler: handler.New(LottoBuyTicket), movesFunds: true},
+	"G45.View":              {handler: handler.New(G45View)},
+}
+
+// DEFAULT_ALLOW contains the methods that only read data
+var DEFAULT_ALLOW = []string{"Wallet.Address", "Wallet.Balance", "Wallet.Height", "AssetTrade.ListOrders", "Lotto.View", "G45.View"}
+
+type TxResult struct {
+	TxId string `json:"txid"`
+}
+
+func openWallet() (*app.WalletInstance, error) {
+	walletInstance := app.Context.WalletInstance
+	if walletInstance == nil {
+		return nil, app.ErrWalletClosed
+	}
+
+	return walletInstance, nil
+}
+
+func WalletAddress(ctx context.Context) (string, error) {
+	walletInstance, err := openWallet()
+	if err != nil {
+		return "", err
+	}
+
+	return walletInstance.GetAddress()
+}
+
+type WalletBalanceParams struct {
+	SCID string `json:"scid"`
+}
+
+type WalletBalanceResult struct {
+	Balance uint64 `json:"balance"`
+}
+
+func WalletBalance(ctx context.Context, params WalletBalanceParams) (*WalletBalanceResult, error) {
+	walletInstance, err := openWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := walletInstance.GetBalance(crypto.HashHexToHash(params.SCID))
+	if err != nil {
+		return nil, err
+	}
+
+	return &WalletBalanceResult{Balance: balance}, nil
+}
+
+func WalletHeight(ctx context.Context) (uint64, error) {
+	walletInstance, err := openWallet()
+	if err != nil {
+		return 0, err
+	}
+
+	return walletInstance.GetHeight()
+}
+
+type WalletTransferParams struct {
+	SCID        string `json:"scid"`
+	Destination string `json:"destination"`
+	Amount      uint64 `json:"amount"`
+	Ringsize    uint64 `json:"ringsize"`
+	Comment     string `json:"comment"`
+}
+
+func WalletTransfer(ctx context.Context, params WalletTransferParams) (*TxResult, error) {
+	walletInstance, err := openWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	transfer := rpc.Transfer{
+		SCID:        crypto.HashHexToHash(params.SCID),
+		Destination: params.Destination,
+		Amount:      params.Amount,
+	}
+
+	if params.Comment != "" {
+		transfer.Payload_RPC = rpc.Arguments{
+			{Name: rpc.RPC_COMMENT, DataType: rpc.DataString, Value: params.Comment},
+		}
+	}
+
+	ringsize := params.Ringsize
+	if ringsize == 0 {
+		ringsize = 2
+	}
+
+	txid, err := walletInstance.Transfer(&rpc.Transfer_Params{
+		Ringsize:  ringsize,
+		Transfers: []rpc.Transfer{transfer},
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxResult{TxId: txid}, nil
+}
+
+type OrderResult struct {
+	Id           int64  `json:"id"`
+	AssetAmount  int64  `json:"assetAmount"`
+	AssetId      string `json:"assetId"`
+	PriceAssetId string `json:"priceAssetId"`
+	UnitPrice    int64  `json:"unitPrice"`
+	Creator      string `json:"creator"`
+	Timestamp    int64  `json:"timestamp"`
+}
+
+func AssetTradeListOrders(ctx context.Context) ([]OrderResult, error) {
+	_, err := openWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := asset_trade.ListOrders()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []OrderResult{}
+	for _, order := range orders {
+		results = append(results, OrderResult{
+			Id:           order.Id.Int64,
+			AssetAmount:  order.AssetAmount.Int64,
+			AssetId:      order.AssetId.String,
+			PriceAssetId: order.PriceAssetId.String,
+			UnitPrice:    order.UnitPrice.Int64,
+			Creator:      order.Creator.String,
+			Timestamp:    order.Timestamp.Int64,
+		})
+	}
+
+	return results, nil
+}
+
+type LottoParams struct {
+	TxId     string `json:"txid"`
+	Password string `json:"password"`
+}
+
+type LottoResult struct {
+	TxId           string `json:"txid"`
+	Owner          string `json:"owner"`
+	MaxTickets     int64  `json:"maxTickets"`
+	TicketCount    int64  `json:"ticketCount"`
+	TicketPrice    int64  `json:"ticketPrice"`
+	BaseReward     int64  `json:"baseReward"`
+	UniqueWallet   bool   `json:"uniqueWallet"`
+	PasswordLock   bool   `json:"passwordLock"`
+	StartTimestamp int64  `json:"startTimestamp"`
+	DrawTimestamp  int64  `json:"drawTimestamp"`
+	Winner         string `json:"winner"`
+	WinningTicket  int64  `json:"winningTicket"`
+	Claimed        bool   `json:"claimed"`
+}
+
+func LottoView(ctx context.Context, params LottoParams) (*LottoResult, error) {
+	_, err := openWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := lotto.GetLotto(params.TxId)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LottoResult{
+		TxId:           l.TxId.String,
+		Owner:          l.Owner.String,
+		MaxTickets:     l.MaxTickets.Int64,
+		TicketCount:    l.TicketCount.Int64,
+		TicketPrice:    l.TicketPrice.Int64,
+		BaseReward:     l.BaseReward.Int64,
+		UniqueWallet:   l.UniqueWallet.Bool,
+		PasswordLock:   l.PasswordHash.Valid && l.PasswordHash.String != "",
+		StartTimestamp: l.StartTimestamp.Int64,
+		DrawTimestamp:  l.DrawTimestamp.Int64,
+		Winner:         l.Winner.String,
+		WinningTicket:  l.WinningTicket.Int64,
+		Claimed:        l.ClaimTimestamp.Valid,
+	}, nil
+}
+
+func LottoBuyTicket(ctx context.Context, params LottoParams) (*TxResult, error) {
+	_, err := openWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	txid, err := lotto.BuyTicket(params.TxId, params.Password, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxResult{TxId: txid}, nil
+}
+
+type G45ViewParams struct {
+	SCID string `json:"scid"`
+}
+
+type G45ViewResult struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+func G45View(ctx context.Context, params G45ViewParams) (*G45ViewResult, error) {
+	_, err := openWallet()
+	if err != nil {
+		return nil, err
+	}
+
+	scType, data, err := g45_sc.View(params.SCID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &G45ViewResult{Type: scType, Data: data}, nil
+}
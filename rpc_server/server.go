@@ -0,0 +1,183 @@
+package rpc_server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/creachadair/jrpc2"
+	"github.com/creachadair/jrpc2/code"
+	"github.com/creachadair/jrpc2/handler"
+	"github.com/creachadair/jrpc2/jhttp"
+	"github.com/g45t345rt/derosphere/app"
+)
+
+const (
+	CONFIRM_DENY   = "deny"   // methods moving funds always fail
+	CONFIRM_PROMPT = "prompt" // ask in the terminal before each call
+	CONFIRM_AUTO   = "auto"   // methods moving funds run without asking
+)
+
+var CONFIRM_POLICIES = []string{CONFIRM_DENY, CONFIRM_PROMPT, CONFIRM_AUTO}
+
+var ErrMethodNotAllowed = jrpc2.Errorf(code.Code(-32001), "method not allowed")
+var ErrCallDenied = jrpc2.Errorf(code.Code(-32002), "call denied by confirm policy")
+
+func IsValidConfirmPolicy(policy string) bool {
+	for _, p := range CONFIRM_POLICIES {
+		if p == policy {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Server struct {
+	Address string
+	Token   string
+	Allow   []string // method names or * for all methods
+	Confirm string
+
+	allowed    map[string]bool
+	httpServer *http.Server
+	bridge     jhttp.Bridge
+}
+
+// Methods returns every method name exposed by the server
+func Methods() []string {
+	var names []string
+	for name := range methods {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Assign implements jrpc2.Assigner and applies the allowlist and confirm policy
+func (s *Server) Assign(ctx context.Context, name string) jrpc2.Handler {
+	m, ok := methods[name]
+	if !ok {
+		return nil
+	}
+
+	if !s.allowed["*"] && !s.allowed[name] {
+		return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+			return nil, ErrMethodNotAllowed
+		})
+	}
+
+	if !m.movesFunds {
+		return m.handler
+	}
+
+	return handler.Func(func(ctx context.Context, req *jrpc2.Request) (interface{}, error) {
+		err := s.confirm(req)
+		if err != nil {
+			return nil, err
+		}
+
+		return m.handler(ctx, req)
+	})
+}
+
+func (s *Server) Names() []string {
+	var names []string
+	for _, name := range Methods() {
+		if s.allowed["*"] || s.allowed[name] {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+func (s *Server) confirm(req *jrpc2.Request) error {
+	switch s.Confirm {
+	case CONFIRM_AUTO:
+		return nil
+	case CONFIRM_PROMPT:
+		var params json.RawMessage
+		req.UnmarshalParams(&params)
+
+		yes, err := app.PromptYesNo(fmt.Sprintf("Allow rpc call %s %s?", req.Method(), string(params)), false)
+		if err != nil || !yes {
+			return ErrCallDenied
+		}
+
+		return nil
+	}
+
+	return ErrCallDenied
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+
+	token := strings.TrimPrefix(auth, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.Token)) == 1
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "Authorization Required", http.StatusUnauthorized)
+		return
+	}
+
+	s.bridge.ServeHTTP(w, r)
+}
+
+// Start listens in the background - calls are handled one at a time since they share the wallet, db and prompt
+func (s *Server) Start() error {
+	if s.Token == "" {
+		return errors.New("token is required")
+	}
+
+	if !IsValidConfirmPolicy(s.Confirm) {
+		return fmt.Errorf("invalid confirm policy [%s] - use %s", s.Confirm, strings.Join(CONFIRM_POLICIES, ", "))
+	}
+
+	s.allowed = make(map[string]bool)
+	for _, name := range s.Allow {
+		if name != "*" {
+			_, ok := methods[name]
+			if !ok {
+				return fmt.Errorf("unknown method [%s]", name)
+			}
+		}
+
+		s.allowed[name] = true
+	}
+
+	s.bridge = jhttp.NewBridge(s, &jhttp.BridgeOptions{
+		Server: &jrpc2.ServerOptions{Concurrency: 1, DisableBuiltin: true},
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/json_rpc", s)
+
+	listener, err := net.Listen("tcp", s.Address)
+	if err != nil {
+		return err
+	}
+
+	s.httpServer = &http.Server{Handler: mux}
+	go s.httpServer.Serve(listener)
+	return nil
+}
+
+func (s *Server) Stop() error {
+	s.bridge.Close()
+	return s.httpServer.Shutdown(context.Background())
+}
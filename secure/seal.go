@@ -0,0 +1,120 @@
+package secure
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var (
+	ErrInvalidPassword = errors.New("invalid master password")
+	ErrNotSealed       = errors.New("file is not sealed")
+)
+
+// sealed file = magic + salt + nonce + xchacha20-poly1305(data)
+var magic = []byte("DSPHERE1")
+
+const SALT_SIZE = 16
+
+type Key struct {
+	Salt  []byte
+	value []byte
+}
+
+func DeriveKey(password string, salt []byte) *Key {
+	return &Key{
+		Salt:  salt,
+		value: argon2.IDKey([]byte(password), salt, 1, 64*1024, 4, chacha20poly1305.KeySize),
+	}
+}
+
+// NewKey derives a key from password with a random salt
+func NewKey(password string) (*Key, error) {
+	salt := make([]byte, SALT_SIZE)
+	_, err := rand.Read(salt)
+	if err != nil {
+		return nil, err
+	}
+
+	return DeriveKey(password, salt), nil
+}
+
+// Equal checks if password derives the same key - used to confirm the current master password
+func (k *Key) Equal(password string) bool {
+	other := DeriveKey(password, k.Salt)
+	return subtle.ConstantTimeCompare(k.value, other.value) == 1
+}
+
+func IsSealed(data []byte) bool {
+	return bytes.HasPrefix(data, magic) && len(data) >= len(magic)+SALT_SIZE+chacha20poly1305.NonceSizeX
+}
+
+// ReadSalt returns the salt of a sealed file to derive its key
+func ReadSalt(sealed []byte) ([]byte, error) {
+	if !IsSealed(sealed) {
+		return nil, ErrNotSealed
+	}
+
+	return sealed[len(magic) : len(magic)+SALT_SIZE], nil
+}
+
+func (k *Key) Seal(data []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(k.value)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	_, err = rand.Read(nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	var sealed []byte
+	sealed = append(sealed, magic...)
+	sealed = append(sealed, k.Salt...)
+	sealed = append(sealed, nonce...)
+	return aead.Seal(sealed, nonce, data, magic), nil
+}
+
+func (k *Key) Open(sealed []byte) ([]byte, error) {
+	salt, err := ReadSalt(sealed)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(salt, k.Salt) {
+		return nil, ErrInvalidPassword
+	}
+
+	aead, err := chacha20poly1305.NewX(k.value)
+	if err != nil {
+		return nil, err
+	}
+
+	start := len(magic) + SALT_SIZE
+	nonce := sealed[start : start+chacha20poly1305.NonceSizeX]
+	data, err := aead.Open(nil, nonce, sealed[start+chacha20poly1305.NonceSizeX:], magic)
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+
+	return data, nil
+}
+
+// WriteFile replaces filename only once data is fully written and only the user can read it
+func WriteFile(filename string, data []byte) error {
+	tmpFilename := filename + ".tmp"
+	err := ioutil.WriteFile(tmpFilename, data, 0600)
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, filename)
+}
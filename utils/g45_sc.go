@@ -69,18 +69,20 @@ type G45_FAT struct {
 }
 
 func (asset *G45_FAT) Print() {
-	fmt.Println("SCID: ", asset.SCID)
-	fmt.Println("Private: ", asset.Private)
-	fmt.Println("Minter: ", asset.Minter)
-	fmt.Println("Timestamp: ", asset.Timestamp)
-	fmt.Println("Collection SCID: ", asset.Collection)
-	fmt.Println("Frozen Metadata: ", asset.FrozenMetadata)
-	fmt.Println("Frozen Collection: ", asset.FrozenCollection)
-	fmt.Println("Metadata Format: ", asset.MetadataFormat)
-	fmt.Println("Metadata: ", asset.Metadata)
-	fmt.Println("Max Supply: ", asset.MaxSupply)
-	fmt.Println("Total Supply: ", asset.TotalSupply)
-	fmt.Println("Decimals: ", asset.Decimals)
+	PrintFields(
+		Field{Name: "SCID", Value: asset.SCID},
+		Field{Name: "Private", Value: asset.Private},
+		Field{Name: "Minter", Value: asset.Minter},
+		Field{Name: "Timestamp", Value: asset.Timestamp},
+		Field{Name: "Collection SCID", Value: asset.Collection},
+		Field{Name: "Frozen Metadata", Value: asset.FrozenMetadata},
+		Field{Name: "Frozen Collection", Value: asset.FrozenCollection},
+		Field{Name: "Metadata Format", Value: asset.MetadataFormat},
+		Field{Name: "Metadata", Value: asset.Metadata},
+		Field{Name: "Max Supply", Value: asset.MaxSupply},
+		Field{Name: "Total Supply", Value: asset.TotalSupply},
+		Field{Name: "Decimals", Value: asset.Decimals},
+	)
 }
 
 func (asset *G45_FAT) JsonMetadata() (map[string]interface{}, error) {
@@ -175,14 +177,16 @@ type G45_C struct {
 }
 
 func (asset *G45_C) Print() {
-	fmt.Println("SCID: ", asset.SCID)
-	fmt.Println("Frozen Assets: ", asset.FrozenAssets)
-	fmt.Println("Frozen Metadata: ", asset.FrozenMetadata)
-	fmt.Println("Metadata Format: ", asset.MetadataFormat)
-	fmt.Println("Metadata: ", asset.Metadata)
-	fmt.Println("Owner: ", asset.Owner)
-	fmt.Println("Original Owner: ", asset.OriginalOwner)
-	fmt.Println("Timestamp: ", asset.Timestamp)
+	PrintFields(
+		Field{Name: "SCID", Value: asset.SCID},
+		Field{Name: "Frozen Assets", Value: asset.FrozenAssets},
+		Field{Name: "Frozen Metadata", Value: asset.FrozenMetadata},
+		Field{Name: "Metadata Format", Value: asset.MetadataFormat},
+		Field{Name: "Metadata", Value: asset.Metadata},
+		Field{Name: "Owner", Value: asset.Owner},
+		Field{Name: "Original Owner", Value: asset.OriginalOwner},
+		Field{Name: "Timestamp", Value: asset.Timestamp},
+	)
 }
 
 func (asset *G45_C) JsonMetadata() (map[string]interface{}, error) {
@@ -286,20 +290,22 @@ type G45_AT struct {
 }
 
 func (asset *G45_AT) Print() {
-	fmt.Println("SCID: ", asset.SCID)
-	fmt.Println("Private: ", asset.Private)
-	fmt.Println("Minter: ", asset.Minter)
-	fmt.Println("Original Minter: ", asset.OriginalMinter)
-	fmt.Println("Timestamp: ", asset.Timestamp)
-	fmt.Println("Collection SCID: ", asset.Collection)
-	fmt.Println("Frozen Metadata: ", asset.FrozenMetadata)
-	fmt.Println("Frozen Mint: ", asset.FrozenMint)
-	fmt.Println("Frozen Collection: ", asset.FrozenCollection)
-	fmt.Println("Metadata Format: ", asset.MetadataFormat)
-	fmt.Println("Metadata: ", asset.Metadata)
-	fmt.Println("Max Supply: ", asset.MaxSupply)
-	fmt.Println("Total Supply: ", asset.TotalSupply)
-	fmt.Println("Decimals: ", asset.Decimals)
+	PrintFields(
+		Field{Name: "SCID", Value: asset.SCID},
+		Field{Name: "Private", Value: asset.Private},
+		Field{Name: "Minter", Value: asset.Minter},
+		Field{Name: "Original Minter", Value: asset.OriginalMinter},
+		Field{Name: "Timestamp", Value: asset.Timestamp},
+		Field{Name: "Collection SCID", Value: asset.Collection},
+		Field{Name: "Frozen Metadata", Value: asset.FrozenMetadata},
+		Field{Name: "Frozen Mint", Value: asset.FrozenMint},
+		Field{Name: "Frozen Collection", Value: asset.FrozenCollection},
+		Field{Name: "Metadata Format", Value: asset.MetadataFormat},
+		Field{Name: "Metadata", Value: asset.Metadata},
+		Field{Name: "Max Supply", Value: asset.MaxSupply},
+		Field{Name: "Total Supply", Value: asset.TotalSupply},
+		Field{Name: "Decimals", Value: asset.Decimals},
+	)
 }
 
 func (asset *G45_AT) JsonMetadata() (map[string]interface{}, error) {
@@ -395,14 +401,16 @@ type G45_NFT struct {
 }
 
 func (asset *G45_NFT) Print() {
-	fmt.Println("SCID: ", asset.SCID)
-	fmt.Println("Private: ", asset.Private)
-	fmt.Println("Minter: ", asset.Minter)
-	fmt.Println("Timestamp: ", asset.Timestamp)
-	fmt.Println("Collection SCID: ", asset.Collection)
-	fmt.Println("Metadata Format: ", asset.MetadataFormat)
-	fmt.Println("Metadata: ", asset.Metadata)
-	fmt.Println("Owner: ", asset.Owner)
+	PrintFields(
+		Field{Name: "SCID", Value: asset.SCID},
+		Field{Name: "Private", Value: asset.Private},
+		Field{Name: "Minter", Value: asset.Minter},
+		Field{Name: "Timestamp", Value: asset.Timestamp},
+		Field{Name: "Collection SCID", Value: asset.Collection},
+		Field{Name: "Metadata Format", Value: asset.MetadataFormat},
+		Field{Name: "Metadata", Value: asset.Metadata},
+		Field{Name: "Owner", Value: asset.Owner},
+	)
 }
 
 func (asset *G45_NFT) JsonMetadata() (map[string]interface{}, error) {
@@ -0,0 +1,190 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	OUTPUT_TABLE = "table"
+	OUTPUT_JSON  = "json"
+	OUTPUT_CSV   = "csv"
+)
+
+var OUTPUTS = []string{OUTPUT_TABLE, OUTPUT_JSON, OUTPUT_CSV}
+
+// Output is the current output format used by PrintFields, PrintRecords and PrintError
+var Output = OUTPUT_TABLE
+
+func IsValidOutput(output string) bool {
+	for _, o := range OUTPUTS {
+		if o == output {
+			return true
+		}
+	}
+
+	return false
+}
+
+type Field struct {
+	Name  string
+	Value interface{}
+}
+
+// Record keeps fields ordered when encoded to json
+type Record []Field
+
+func (r Record) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString("{")
+	for i, field := range r {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+
+		key, err := json.Marshal(FieldKey(field.Name))
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteString(":")
+		buf.Write(value)
+	}
+	buf.WriteString("}")
+
+	return buf.Bytes(), nil
+}
+
+// FieldKey converts a display name to a json/csv key - "Collection SCID" -> "collectionScid"
+func FieldKey(name string) string {
+	words := strings.Fields(name)
+	if len(words) == 0 {
+		return "index"
+	}
+
+	key := strings.ToLower(words[0])
+	for _, word := range words[1:] {
+		word = strings.ToLower(word)
+		key += strings.ToUpper(word[:1]) + word[1:]
+	}
+
+	return key
+}
+
+func fieldValue(value interface{}) string {
+	if value == nil {
+		return ""
+	}
+
+	return fmt.Sprint(value)
+}
+
+func printJSON(v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		PrintError(os.Stdout, err)
+		return
+	}
+
+	fmt.Println(string(data))
+}
+
+// PrintFields prints a single object - one field per line in table output
+func PrintFields(fields ...Field) {
+	switch Output {
+	case OUTPUT_JSON:
+		printJSON(Record(fields))
+	case OUTPUT_CSV:
+		w := csv.NewWriter(os.Stdout)
+		var keys, values []string
+		for _, field := range fields {
+			keys = append(keys, FieldKey(field.Name))
+			values = append(values, fieldValue(field.Value))
+		}
+
+		w.Write(keys)
+		w.Write(values)
+		w.Flush()
+	default:
+		for _, field := range fields {
+			fmt.Printf("%s: %v\n", field.Name, field.Value)
+		}
+	}
+}
+
+// PrintRecords prints rows as a json array or csv lines - headers are used as keys
+func PrintRecords(headers []interface{}, rows [][]interface{}) {
+	switch Output {
+	case OUTPUT_JSON:
+		records := []Record{}
+		for _, row := range rows {
+			var record Record
+			for i, header := range headers {
+				var value interface{}
+				if i < len(row) {
+					value = row[i]
+				}
+
+				record = append(record, Field{Name: fieldValue(header), Value: value})
+			}
+
+			records = append(records, record)
+		}
+
+		printJSON(records)
+	case OUTPUT_CSV:
+		w := csv.NewWriter(os.Stdout)
+		var keys []string
+		for _, header := range headers {
+			keys = append(keys, FieldKey(fieldValue(header)))
+		}
+
+		w.Write(keys)
+		for _, row := range rows {
+			var values []string
+			for _, value := range row {
+				values = append(values, fieldValue(value))
+			}
+
+			w.Write(values)
+		}
+
+		w.Flush()
+	}
+}
+
+// PrintValue prints a single value - json output wraps it in an object with the given name
+func PrintValue(name string, value interface{}) {
+	if Output == OUTPUT_TABLE {
+		fmt.Println(value)
+		return
+	}
+
+	PrintFields(Field{Name: name, Value: value})
+}
+
+func PrintError(w io.Writer, err error) {
+	switch Output {
+	case OUTPUT_JSON:
+		data, _ := json.Marshal(map[string]string{"error": err.Error()})
+		fmt.Fprintln(w, string(data))
+	case OUTPUT_CSV:
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"error"})
+		cw.Write([]string{err.Error()})
+		cw.Flush()
+	default:
+		fmt.Fprintln(w, err)
+	}
+}